@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage/filestore"
+)
+
+// command is a maintenance task run against the data directory instead of
+// starting a server
+type command struct {
+	usage       string
+	description string
+	args        int // number of arguments, or -1 if the command parses its own flags
+	run         func(ctx context.Context, store *filestore.FileStore, args []string) error
+}
+
+// commands lists the available maintenance commands by name
+var commands = map[string]command{
+	"rename": {
+		usage:       "rename <entity> <new-name>",
+		description: "Rename an entity, rewriting relations and keeping the old name as an alias",
+		args:        2,
+		run:         runRename,
+	},
+	"restore": {
+		usage:       "restore <entity>",
+		description: "Restore the most recently deleted entity with this name from the trash",
+		args:        1,
+		run:         runRestore,
+	},
+	"merge": {
+		usage:       "merge <source> <target>",
+		description: "Merge the source entity into the target, moving observations and relations",
+		args:        2,
+		run:         runMerge,
+	},
+	"dedupe-relations": {
+		usage:       "dedupe-relations",
+		description: "Remove relations that repeat the endpoints and type of another",
+		args:        0,
+		run:         runDedupeRelations,
+	},
+	"path": {
+		usage:       "path <from> <to>",
+		description: "Show the shortest chain of relations connecting two entities",
+		args:        2,
+		run:         runPath,
+	},
+	"paths": {
+		usage:       "paths <from> <to>",
+		description: "List the paths of up to 4 relations connecting two entities",
+		args:        2,
+		run:         runPaths,
+	},
+	"export": {
+		usage:       "export [--format <format>] [flags]",
+		description: "Export the graph as dot, mermaid or graphml; --root, --depth and --type select part of it",
+		args:        -1,
+		run:         runExport,
+	},
+	"stats": {
+		usage:       "stats [--top <n>] [flags]",
+		description: "Report central entities, connected components, orphans and entities without observations",
+		args:        -1,
+		run:         runStats,
+	},
+}
+
+// runCommand executes the named maintenance command
+func runCommand(name string, store *filestore.FileStore, args []string) error {
+	cmd := commands[name]
+	if cmd.args >= 0 && len(args) != cmd.args {
+		return fmt.Errorf("usage: ghcp-memory-context %s", cmd.usage)
+	}
+	return cmd.run(context.Background(), store, args)
+}
+
+// printCommands writes the command list for the help output
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		log.Printf("  %-36s %s", commands[name].usage, commands[name].description)
+	}
+}
+
+func runRename(ctx context.Context, store *filestore.FileStore, args []string) error {
+	entity, err := store.RenameEntity(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	log.Printf("Renamed '%s' to '%s'", args[0], entity.Name)
+	return nil
+}
+
+func runMerge(ctx context.Context, store *filestore.FileStore, args []string) error {
+	entity, err := store.MergeEntities(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	log.Printf("Merged '%s' into '%s' (%d observations, aliases: %v)",
+		args[0], entity.Name, entity.GetObservationCount(), entity.Aliases)
+	return nil
+}
+
+func runRestore(ctx context.Context, store *filestore.FileStore, args []string) error {
+	entity, err := store.RestoreEntity(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	log.Printf("Restored '%s' (%d observations)", entity.Name, entity.GetObservationCount())
+	return nil
+}
+
+func runDedupeRelations(ctx context.Context, store *filestore.FileStore, args []string) error {
+	removed, err := store.DedupeRelations(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rel := range removed {
+		log.Printf("Removed duplicate relation %s %s %s (%s)", rel.From, rel.RelationType, rel.To, rel.ID)
+	}
+	log.Printf("Removed %d duplicate relations", len(removed))
+	return nil
+}
+
+func runPath(ctx context.Context, store *filestore.FileStore, args []string) error {
+	return explainConnection(ctx, store, args, false)
+}
+
+func runPaths(ctx context.Context, store *filestore.FileStore, args []string) error {
+	return explainConnection(ctx, store, args, true)
+}
+
+func explainConnection(ctx context.Context, store *filestore.FileStore, args []string, all bool) error {
+	text, err := graph.New(store).Explain(ctx, args[0], args[1], all, graph.Options{})
+	if err != nil {
+		return err
+	}
+	log.Print(text)
+	return nil
+}
+
+func runExport(ctx context.Context, store *filestore.FileStore, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "mermaid", "Output format: dot, mermaid or graphml")
+	root := flags.String("root", "", "Export only the subgraph around this entity")
+	depth := flags.Int("depth", graph.DefaultDepth, "Hops to follow from the root")
+	direction := flags.String("direction", "both", "Relations to follow from the root: out, in or both")
+	entityTypes := flags.String("type", "", "Comma-separated entity types to export")
+	relationTypes := flags.String("relation-types", "", "Comma-separated relation types to export")
+	output := flags.String("output", "", "File to write instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument '%s'", flags.Arg(0))
+	}
+
+	exportFormat, err := graph.ParseExportFormat(*format)
+	if err != nil {
+		return err
+	}
+	dir, err := graph.ParseDirection(*direction)
+	if err != nil {
+		return err
+	}
+
+	subgraph, err := graph.New(store).Export(ctx, graph.ExportOptions{
+		Root: *root,
+		Options: graph.Options{
+			Depth:         *depth,
+			Direction:     dir,
+			RelationTypes: splitList(*relationTypes),
+		},
+		EntityTypes: splitList(*entityTypes),
+	})
+	if err != nil {
+		return err
+	}
+	text, err := subgraph.Render(exportFormat)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.WriteString(text)
+		return err
+	}
+	if err := os.WriteFile(*output, []byte(text), 0644); err != nil {
+		return err
+	}
+	log.Printf("Exported %d entities and %d relations to %s", len(subgraph.Nodes), len(subgraph.Relations), *output)
+	return nil
+}
+
+func runStats(ctx context.Context, store *filestore.FileStore, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	top := flags.Int("top", graph.DefaultTop, "Number of central entities and components to list")
+	relationTypes := flags.String("relation-types", "", "Comma-separated relation types to count")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument '%s'", flags.Arg(0))
+	}
+	if *top < 1 || *top > graph.MaxTop {
+		return fmt.Errorf("--top must be between 1 and %d", graph.MaxTop)
+	}
+
+	stats, err := graph.New(store).Stats(ctx, graph.StatsOptions{
+		RelationTypes: splitList(*relationTypes),
+		Top:           *top,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(stats.Format())
+	return err
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -8,12 +8,16 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/tr4d3r/ghcp-memory-context/internal/api"
+	"github.com/tr4d3r/ghcp-memory-context/internal/embed"
 	"github.com/tr4d3r/ghcp-memory-context/internal/mcp"
 	"github.com/tr4d3r/ghcp-memory-context/internal/storage/filestore"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
 )
 
 // Server represents the MCP memory context server
@@ -23,22 +27,12 @@ type Server struct {
 	apiRouter *api.Router
 }
 
-// NewServer creates a new server instance
-func NewServer(port string, dataDir string) *Server {
+// NewServer creates a new server instance backed by an initialized store
+func NewServer(port string, store *filestore.FileStore) *Server {
 	if port == "" {
 		port = "8080"
 	}
 
-	if dataDir == "" {
-		dataDir = "./data"
-	}
-
-	// Initialize file store
-	store := filestore.NewFileStore(dataDir)
-	if err := store.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
-	}
-
 	// Create API router with the store
 	apiRouter := api.NewRouter(store)
 
@@ -112,10 +106,26 @@ func main() {
 	var dataDir string
 	var showVersion bool
 	var showHelp bool
+	var expiryGrace time.Duration
+	var sweepInterval time.Duration
+	var trashRetention time.Duration
+	var fuzziness int
+	var embedder string
+	var embeddingModel string
+	var minSimilarity float64
+	var workspace string
 
 	flag.BoolVar(&mcpStdio, "mcp-stdio", false, "Run in MCP stdio mode for integration with MCP clients")
 	flag.StringVar(&port, "port", "", "Server port (default: 8080, env: PORT)")
 	flag.StringVar(&dataDir, "data-dir", "", "Data storage directory (default: ./.memory-context, env: DATA_DIR)")
+	flag.DurationVar(&expiryGrace, "expiry-grace", 24*time.Hour, "How long expired facts are kept on disk before being purged (env: EXPIRY_GRACE)")
+	flag.DurationVar(&sweepInterval, "sweep-interval", time.Hour, "How often expired facts are purged, 0 disables (env: SWEEP_INTERVAL)")
+	flag.DurationVar(&trashRetention, "trash-retention", filestore.DefaultTrashRetention, "How long deleted entities can be restored (env: TRASH_RETENTION)")
+	flag.IntVar(&fuzziness, "fuzziness", textutil.DefaultFuzziness, "Most typos tolerated per search word, 0 for exact words (env: FUZZINESS)")
+	flag.StringVar(&embedder, "embedder", "hash", "Embedder for semantic search: hash (offline) or the URL of a local embedding endpoint, e.g. http://localhost:11434/api/embed (env: EMBEDDER)")
+	flag.StringVar(&embeddingModel, "embedding-model", "", "Model name sent to the embedding endpoint (env: EMBEDDING_MODEL)")
+	flag.Float64Var(&minSimilarity, "min-similarity", filestore.DefaultMinSimilarity, "Least cosine similarity of a semantic match (env: MIN_SIMILARITY)")
+	flag.StringVar(&workspace, "workspace", "", "Directory that working trees checked for stale facts must lie within (default: current directory, env: WORKSPACE)")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.BoolVar(&showHelp, "help", false, "Show help information")
 	flag.Parse()
@@ -130,15 +140,20 @@ func main() {
 		log.Println("")
 		log.Println("Usage:")
 		log.Println("  ghcp-memory-context [options]")
+		log.Println("  ghcp-memory-context [options] <command> [args]")
 		log.Println("")
 		log.Println("Options:")
 		flag.PrintDefaults()
 		log.Println("")
+		log.Println("Commands:")
+		printCommands()
+		log.Println("")
 		log.Println("Examples:")
 		log.Println("  ghcp-memory-context                    # Start HTTP server")
 		log.Println("  ghcp-memory-context --mcp-stdio        # Start MCP stdio server")
 		log.Println("  ghcp-memory-context --port 3000        # Custom port")
 		log.Println("  ghcp-memory-context --data-dir /path   # Custom data directory")
+		log.Println("  ghcp-memory-context merge AuthService auth_service  # Merge duplicate entities")
 		return
 	}
 
@@ -149,9 +164,49 @@ func main() {
 	if dataDir == "" {
 		dataDir = os.Getenv("DATA_DIR")
 	}
+	if workspace == "" {
+		workspace = os.Getenv("WORKSPACE")
+	}
+	if workspace == "" {
+		workspace = "."
+	}
+	if value := os.Getenv("EXPIRY_GRACE"); value != "" && !isFlagSet("expiry-grace") {
+		if d, err := time.ParseDuration(value); err == nil {
+			expiryGrace = d
+		}
+	}
+	if value := os.Getenv("SWEEP_INTERVAL"); value != "" && !isFlagSet("sweep-interval") {
+		if d, err := time.ParseDuration(value); err == nil {
+			sweepInterval = d
+		}
+	}
+
+	if value := os.Getenv("TRASH_RETENTION"); value != "" && !isFlagSet("trash-retention") {
+		if d, err := time.ParseDuration(value); err == nil {
+			trashRetention = d
+		}
+	}
+	if value := os.Getenv("FUZZINESS"); value != "" && !isFlagSet("fuzziness") {
+		if n, err := strconv.Atoi(value); err == nil {
+			fuzziness = n
+		}
+	}
+	if value := os.Getenv("EMBEDDER"); value != "" && !isFlagSet("embedder") {
+		embedder = value
+	}
+	if value := os.Getenv("EMBEDDING_MODEL"); value != "" && !isFlagSet("embedding-model") {
+		embeddingModel = value
+	}
+	if value := os.Getenv("MIN_SIMILARITY"); value != "" && !isFlagSet("min-similarity") {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			minSimilarity = f
+		}
+	}
 
-	// Handle legacy positional argument for data directory
-	if dataDir == "" && len(flag.Args()) > 0 {
+	// A leading positional argument is either a command or, for backwards
+	// compatibility, the data directory
+	_, isCommand := commands[flag.Arg(0)]
+	if !isCommand && dataDir == "" && len(flag.Args()) > 0 {
 		dataDir = flag.Args()[0]
 	}
 
@@ -171,19 +226,56 @@ func main() {
 	if err := store.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
+	store.SetTrashRetention(trashRetention)
+	store.SetFuzziness(fuzziness)
+	if embedder != "hash" {
+		if !strings.HasPrefix(embedder, "http://") && !strings.HasPrefix(embedder, "https://") {
+			log.Fatalf("Invalid embedder %q: use hash or an http(s) URL", embedder)
+		}
+		store.SetEmbedder(embed.NewHTTPEmbedder(embedder, embeddingModel), minSimilarity)
+	} else {
+		store.SetEmbedder(embed.NewHashEmbedder(0), minSimilarity)
+	}
+
+	if isCommand {
+		if err := runCommand(flag.Arg(0), store, flag.Args()[1:]); err != nil {
+			log.Fatalf("%s failed: %v", flag.Arg(0), err)
+		}
+		return
+	}
+
+	// Purge expired facts in the background
+	if sweepInterval > 0 {
+		sweepCtx, stopSweeper := context.WithCancel(context.Background())
+		defer stopSweeper()
+		store.StartExpirySweeper(sweepCtx, sweepInterval, expiryGrace)
+	}
 
 	if mcpStdio {
 		// Run MCP stdio server
 		log.Printf("Starting MCP stdio server (data directory: %s)", dataDir)
 		mcpServer := mcp.NewStdioServer(store)
+		mcpServer.SetWorkspace(workspace)
 		if err := mcpServer.Run(); err != nil {
 			log.Fatalf("MCP stdio server error: %v", err)
 		}
 	} else {
 		// Run HTTP server
-		server := NewServer(port, dataDir)
+		server := NewServer(port, store)
+		server.apiRouter.SetWorkspace(workspace)
 		if err := server.Start(); err != nil {
 			log.Fatalf("Server error: %v", err)
 		}
 	}
 }
+
+// isFlagSet reports whether a flag was explicitly provided on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// handleEntityAttributes handles requests to /entities/{name}/attributes.
+// PATCH merges the JSON object in the body into the entity's attributes;
+// null values remove attributes.
+func (r *Router) handleEntityAttributes(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName string) {
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		if err.Error() == "entity '"+entityName+"' not found" {
+			r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
+		}
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		attributes := entity.Attributes
+		if attributes == nil {
+			attributes = map[string]any{}
+		}
+		r.writeSuccessResponse(w, attributes, "Attributes retrieved successfully")
+	case http.MethodPatch:
+		if err := validateJSONRequest(req); err != nil {
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var changes map[string]any
+		if err := json.NewDecoder(req.Body).Decode(&changes); err != nil {
+			r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload: expected an object of attributes")
+			return
+		}
+
+		// Work on a copy so a rejected change leaves the cached entity untouched
+		updated := entity.Clone()
+		updated.SetAttributes(changes)
+
+		if err := r.store.UpdateEntity(ctx, updated); err != nil {
+			if storage.IsInvalidInput(err) {
+				r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			} else {
+				r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update attributes: "+err.Error())
+			}
+			return
+		}
+
+		r.writeSuccessResponse(w, updated, "Attributes updated successfully")
+	default:
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -4,15 +4,38 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 // CreateEntityRequest represents the request payload for creating an entity
 type CreateEntityRequest struct {
-	Name         string   `json:"name"`
-	EntityType   string   `json:"entityType"`
-	Observations []string `json:"observations,omitempty"`
+	Name         string             `json:"name"`
+	EntityType   string             `json:"entityType"`
+	Observations []ObservationInput `json:"observations,omitempty"`
+	Aliases      []string           `json:"aliases,omitempty"`
+	Attributes   map[string]any     `json:"attributes,omitempty"`
+}
+
+// ObservationInput is an observation of a new entity, given either as its
+// text or as an object with the text and fields such as tags
+type ObservationInput struct {
+	Text string `json:"text"`
+	ObservationFields
+}
+
+// UnmarshalJSON accepts a plain string as well as an object
+func (in *ObservationInput) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*in = ObservationInput{Text: text}
+		return nil
+	}
+	type fields ObservationInput
+	return json.Unmarshal(data, (*fields)(in))
 }
 
 // UpdateEntityRequest represents the request payload for updating an entity
@@ -23,8 +46,29 @@ type UpdateEntityRequest struct {
 
 // AddObservationRequest represents the request payload for adding an observation
 type AddObservationRequest struct {
-	Text   string `json:"text"`
-	Source string `json:"source,omitempty"`
+	Text string `json:"text"`
+	ObservationFields
+}
+
+// RenameEntityRequest represents the request payload for renaming an entity
+type RenameEntityRequest struct {
+	NewName string `json:"newName"`
+}
+
+// MergeEntityRequest represents the request payload for merging an entity
+// into another
+type MergeEntityRequest struct {
+	Into string `json:"into"`
+}
+
+// UpdateObservationRequest represents the request payload for changing an
+// existing observation; omitted fields are left unchanged
+type UpdateObservationRequest struct {
+	Text       *string  `json:"text,omitempty"`
+	Author     string   `json:"author,omitempty"`
+	Importance *int     `json:"importance,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	Pinned     *bool    `json:"pinned,omitempty"`
 }
 
 // handleEntities handles requests to /entities
@@ -41,16 +85,21 @@ func (r *Router) handleEntities(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// handleEntityByName handles requests to /entities/{name}
+// handleEntityByName handles requests to /entities/{name} and its sub-resources
 func (r *Router) handleEntityByName(w http.ResponseWriter, req *http.Request) {
 	ctx := context.Background()
-	entityName := extractPathParam(req, "/entities/")
+	entityName, subPath, _ := strings.Cut(extractPathParam(req, "/entities/"), "/")
 
 	if entityName == "" {
 		r.writeErrorResponse(w, http.StatusBadRequest, "Entity name is required")
 		return
 	}
 
+	if subPath != "" {
+		r.handleEntitySubresource(w, req, ctx, entityName, subPath)
+		return
+	}
+
 	switch req.Method {
 	case http.MethodGet:
 		r.handleGetEntity(w, req, ctx, entityName)
@@ -70,11 +119,187 @@ func (r *Router) handleEntityByName(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// handleListEntities lists all entities with optional filtering
+// handleEntitySubresource handles requests to /entities/{name}/{subresource}
+func (r *Router) handleEntitySubresource(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName, subPath string) {
+	resource, rest, _ := strings.Cut(subPath, "/")
+
+	switch {
+	case resource == "observations" && rest == "":
+		if req.Method != http.MethodPost {
+			r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		r.handleAddObservation(w, req, ctx, entityName)
+	case resource == "observations":
+		r.handleObservationByID(w, req, ctx, entityName, rest)
+	case resource == "aliases":
+		r.handleEntityAliases(w, req, ctx, entityName, rest)
+	case resource == "attributes" && rest == "":
+		r.handleEntityAttributes(w, req, ctx, entityName)
+	case (resource == "rename" || resource == "merge") && rest == "":
+		if req.Method != http.MethodPost {
+			r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if resource == "rename" {
+			r.handleRenameEntity(w, req, ctx, entityName)
+		} else {
+			r.handleMergeEntity(w, req, ctx, entityName)
+		}
+	default:
+		r.writeErrorResponse(w, http.StatusNotFound, "Resource not found")
+	}
+}
+
+// handleObservationByID handles requests to /entities/{name}/observations/{id}
+// and its history and lineage views
+func (r *Router) handleObservationByID(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName, path string) {
+	observationID, action, _ := strings.Cut(path, "/")
+	if action != "" && action != "history" && action != "lineage" {
+		r.writeErrorResponse(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		if err.Error() == "entity '"+entityName+"' not found" {
+			r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
+		}
+		return
+	}
+	if req.Method != http.MethodGet {
+		// Change a copy so a rejected update leaves the cached entity untouched
+		entity = entity.Clone()
+	}
+
+	observation := entity.FindObservation(observationID)
+	if observation == nil {
+		r.writeErrorResponse(w, http.StatusNotFound, "Observation not found")
+		return
+	}
+
+	if action != "" && req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	switch action {
+	case "history":
+		r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"data":          observation.History(),
+			"message":       "Observation history retrieved successfully",
+			"count":         len(observation.Revisions) + 1,
+			"entity":        entity.Name,
+			"observationId": observation.ID,
+		})
+		return
+	case "lineage":
+		lineage := entity.Lineage(observation.ID)
+		r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"data":          lineage,
+			"message":       "Observation lineage retrieved successfully",
+			"count":         len(lineage),
+			"entity":        entity.Name,
+			"observationId": observation.ID,
+		})
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		r.writeSuccessResponse(w, observation, "Observation retrieved successfully")
+	case http.MethodPatch:
+		r.handleUpdateObservation(w, req, ctx, entity, observation)
+	case http.MethodDelete:
+		entity.RemoveObservation(observationID)
+		if err := r.store.UpdateEntity(ctx, entity); err != nil {
+			r.writeUpdateEntityError(w, err)
+			return
+		}
+		r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"message": "Observation deleted successfully",
+		})
+	default:
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleUpdateObservation edits an existing observation in place, recording
+// text changes as revisions
+func (r *Router) handleUpdateObservation(w http.ResponseWriter, req *http.Request, ctx context.Context, entity *models.Entity, observation *models.Observation) {
+	if err := validateJSONRequest(req); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var updateReq UpdateObservationRequest
+	if err := json.NewDecoder(req.Body).Decode(&updateReq); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	// Validate changes on a copy so an invalid update leaves the entity untouched
+	updated := *observation
+	if updateReq.Text != nil {
+		updated.Text = *updateReq.Text
+	}
+	if updateReq.Importance != nil {
+		updated.Importance = *updateReq.Importance
+	}
+	if updateReq.Confidence != nil {
+		updated.Confidence = *updateReq.Confidence
+	}
+	if updateReq.Pinned != nil {
+		updated.Pinned = *updateReq.Pinned
+	}
+
+	if err := updated.Validate(); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+		return
+	}
+
+	if updateReq.Text != nil {
+		if _, err := entity.UpdateObservation(observation.ID, *updateReq.Text, updateReq.Author); err != nil {
+			r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+			return
+		}
+	}
+	observation.Importance = updated.Importance
+	observation.Confidence = updated.Confidence
+	observation.Pinned = updated.Pinned
+	entity.LastModified = time.Now()
+	if err := r.store.UpdateEntity(ctx, entity); err != nil {
+		r.writeUpdateEntityError(w, err)
+		return
+	}
+
+	r.writeSuccessResponse(w, observation, "Observation updated successfully")
+}
+
+// handleListEntities lists all entities with optional filtering by type,
+// attributes (attr=key:value, repeatable) and observation text (q=)
 func (r *Router) handleListEntities(w http.ResponseWriter, req *http.Request, ctx context.Context) {
+	attributes, err := parseMapQueryParam(req, "attr")
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	entityType := parseQueryParam(req, "type")
+	query := parseQueryParam(req, "q")
+	filter := storage.EntityFilter{
+		EntityType:  &entityType,
+		Attributes:  attributes,
+		SearchQuery: &query,
+		Limit:       parseIntQueryParam(req, "limit", 0),
+		Offset:      parseIntQueryParam(req, "offset", 0),
+		SortBy:      parseQueryParam(req, "sort"),
+		SortOrder:   parseQueryParam(req, "order"),
+	}
 
-	entities, err := r.store.ListEntities(ctx, entityType)
+	entities, err := r.store.ListEntitiesFiltered(ctx, filter)
 	if err != nil {
 		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list entities: "+err.Error())
 		return
@@ -107,18 +332,34 @@ func (r *Router) handleCreateEntity(w http.ResponseWriter, req *http.Request, ct
 
 	// Create entity
 	entity := models.NewEntity(createReq.Name, createReq.EntityType)
+	for _, alias := range createReq.Aliases {
+		entity.AddAlias(alias)
+	}
+	if len(createReq.Attributes) > 0 {
+		entity.SetAttributes(createReq.Attributes)
+	}
 
 	// Add observations if provided
-	for _, obsText := range createReq.Observations {
-		if obsText != "" {
-			entity.AddObservation(obsText)
+	for _, input := range createReq.Observations {
+		if input.Text == "" {
+			continue
+		}
+		observation, err := input.buildObservation(input.Text)
+		if err == nil {
+			_, err = addObservation(entity, observation)
+		}
+		if err != nil {
+			r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+			return
 		}
 	}
 
 	// Store entity
 	if err := r.store.CreateEntity(ctx, entity); err != nil {
-		if err.Error() == "entity '"+createReq.Name+"' already exists" {
+		if storage.IsAlreadyExists(err) {
 			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		} else if storage.IsInvalidInput(err) {
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		} else {
 			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to create entity: "+err.Error())
 		}
@@ -163,6 +404,8 @@ func (r *Router) handleUpdateEntity(w http.ResponseWriter, req *http.Request, ct
 		}
 		return
 	}
+	// Change a copy so a rejected update leaves the cached entity untouched
+	entity = entity.Clone()
 
 	var updateReq UpdateEntityRequest
 	if err := json.NewDecoder(req.Body).Decode(&updateReq); err != nil {
@@ -175,40 +418,74 @@ func (r *Router) handleUpdateEntity(w http.ResponseWriter, req *http.Request, ct
 		entity.EntityType = updateReq.EntityType
 	}
 
-	// Replace observations if provided
+	// Replace observations if provided, keeping existing observations (and
+	// their IDs and history) whose text is unchanged
 	if updateReq.Observations != nil {
-		entity.Observations = make([]models.Observation, 0)
+		existing := make(map[string]models.Observation, len(entity.Observations))
+		for _, obs := range entity.Observations {
+			existing[obs.Text] = obs
+		}
+
+		entity.Observations = make([]models.Observation, 0, len(updateReq.Observations))
 		for _, obsText := range updateReq.Observations {
-			if obsText != "" {
+			if obsText == "" {
+				continue
+			}
+			if obs, ok := existing[obsText]; ok {
+				entity.AppendObservation(obs)
+				delete(existing, obsText)
+			} else {
 				entity.AddObservation(obsText)
 			}
 		}
+		// Facts superseded by a dropped observation resurface
+		entity.PruneSupersessionLinks()
 	}
 
 	// Save updated entity
 	if err := r.store.UpdateEntity(ctx, entity); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update entity: "+err.Error())
+		r.writeUpdateEntityError(w, err)
 		return
 	}
 
 	r.writeSuccessResponse(w, entity, "Entity updated successfully")
 }
 
-// handleDeleteEntity deletes an entity
+// writeUpdateEntityError reports a failed entity update, as a bad request
+// when the entity breaks validation or its type's rules
+func (r *Router) writeUpdateEntityError(w http.ResponseWriter, err error) {
+	if storage.IsInvalidInput(err) {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update entity: "+err.Error())
+}
+
+// handleDeleteEntity moves an entity to the trash. The policy query
+// parameter decides what happens to relations that reference it: reject
+// (default), cascade or detach.
 func (r *Router) handleDeleteEntity(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName string) {
-	// Check if entity exists
-	if !r.store.EntityExists(entityName) {
-		r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+	policy, err := storage.ParseDeletePolicy(parseQueryParam(req, "policy"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := r.store.DeleteEntity(ctx, entityName); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete entity: "+err.Error())
+	if err := r.store.DeleteEntityWithPolicy(ctx, entityName, policy); err != nil {
+		switch {
+		case storage.IsNotFound(err):
+			r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		case storage.IsReferenced(err):
+			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete entity: "+err.Error())
+		}
 		return
 	}
 
 	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"message": "Entity deleted successfully",
+		"message": "Entity moved to trash",
+		"policy":  policy,
 	})
 }
 
@@ -229,6 +506,8 @@ func (r *Router) handleAddObservation(w http.ResponseWriter, req *http.Request,
 		}
 		return
 	}
+	// Change a copy so a rejected observation leaves the cached entity untouched
+	entity = entity.Clone()
 
 	var addObsReq AddObservationRequest
 	if err := json.NewDecoder(req.Body).Decode(&addObsReq); err != nil {
@@ -244,18 +523,93 @@ func (r *Router) handleAddObservation(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
+	// Build and validate the observation
+	observation, err := addObsReq.buildObservation(addObsReq.Text)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+		return
+	}
+
 	// Add observation
-	if addObsReq.Source != "" {
-		entity.AddObservationWithSource(addObsReq.Text, addObsReq.Source)
-	} else {
-		entity.AddObservation(addObsReq.Text)
+	if _, err := addObservation(entity, observation); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+		return
 	}
 
 	// Save updated entity
 	if err := r.store.UpdateEntity(ctx, entity); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update entity: "+err.Error())
+		r.writeUpdateEntityError(w, err)
 		return
 	}
 
 	r.writeSuccessResponse(w, entity, "Observation added successfully")
 }
+
+// handleRenameEntity renames an entity, keeping the old name as an alias
+func (r *Router) handleRenameEntity(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName string) {
+	if err := validateJSONRequest(req); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var renameReq RenameEntityRequest
+	if err := json.NewDecoder(req.Body).Decode(&renameReq); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if err := validateRequiredFields(map[string]string{"newName": renameReq.NewName}); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entity, err := r.store.RenameEntity(ctx, entityName, renameReq.NewName)
+	if err != nil {
+		r.writeStructuralError(w, err)
+		return
+	}
+
+	r.writeSuccessResponse(w, entity, "Entity renamed successfully")
+}
+
+// handleMergeEntity merges an entity into another, moving its observations
+// and relations and keeping its name as an alias of the target
+func (r *Router) handleMergeEntity(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName string) {
+	if err := validateJSONRequest(req); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var mergeReq MergeEntityRequest
+	if err := json.NewDecoder(req.Body).Decode(&mergeReq); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if err := validateRequiredFields(map[string]string{"into": mergeReq.Into}); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entity, err := r.store.MergeEntities(ctx, entityName, mergeReq.Into)
+	if err != nil {
+		r.writeStructuralError(w, err)
+		return
+	}
+
+	r.writeSuccessResponse(w, entity, "Entities merged successfully")
+}
+
+// writeStructuralError maps rename and merge failures to HTTP status codes
+func (r *Router) writeStructuralError(w http.ResponseWriter, err error) {
+	switch {
+	case storage.IsNotFound(err):
+		r.writeErrorResponse(w, http.StatusNotFound, err.Error())
+	case storage.IsAlreadyExists(err):
+		r.writeErrorResponse(w, http.StatusConflict, err.Error())
+	case storage.IsInvalidInput(err):
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		r.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
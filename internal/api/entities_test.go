@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage/filestore"
+)
+
+// setupTestRouter returns a router over a new file store, restricted to the
+// given entity types if there are any
+func setupTestRouter(t *testing.T, types ...schema.TypeDefinition) *Router {
+	store := filestore.NewFileStore(t.TempDir())
+	if err := store.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize FileStore: %v", err)
+	}
+
+	if len(types) > 0 {
+		registry, err := schema.NewRegistry(types)
+		if err != nil {
+			t.Fatalf("Failed to build registry: %v", err)
+		}
+		store.SetTypeRegistry(registry)
+	}
+
+	return NewRouter(store)
+}
+
+// serviceType requires services to name their owner in an attribute and a tag
+var serviceType = schema.TypeDefinition{
+	Name:         "service",
+	RequiredTags: []string{"owner"},
+	AttributeSchema: &schema.Schema{
+		Type:       "object",
+		Required:   []string{"owner"},
+		Properties: map[string]*schema.Schema{"owner": {Type: "string"}},
+	},
+}
+
+func doRequest(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreateEntityWithTaggedObservations(t *testing.T) {
+	handler := setupTestRouter(t, serviceType).SetupRoutes()
+
+	rec := doRequest(handler, http.MethodPost, "/entities", `{
+		"name": "payments",
+		"entityType": "service",
+		"attributes": {"owner": "team-payments"},
+		"observations": [{"text": "settles card payments", "tags": ["owner"]}]
+	}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected tagged observations to be accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Plain strings are untagged, which the type does not allow
+	rec = doRequest(handler, http.MethodPost, "/entities", `{
+		"name": "billing",
+		"entityType": "service",
+		"attributes": {"owner": "team-billing"},
+		"observations": ["sends invoices"]
+	}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected untagged observations to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRememberValidatesNewEntity(t *testing.T) {
+	handler := setupTestRouter(t, serviceType).SetupRoutes()
+
+	// Required attributes and tags are checked on the entity as created
+	rec := doRequest(handler, http.MethodPost, "/memory/remember", `{
+		"entityName": "payments",
+		"entityType": "service",
+		"observation": "settles card payments",
+		"tags": ["owner"],
+		"attributes": {"owner": "team-payments"}
+	}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the fact to be remembered, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// A rejected fact leaves no entity behind
+	rec = doRequest(handler, http.MethodPost, "/memory/remember", `{
+		"entityName": "billing",
+		"entityType": "service",
+		"observation": "sends invoices",
+		"attributes": {"owner": "team-billing"}
+	}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected the untagged fact to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doRequest(handler, http.MethodGet, "/entities/billing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no entity to be created, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestReplaceObservationsResurfacesSuperseded(t *testing.T) {
+	handler := setupTestRouter(t).SetupRoutes()
+
+	rec := doRequest(handler, http.MethodPost, "/memory/remember", `{"entityName": "api", "observation": "we use REST"}`)
+	var remembered struct {
+		Observation struct {
+			ID string `json:"id"`
+		} `json:"observation"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&remembered); err != nil || remembered.Observation.ID == "" {
+		t.Fatalf("Failed to remember fact: %v", err)
+	}
+	rec = doRequest(handler, http.MethodPost, "/memory/remember",
+		`{"entityName": "api", "observation": "we use gRPC", "supersedes": ["`+remembered.Observation.ID+`"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to supersede fact: %d: %s", rec.Code, rec.Body)
+	}
+
+	// Replacing the observations drops the superseding one
+	rec = doRequest(handler, http.MethodPut, "/entities/api", `{"observations": ["we use REST"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Failed to replace observations: %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(handler, http.MethodGet, "/memory/recall?entity=api", "")
+	var recalled struct {
+		Data struct {
+			Observations []struct {
+				Text string `json:"text"`
+			} `json:"observations"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&recalled); err != nil {
+		t.Fatalf("Failed to decode recall: %v", err)
+	}
+	if obs := recalled.Data.Observations; len(obs) != 1 || obs[0].Text != "we use REST" {
+		t.Errorf("Expected the superseded fact to resurface, got %+v", obs)
+	}
+}
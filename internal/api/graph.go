@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// handleGraphNeighbors handles the /graph/neighbors endpoint
+// It lists the entities one relation away from an entity, optionally limited
+// by direction (out, in or both) and relation types
+func (r *Router) handleGraphNeighbors(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	name := parseQueryParam(req, "entity")
+	if name == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Query parameter 'entity' is required")
+		return
+	}
+
+	opts, ok := r.parseGraphOptions(w, req)
+	if !ok {
+		return
+	}
+
+	neighbors, err := graph.New(r.store).Neighbors(ctx, name, opts)
+	if err != nil {
+		r.writeGraphError(w, err)
+		return
+	}
+	if neighbors == nil {
+		neighbors = []graph.Neighbor{}
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    neighbors,
+		"message": "Neighbors retrieved successfully",
+		"count":   len(neighbors),
+	})
+}
+
+// handleGraphSubgraph handles the /graph/subgraph endpoint
+// It returns the entities within depth hops of a root entity together with
+// the relations connecting them
+func (r *Router) handleGraphSubgraph(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	root := parseQueryParam(req, "root")
+	if root == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Query parameter 'root' is required")
+		return
+	}
+
+	opts, ok := r.parseGraphOptions(w, req)
+	if !ok {
+		return
+	}
+	opts.Depth = parseIntQueryParam(req, "depth", graph.DefaultDepth)
+	if opts.Depth < 1 || opts.Depth > graph.MaxDepth {
+		r.writeErrorResponse(w, http.StatusBadRequest, "depth must be between 1 and 5")
+		return
+	}
+	opts.MaxNodes = parseIntQueryParam(req, "limit", graph.DefaultMaxNodes)
+
+	subgraph, err := graph.New(r.store).Subgraph(ctx, root, opts)
+	if err != nil {
+		r.writeGraphError(w, err)
+		return
+	}
+
+	r.writeSuccessResponse(w, subgraph, "Subgraph retrieved successfully")
+}
+
+// handleGraphPath handles the /graph/path endpoint
+// It returns the shortest path between two entities, weighted by relation
+// weight if weighted=true
+func (r *Router) handleGraphPath(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	from, to, ok := r.parsePathEndpoints(w, req)
+	if !ok {
+		return
+	}
+	opts, ok := r.parseGraphOptions(w, req)
+	if !ok {
+		return
+	}
+	opts.Weighted = parseBoolQueryParam(req, "weighted", false)
+
+	path, err := graph.New(r.store).ShortestPath(ctx, from, to, opts)
+	if err != nil {
+		r.writeGraphError(w, err)
+		return
+	}
+
+	r.writeSuccessResponse(w, path, "Path retrieved successfully")
+}
+
+// handleGraphPaths handles the /graph/paths endpoint
+// It lists the simple paths of at most depth hops between two entities,
+// shortest first (cheapest first if weighted=true)
+func (r *Router) handleGraphPaths(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	from, to, ok := r.parsePathEndpoints(w, req)
+	if !ok {
+		return
+	}
+	opts, ok := r.parseGraphOptions(w, req)
+	if !ok {
+		return
+	}
+	opts.Weighted = parseBoolQueryParam(req, "weighted", false)
+	opts.Depth = parseIntQueryParam(req, "depth", graph.DefaultPathDepth)
+	if opts.Depth < 1 || opts.Depth > graph.MaxDepth {
+		r.writeErrorResponse(w, http.StatusBadRequest, "depth must be between 1 and 5")
+		return
+	}
+	opts.MaxPaths = parseIntQueryParam(req, "limit", graph.DefaultMaxPaths)
+
+	paths, err := graph.New(r.store).AllPaths(ctx, from, to, opts)
+	if err != nil {
+		r.writeGraphError(w, err)
+		return
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    paths,
+		"message": "Paths retrieved successfully",
+		"count":   len(paths),
+	})
+}
+
+// GraphQueryRequest represents the request payload for a graph query
+type GraphQueryRequest struct {
+	Query string `json:"query"`
+}
+
+// handleGraphQuery handles the /graph/query endpoint
+// It runs a pattern query such as
+// (a:service)-[:depends_on*1..3]->(b) WHERE b.name =~ "db" and returns the
+// matches as a table
+func (r *Router) handleGraphQuery(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	if err := validateJSONRequest(req); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var queryReq GraphQueryRequest
+	if err := json.NewDecoder(req.Body).Decode(&queryReq); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if queryReq.Query == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	result, err := graph.New(r.store).Query(ctx, queryReq.Query)
+	if err != nil {
+		var queryErr *graph.QueryError
+		if errors.As(err, &queryErr) || errors.Is(err, graph.ErrQueryTooComplex) {
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r.writeGraphError(w, err)
+		return
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    result,
+		"message": "Query executed successfully",
+		"count":   len(result.Rows),
+	})
+}
+
+// handleGraphExport handles the /graph/export endpoint
+// It renders the whole graph, or the subgraph around root, as Graphviz DOT,
+// a Mermaid flowchart or GraphML, optionally limited to entity types
+func (r *Router) handleGraphExport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	format, err := graph.ParseExportFormat(parseQueryParam(req, "format"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts, ok := r.parseGraphOptions(w, req)
+	if !ok {
+		return
+	}
+	opts.Depth = parseIntQueryParam(req, "depth", graph.DefaultDepth)
+	if opts.Depth < 1 || opts.Depth > graph.MaxDepth {
+		r.writeErrorResponse(w, http.StatusBadRequest, "depth must be between 1 and 5")
+		return
+	}
+	opts.MaxNodes = parseIntQueryParam(req, "limit", graph.DefaultMaxNodes)
+
+	subgraph, err := graph.New(r.store).Export(ctx, graph.ExportOptions{
+		Root:        parseQueryParam(req, "root"),
+		Options:     opts,
+		EntityTypes: parseListQueryParam(req, "type"),
+	})
+	if err != nil {
+		r.writeGraphError(w, err)
+		return
+	}
+	text, err := subgraph.Render(format)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to render graph: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(text))
+}
+
+// handleGraphStats handles the /graph/stats endpoint
+// It reports the most central entities, connected components, orphans and
+// entities without observations, optionally counting only some relation types
+func (r *Router) handleGraphStats(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	top := parseIntQueryParam(req, "top", graph.DefaultTop)
+	if top < 1 || top > graph.MaxTop {
+		r.writeErrorResponse(w, http.StatusBadRequest, "top must be between 1 and 100")
+		return
+	}
+
+	stats, err := graph.New(r.store).Stats(ctx, graph.StatsOptions{
+		RelationTypes: parseListQueryParam(req, "types"),
+		Top:           top,
+	})
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to compute graph statistics: "+err.Error())
+		return
+	}
+
+	r.writeSuccessResponse(w, stats, "Graph statistics computed successfully")
+}
+
+// parsePathEndpoints reads the required from and to query parameters,
+// writing an error response if either is missing
+func (r *Router) parsePathEndpoints(w http.ResponseWriter, req *http.Request) (string, string, bool) {
+	from, to := parseQueryParam(req, "from"), parseQueryParam(req, "to")
+	if from == "" || to == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Query parameters 'from' and 'to' are required")
+		return "", "", false
+	}
+	return from, to, true
+}
+
+// parseGraphOptions reads the direction and types query parameters shared by
+// the graph endpoints, writing an error response if they are invalid
+func (r *Router) parseGraphOptions(w http.ResponseWriter, req *http.Request) (graph.Options, bool) {
+	direction, err := graph.ParseDirection(parseQueryParam(req, "direction"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return graph.Options{}, false
+	}
+	return graph.Options{
+		Direction:     direction,
+		RelationTypes: parseListQueryParam(req, "types"),
+	}, true
+}
+
+// writeGraphError reports a failed traversal
+func (r *Router) writeGraphError(w http.ResponseWriter, err error) {
+	if storage.IsNotFound(err) {
+		r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		return
+	}
+	if errors.Is(err, graph.ErrNoPath) {
+		r.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to traverse graph: "+err.Error())
+}
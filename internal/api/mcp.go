@@ -3,11 +3,17 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/provenance"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 // MCPResource represents an MCP resource
@@ -80,6 +86,13 @@ func (r *Router) handleMCPResources(w http.ResponseWriter, req *http.Request) {
 		MimeType:    "application/json",
 	})
 
+	resources = append(resources, MCPResource{
+		URI:         "memory://graph",
+		Name:        "Entity Graph",
+		Description: "Mermaid flowchart of all entities and their relations",
+		MimeType:    "application/json",
+	})
+
 	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"resources": resources,
 	})
@@ -109,11 +122,16 @@ func (r *Router) handleMCPResourceByURI(w http.ResponseWriter, req *http.Request
 			r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
 			return
 		}
+		entity = entity.FilterObservations(storage.ObservationFilter{}.Matches)
 
 		content := MCPContent{
 			Type: "text",
-			Text: fmt.Sprintf("Entity: %s\nType: %s\nObservations:\n", entity.Name, entity.EntityType),
+			Text: fmt.Sprintf("Entity: %s\nType: %s\n", entity.Name, entity.EntityType),
 		}
+		if len(entity.Aliases) > 0 {
+			content.Text += fmt.Sprintf("Aliases: %s\n", strings.Join(entity.Aliases, ", "))
+		}
+		content.Text += "Observations:\n"
 
 		for i, obs := range entity.Observations {
 			content.Text += fmt.Sprintf("%d. %s (source: %s)\n", i+1, obs.Text, obs.Source)
@@ -143,9 +161,28 @@ func (r *Router) handleMCPResourceByURI(w http.ResponseWriter, req *http.Request
 		}
 
 		for _, rel := range relations.Relations {
-			content.Text += fmt.Sprintf("- %s %s %s\n", rel.From, rel.RelationType, rel.To)
+			content.Text += fmt.Sprintf("- %s %s %s%s\n", rel.From, rel.RelationType, rel.To, rel.Details())
 		}
 
+		r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"contents": []MCPContent{content},
+		})
+	} else if resourceURI == "memory://graph" {
+		subgraph, err := graph.New(r.store).Export(ctx, graph.ExportOptions{})
+		if err != nil {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to export graph: "+err.Error())
+			return
+		}
+		text, err := subgraph.Render(graph.FormatMermaid)
+		if err != nil {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to render graph: "+err.Error())
+			return
+		}
+
+		content := MCPContent{
+			Type: "text",
+			Text: text,
+		}
 		r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
 			"contents": []MCPContent{content},
 		})
@@ -186,11 +223,43 @@ func (r *Router) handleMCPRememberFact(w http.ResponseWriter, req *http.Request)
 	entityType, _ := toolCall.Arguments["entityType"].(string)
 	source, _ := toolCall.Arguments["source"].(string)
 
+	// Build and validate the observation before touching storage
+	ttl, _ := toolCall.Arguments["ttl"].(string)
+	pinned, _ := toolCall.Arguments["pinned"].(bool)
+	fields := ObservationFields{
+		Source:     source,
+		Tags:       stringSliceArgument(toolCall.Arguments, "tags"),
+		Metadata:   stringMapArgument(toolCall.Arguments, "metadata"),
+		TTL:        ttl,
+		Importance: int(numberArgument(toolCall.Arguments, "importance")),
+		Confidence: numberArgument(toolCall.Arguments, "confidence"),
+		Pinned:     pinned,
+	}
+	prov, err := provenanceArgument(toolCall.Arguments, "provenance")
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: Invalid provenance: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+	fields.Provenance = prov
+	obs, err := fields.buildObservation(observation)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: Invalid observation: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+
 	// Use the same logic as /memory/remember
 	var entity *models.Entity
-	var err error
+	exists := r.store.EntityExists(entityName)
 
-	if r.store.EntityExists(entityName) {
+	if exists {
 		entity, err = r.store.GetEntity(ctx, entityName)
 		if err != nil {
 			result := MCPToolResult{
@@ -200,34 +269,43 @@ func (r *Router) handleMCPRememberFact(w http.ResponseWriter, req *http.Request)
 			r.writeJSONResponse(w, http.StatusInternalServerError, result)
 			return
 		}
+		// Change a copy so a rejected fact leaves the cached entity untouched
+		entity = entity.Clone()
 	} else {
 		if entityType == "" {
 			entityType = "memory"
 		}
 		entity = models.NewEntity(entityName, entityType)
-		if err := r.store.CreateEntity(ctx, entity); err != nil {
-			result := MCPToolResult{
-				Content: []MCPContent{{Type: "text", Text: "Error: Failed to create entity"}},
-				IsError: true,
-			}
-			r.writeJSONResponse(w, http.StatusInternalServerError, result)
-			return
-		}
 	}
 
 	// Add observation
-	if source != "" {
-		entity.AddObservationWithSource(observation, source)
-	} else {
-		entity.AddObservation(observation)
+	entity.AppendObservation(obs)
+	for _, alias := range stringSliceArgument(toolCall.Arguments, "aliases") {
+		entity.AddAlias(alias)
+	}
+	if attributes, ok := toolCall.Arguments["attributes"].(map[string]interface{}); ok {
+		entity.SetAttributes(attributes)
 	}
 
-	if err := r.store.UpdateEntity(ctx, entity); err != nil {
+	// A new entity is created complete, so it is validated as stored
+	if exists {
+		if err := r.store.UpdateEntity(ctx, entity); err != nil {
+			r.writeMCPUpdateError(w, err)
+			return
+		}
+	} else if err := r.store.CreateEntity(ctx, entity); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case storage.IsAlreadyExists(err):
+			status = http.StatusConflict
+		case storage.IsInvalidInput(err):
+			status = http.StatusBadRequest
+		}
 		result := MCPToolResult{
-			Content: []MCPContent{{Type: "text", Text: "Error: Failed to update entity"}},
+			Content: []MCPContent{{Type: "text", Text: "Error: Failed to create entity: " + err.Error()}},
 			IsError: true,
 		}
-		r.writeJSONResponse(w, http.StatusInternalServerError, result)
+		r.writeJSONResponse(w, status, result)
 		return
 	}
 
@@ -258,6 +336,40 @@ func (r *Router) handleMCPRecallFacts(w http.ResponseWriter, req *http.Request)
 
 	entityName, _ := toolCall.Arguments["entityName"].(string)
 	entityType, _ := toolCall.Arguments["entityType"].(string)
+	pinnedOnly, _ := toolCall.Arguments["pinnedOnly"].(bool)
+	showLineage, _ := toolCall.Arguments["showLineage"].(bool)
+	includeSuperseded, _ := toolCall.Arguments["includeSuperseded"].(bool)
+	filePath, _ := toolCall.Arguments["filePath"].(string)
+	symbol, _ := toolCall.Arguments["symbol"].(string)
+	filter := storage.ObservationFilter{
+		EntityType:        entityType,
+		Tags:              stringSliceArgument(toolCall.Arguments, "tags"),
+		Metadata:          stringMapArgument(toolCall.Arguments, "metadata"),
+		Attributes:        stringMapArgument(toolCall.Arguments, "attributes"),
+		MinImportance:     int(numberArgument(toolCall.Arguments, "minImportance")),
+		PinnedOnly:        pinnedOnly,
+		IncludeSuperseded: includeSuperseded,
+		FilePath:          filePath,
+		Symbol:            symbol,
+	}
+
+	rankArg, _ := toolCall.Arguments["rank"].(string)
+	rank, err := graph.ParseRank(rankArg)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var scores map[string]float64
+	if rank == graph.RankCentrality && entityName == "" {
+		if scores, err = graph.New(r.store).PageRank(ctx); err != nil {
+			result := MCPToolResult{
+				Content: []MCPContent{{Type: "text", Text: "Error ranking entities"}},
+				IsError: true,
+			}
+			r.writeJSONResponse(w, http.StatusInternalServerError, result)
+			return
+		}
+	}
 
 	if entityName != "" {
 		// Recall specific entity
@@ -269,12 +381,59 @@ func (r *Router) handleMCPRecallFacts(w http.ResponseWriter, req *http.Request)
 			r.writeJSONResponse(w, http.StatusOK, result)
 			return
 		}
+		full := entity
+		entity = entity.FilterObservations(filter.Matches)
+		models.SortObservations(entity.Observations)
 
 		var text strings.Builder
 		text.WriteString(fmt.Sprintf("Entity: %s (%s)\n", entity.Name, entity.EntityType))
+		if len(entity.Aliases) > 0 {
+			text.WriteString(fmt.Sprintf("Also known as: %s\n", strings.Join(entity.Aliases, ", ")))
+		}
+		if len(entity.Attributes) > 0 {
+			text.WriteString(fmt.Sprintf("Attributes: %s\n", entity.AttributeSummary()))
+		}
 		text.WriteString("Observations:\n")
 		for i, obs := range entity.Observations {
-			text.WriteString(fmt.Sprintf("%d. %s\n", i+1, obs.Text))
+			text.WriteString(fmt.Sprintf("%d. %s%s {id: %s}\n", i+1, obs.Text, formatObservationDetails(obs), obs.ID))
+			if showLineage {
+				for _, linked := range full.Lineage(obs.ID) {
+					if linked.ID == obs.ID {
+						continue
+					}
+					state := "current"
+					if linked.IsSuperseded() {
+						state = "superseded"
+					}
+					text.WriteString(fmt.Sprintf("   ↳ [%s %s] %s\n", state, linked.CreatedAt.Format("2006-01-02"), linked.Text))
+				}
+			}
+		}
+
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: text.String()}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+	} else if filter.HasObservationCriteria() {
+		// Recall matching observations across entities
+		results, err := r.store.SearchObservationsFiltered(ctx, "", filter)
+		if err != nil {
+			result := MCPToolResult{
+				Content: []MCPContent{{Type: "text", Text: "Error retrieving observations"}},
+				IsError: true,
+			}
+			r.writeJSONResponse(w, http.StatusInternalServerError, result)
+			return
+		}
+		if scores != nil {
+			graph.RankResults(results, scores)
+		}
+
+		var text strings.Builder
+		text.WriteString("Matching observations:\n")
+		for i, res := range results {
+			text.WriteString(fmt.Sprintf("%d. [%s] %s: %s%s\n",
+				i+1, res.EntityType, res.EntityName, res.Observation.Text, formatObservationDetails(res.Observation)))
 		}
 
 		result := MCPToolResult{
@@ -282,8 +441,11 @@ func (r *Router) handleMCPRecallFacts(w http.ResponseWriter, req *http.Request)
 		}
 		r.writeJSONResponse(w, http.StatusOK, result)
 	} else {
-		// List entities by type
-		entities, err := r.store.ListEntities(ctx, entityType)
+		// List entities by type and attributes
+		entities, err := r.store.ListEntitiesFiltered(ctx, storage.EntityFilter{
+			EntityType: &entityType,
+			Attributes: filter.Attributes,
+		})
 		if err != nil {
 			result := MCPToolResult{
 				Content: []MCPContent{{Type: "text", Text: "Error retrieving entities"}},
@@ -292,6 +454,9 @@ func (r *Router) handleMCPRecallFacts(w http.ResponseWriter, req *http.Request)
 			r.writeJSONResponse(w, http.StatusInternalServerError, result)
 			return
 		}
+		if scores != nil {
+			graph.RankEntities(entities, scores)
+		}
 
 		var text strings.Builder
 		if entityType != "" {
@@ -300,9 +465,23 @@ func (r *Router) handleMCPRecallFacts(w http.ResponseWriter, req *http.Request)
 			text.WriteString("All entities:\n")
 		}
 
+		var pinned []string
 		for _, entity := range entities {
 			text.WriteString(fmt.Sprintf("- %s (%s): %d observations\n",
 				entity.Name, entity.EntityType, entity.GetObservationCount()))
+			for _, obs := range entity.PinnedObservations() {
+				if filter.Matches(obs) {
+					pinned = append(pinned, fmt.Sprintf("- %s: %s\n", entity.Name, obs.Text))
+				}
+			}
+		}
+
+		// Pinned facts are always surfaced, even in an overview
+		if len(pinned) > 0 {
+			text.WriteString("\nPinned facts:\n")
+			for _, line := range pinned {
+				text.WriteString(line)
+			}
 		}
 
 		result := MCPToolResult{
@@ -335,9 +514,44 @@ func (r *Router) handleMCPSearchMemory(w http.ResponseWriter, req *http.Request)
 	}
 
 	entityType, _ := toolCall.Arguments["entityType"].(string)
+	pinnedOnly, _ := toolCall.Arguments["pinnedOnly"].(bool)
+	filePath, _ := toolCall.Arguments["filePath"].(string)
+	filter := storage.ObservationFilter{
+		EntityType:    entityType,
+		Tags:          stringSliceArgument(toolCall.Arguments, "tags"),
+		Metadata:      stringMapArgument(toolCall.Arguments, "metadata"),
+		Attributes:    stringMapArgument(toolCall.Arguments, "attributes"),
+		MinImportance: int(numberArgument(toolCall.Arguments, "minImportance")),
+		PinnedOnly:    pinnedOnly,
+		FilePath:      filePath,
+	}
+	if fuzziness, ok := toolCall.Arguments["fuzziness"].(float64); ok {
+		edits := max(int(fuzziness), 0)
+		filter.Fuzziness = &edits
+	}
+	modeArg, _ := toolCall.Arguments["mode"].(string)
+	mode, err := storage.ParseSearchMode(modeArg)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Mode = mode
+	kinds, err := storage.ParseHitKinds(stringSliceArgument(toolCall.Arguments, "kinds"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	results, err := r.store.SearchObservations(ctx, query, entityType)
+	hits, err := r.store.SearchAll(ctx, query, kinds, filter)
 	if err != nil {
+		var syntaxErr *search.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			r.writeJSONResponse(w, http.StatusBadRequest, MCPToolResult{
+				Content: []MCPContent{{Type: "text", Text: "Error: " + syntaxErr.Error()}},
+				IsError: true,
+			})
+			return
+		}
 		result := MCPToolResult{
 			Content: []MCPContent{{Type: "text", Text: "Error searching memory"}},
 			IsError: true,
@@ -349,12 +563,14 @@ func (r *Router) handleMCPSearchMemory(w http.ResponseWriter, req *http.Request)
 	var text strings.Builder
 	text.WriteString(fmt.Sprintf("Search results for '%s':\n", query))
 
-	if len(results) == 0 {
+	if len(hits) == 0 {
 		text.WriteString("No results found.\n")
+		if suggestions, err := r.store.SuggestSearch(ctx, query, filter); err == nil && len(suggestions) > 0 {
+			text.WriteString("Did you mean: " + strings.Join(suggestions, " | ") + "\n")
+		}
 	} else {
-		for i, result := range results {
-			text.WriteString(fmt.Sprintf("%d. [%s] %s: %s\n",
-				i+1, result.EntityType, result.EntityName, result.Observation.Text))
+		for i, hit := range hits {
+			text.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatSearchHit(hit)))
 		}
 	}
 
@@ -400,14 +616,12 @@ func (r *Router) handleMCPForgetFact(w http.ResponseWriter, req *http.Request) {
 		r.writeJSONResponse(w, http.StatusOK, result)
 		return
 	}
+	// Change a copy so a rejected change leaves the cached entity untouched
+	entity = entity.Clone()
 
 	if entity.RemoveObservation(observationID) {
 		if err := r.store.UpdateEntity(ctx, entity); err != nil {
-			result := MCPToolResult{
-				Content: []MCPContent{{Type: "text", Text: "Error updating entity"}},
-				IsError: true,
-			}
-			r.writeJSONResponse(w, http.StatusInternalServerError, result)
+			r.writeMCPUpdateError(w, err)
 			return
 		}
 
@@ -422,3 +636,610 @@ func (r *Router) handleMCPForgetFact(w http.ResponseWriter, req *http.Request) {
 		r.writeJSONResponse(w, http.StatusOK, result)
 	}
 }
+
+// handleMCPSupersedeFact handles the /mcp/tools/supersede_fact endpoint
+// MCP tool for replacing facts while keeping their lineage
+func (r *Router) handleMCPSupersedeFact(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	observation, _ := toolCall.Arguments["observation"].(string)
+	supersedes := stringSliceArgument(toolCall.Arguments, "observationIds")
+	if entityName == "" || observation == "" || len(supersedes) == 0 {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName, observationIds and observation arguments are required")
+		return
+	}
+
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Entity not found"}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+	// Change a copy so a rejected change leaves the cached entity untouched
+	entity = entity.Clone()
+
+	source, _ := toolCall.Arguments["source"].(string)
+	fields := ObservationFields{
+		Source:     source,
+		Tags:       stringSliceArgument(toolCall.Arguments, "tags"),
+		Supersedes: supersedes,
+	}
+	obs, err := fields.buildObservation(observation)
+	if err == nil {
+		_, err = entity.Supersede(obs)
+	}
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+
+	if err := r.store.UpdateEntity(ctx, entity); err != nil {
+		r.writeMCPUpdateError(w, err)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: fmt.Sprintf("Successfully superseded %d fact(s) with: %s", len(supersedes), observation),
+		}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPUpdateFact handles the /mcp/tools/update_fact endpoint
+// MCP tool for correcting an observation in place
+func (r *Router) handleMCPUpdateFact(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	observationID, _ := toolCall.Arguments["observationId"].(string)
+	text, _ := toolCall.Arguments["text"].(string)
+	if entityName == "" || observationID == "" || text == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName, observationId and text arguments are required")
+		return
+	}
+	author, _ := toolCall.Arguments["author"].(string)
+
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Entity not found"}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+	// Change a copy so a rejected change leaves the cached entity untouched
+	entity = entity.Clone()
+
+	obs, err := entity.UpdateObservation(observationID, text, author)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+
+	if err := r.store.UpdateEntity(ctx, entity); err != nil {
+		r.writeMCPUpdateError(w, err)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: fmt.Sprintf("Successfully updated fact %s (revision %d): %s", obs.ID, len(obs.Revisions)+1, obs.Text),
+		}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPFactHistory handles the /mcp/tools/fact_history endpoint
+// MCP tool for listing the revisions of an observation
+func (r *Router) handleMCPFactHistory(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	observationID, _ := toolCall.Arguments["observationId"].(string)
+	if entityName == "" || observationID == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName and observationId arguments are required")
+		return
+	}
+
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Entity not found"}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	obs := entity.FindObservation(observationID)
+	if obs == nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Observation not found"}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("History of fact %s:\n", obs.ID))
+	for i, rev := range obs.History() {
+		text.WriteString(fmt.Sprintf("%d. [%s by %s] %s\n", i+1, rev.Timestamp.Format(time.RFC3339), rev.Author, rev.Text))
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: text.String()}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPRenameEntity handles the /mcp/tools/rename_entity endpoint
+// MCP tool for renaming an entity or merging it into another
+func (r *Router) handleMCPRenameEntity(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	newName, _ := toolCall.Arguments["newName"].(string)
+	merge, _ := toolCall.Arguments["merge"].(bool)
+	if entityName == "" || newName == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName and newName arguments are required")
+		return
+	}
+
+	var entity *models.Entity
+	var err error
+	if merge {
+		entity, err = r.store.MergeEntities(ctx, entityName, newName)
+	} else {
+		entity, err = r.store.RenameEntity(ctx, entityName, newName)
+	}
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	text := fmt.Sprintf("Renamed '%s' to '%s'", entityName, entity.Name)
+	if merge {
+		text = fmt.Sprintf("Merged '%s' into '%s' (%d observations)", entityName, entity.Name, entity.GetObservationCount())
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: text}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPSetAttributes handles the /mcp/tools/set_attributes endpoint
+// MCP tool for setting or removing structured entity attributes
+func (r *Router) handleMCPSetAttributes(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	attributes, _ := toolCall.Arguments["attributes"].(map[string]interface{})
+	if entityName == "" || len(attributes) == 0 {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName and attributes arguments are required")
+		return
+	}
+
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Entity not found"}},
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	updated := entity.Clone()
+	updated.SetAttributes(attributes)
+	if err := r.store.UpdateEntity(ctx, updated); err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: fmt.Sprintf("Attributes of '%s': %s", updated.Name, updated.AttributeSummary())}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPExploreGraph handles the /mcp/tools/explore_graph endpoint
+// MCP tool for listing the entities and relations around an entity
+func (r *Router) handleMCPExploreGraph(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entityName, _ := toolCall.Arguments["entityName"].(string)
+	if entityName == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "entityName argument is required")
+		return
+	}
+
+	direction, _ := toolCall.Arguments["direction"].(string)
+	dir, err := graph.ParseDirection(direction)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subgraph, err := graph.New(r.store).Subgraph(ctx, entityName, graph.Options{
+		Depth:         int(numberArgument(toolCall.Arguments, "depth")),
+		Direction:     dir,
+		RelationTypes: stringSliceArgument(toolCall.Arguments, "relationTypes"),
+	})
+	if err != nil {
+		text, isError := "Error: "+err.Error(), true
+		if storage.IsNotFound(err) {
+			text, isError = "Entity not found", false
+		}
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: text}},
+			IsError: isError,
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: subgraph.Format()}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPExplainConnection handles the /mcp/tools/explain_connection endpoint
+// MCP tool for explaining how two entities are connected
+func (r *Router) handleMCPExplainConnection(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	from, _ := toolCall.Arguments["from"].(string)
+	to, _ := toolCall.Arguments["to"].(string)
+	if from == "" || to == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "from and to arguments are required")
+		return
+	}
+
+	direction, _ := toolCall.Arguments["direction"].(string)
+	dir, err := graph.ParseDirection(direction)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	weighted, _ := toolCall.Arguments["weighted"].(bool)
+	all, _ := toolCall.Arguments["allPaths"].(bool)
+
+	text, err := graph.New(r.store).Explain(ctx, from, to, all, graph.Options{
+		Depth:         int(numberArgument(toolCall.Arguments, "maxDepth")),
+		Direction:     dir,
+		RelationTypes: stringSliceArgument(toolCall.Arguments, "relationTypes"),
+		Weighted:      weighted,
+	})
+	if err != nil {
+		text, isError := "Error: "+err.Error(), true
+		if storage.IsNotFound(err) {
+			text, isError = "Entity not found: "+err.Error(), false
+		}
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: text}},
+			IsError: isError,
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: text}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPQueryGraph handles the /mcp/tools/query_graph endpoint
+// MCP tool for running a graph pattern query
+func (r *Router) handleMCPQueryGraph(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	query, _ := toolCall.Arguments["query"].(string)
+	if query == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "query argument is required")
+		return
+	}
+
+	queryResult, err := graph.New(r.store).Query(ctx, query)
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: queryResult.Format()}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// handleMCPFindStaleFacts handles the /mcp/tools/find_stale_facts endpoint
+// MCP tool for finding facts whose referenced code no longer exists
+func (r *Router) handleMCPFindStaleFacts(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	var toolCall MCPToolCall
+	if err := json.NewDecoder(req.Body).Decode(&toolCall); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	root, _ := toolCall.Arguments["root"].(string)
+	filePath, _ := toolCall.Arguments["filePath"].(string)
+
+	var checker *provenance.Checker
+	root, err := provenance.ResolveRoot(r.workspace, root)
+	if err == nil {
+		checker, err = provenance.NewChecker(root)
+	}
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusBadRequest, result)
+		return
+	}
+
+	results, err := r.store.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{FilePath: filePath})
+	if err != nil {
+		result := MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "Error retrieving observations"}},
+			IsError: true,
+		}
+		r.writeJSONResponse(w, http.StatusInternalServerError, result)
+		return
+	}
+
+	stale := checker.FindStale(results)
+
+	var text strings.Builder
+	if len(stale) == 0 {
+		text.WriteString("No stale facts found.\n")
+	} else {
+		text.WriteString(fmt.Sprintf("Found %d stale fact(s):\n", len(stale)))
+		for i, fact := range stale {
+			text.WriteString(fmt.Sprintf("%d. %s: %s {id: %s}\n   reason: %s\n",
+				i+1, fact.EntityName, fact.Observation.Text, fact.Observation.ID, fact.Reason))
+		}
+	}
+
+	result := MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: text.String()}},
+	}
+	r.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// stringSliceArgument extracts a list of strings from an MCP tool argument.
+// Both JSON arrays and comma-separated strings are accepted.
+func stringSliceArgument(args map[string]interface{}, key string) []string {
+	var values []string
+	switch raw := args[key].(type) {
+	case []interface{}:
+		for _, item := range raw {
+			if str, ok := item.(string); ok && strings.TrimSpace(str) != "" {
+				values = append(values, strings.TrimSpace(str))
+			}
+		}
+	case string:
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// stringMapArgument extracts a string map from an MCP tool argument object
+func stringMapArgument(args map[string]interface{}, key string) map[string]string {
+	raw, ok := args[key].(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[k] = fmt.Sprint(v)
+	}
+	return values
+}
+
+// provenanceArgument decodes an optional provenance object from an MCP tool argument
+func provenanceArgument(args map[string]interface{}, key string) (*models.Provenance, error) {
+	raw, ok := args[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var prov models.Provenance
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return nil, fmt.Errorf("provenance must be an object: %w", err)
+	}
+	return &prov, nil
+}
+
+// numberArgument extracts a numeric MCP tool argument, returning 0 if absent
+func numberArgument(args map[string]interface{}, key string) float64 {
+	value, _ := args[key].(float64)
+	return value
+}
+
+// writeMCPUpdateError reports a failed entity update as a tool error, with a
+// bad request status when the change breaks validation or the type's rules
+func (r *Router) writeMCPUpdateError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if storage.IsInvalidInput(err) {
+		status = http.StatusBadRequest
+	}
+	r.writeJSONResponse(w, status, MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: "Error: Failed to update entity: " + err.Error()}},
+		IsError: true,
+	})
+}
+
+// formatSearchHit renders a unified search hit as a line of tool output:
+// entity and relation hits as their highlighted snippet, observations as
+// search_memory always listed them
+func formatSearchHit(hit storage.SearchHit) string {
+	if hit.Kind == storage.HitObservation {
+		return fmt.Sprintf("[%s] %s: %s%s", hit.EntityType, hit.EntityName, hit.Observation.Text, formatObservationDetails(*hit.Observation))
+	}
+	line := "Entity " + hit.Snippet
+	if hit.Kind == storage.HitRelation {
+		line = "Relation " + hit.Snippet
+	}
+	if len(hit.Fields) > 0 {
+		line += " (matched " + strings.Join(hit.Fields, ", ") + ")"
+	}
+	return line
+}
+
+// formatObservationDetails renders tags and ranking flags as a suffix for text output
+func formatObservationDetails(obs models.Observation) string {
+	var details strings.Builder
+	if len(obs.Tags) > 0 {
+		details.WriteString(" [" + strings.Join(obs.Tags, ", ") + "]")
+	}
+
+	var flags []string
+	if obs.Pinned {
+		flags = append(flags, "pinned")
+	}
+	if obs.Importance != 0 {
+		flags = append(flags, fmt.Sprintf("importance %d/%d", obs.Importance, models.MaxImportance))
+	}
+	if obs.Confidence != 0 {
+		flags = append(flags, fmt.Sprintf("confidence %.2f", obs.Confidence))
+	}
+	if len(flags) > 0 {
+		details.WriteString(" (" + strings.Join(flags, ", ") + ")")
+	}
+	if obs.Provenance != nil {
+		details.WriteString(" @ " + obs.Provenance.String())
+	}
+	return details.String()
+}
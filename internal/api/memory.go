@@ -3,31 +3,168 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
+// ObservationFields holds the optional observation attributes shared by the
+// remember and add-observation payloads
+type ObservationFields struct {
+	Source     string             `json:"source,omitempty"`
+	Tags       []string           `json:"tags,omitempty"`
+	Metadata   map[string]string  `json:"metadata,omitempty"`
+	TTL        string             `json:"ttl,omitempty"`
+	ExpiresAt  *time.Time         `json:"expiresAt,omitempty"`
+	Importance int                `json:"importance,omitempty"`
+	Confidence float64            `json:"confidence,omitempty"`
+	Pinned     bool               `json:"pinned,omitempty"`
+	Supersedes []string           `json:"supersedes,omitempty"`
+	Provenance *models.Provenance `json:"provenance,omitempty"`
+}
+
 // RememberRequest represents the request payload for remembering a fact
 type RememberRequest struct {
-	EntityName  string `json:"entityName"`
-	EntityType  string `json:"entityType,omitempty"`
-	Observation string `json:"observation"`
-	Source      string `json:"source,omitempty"`
+	EntityName  string         `json:"entityName"`
+	EntityType  string         `json:"entityType,omitempty"`
+	Observation string         `json:"observation"`
+	Aliases     []string       `json:"aliases,omitempty"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+	ObservationFields
 }
 
 // RecallRequest represents the request payload for recalling facts
 type RecallRequest struct {
-	EntityName string `json:"entityName,omitempty"`
-	EntityType string `json:"entityType,omitempty"`
-	Query      string `json:"query,omitempty"`
+	EntityName        string            `json:"entityName,omitempty"`
+	EntityType        string            `json:"entityType,omitempty"`
+	Query             string            `json:"query,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	IncludeExpired    bool              `json:"includeExpired,omitempty"`
+	IncludeSuperseded bool              `json:"includeSuperseded,omitempty"`
+	MinImportance     int               `json:"minImportance,omitempty"`
+	PinnedOnly        bool              `json:"pinnedOnly,omitempty"`
+	FilePath          string            `json:"filePath,omitempty"`
+	Symbol            string            `json:"symbol,omitempty"`
+	Repository        string            `json:"repository,omitempty"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	Rank              string            `json:"rank,omitempty"`
 }
 
 // SearchRequest represents the request payload for searching memory
 type SearchRequest struct {
-	Query      string `json:"query"`
-	EntityType string `json:"entityType,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
+	Query             string            `json:"query"`
+	EntityType        string            `json:"entityType,omitempty"`
+	Limit             int               `json:"limit,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	IncludeExpired    bool              `json:"includeExpired,omitempty"`
+	IncludeSuperseded bool              `json:"includeSuperseded,omitempty"`
+	MinImportance     int               `json:"minImportance,omitempty"`
+	PinnedOnly        bool              `json:"pinnedOnly,omitempty"`
+	FilePath          string            `json:"filePath,omitempty"`
+	Symbol            string            `json:"symbol,omitempty"`
+	Repository        string            `json:"repository,omitempty"`
+	Attributes        map[string]string `json:"attributes,omitempty"`
+	Fuzziness         *int              `json:"fuzziness,omitempty"`
+	Mode              string            `json:"mode,omitempty"`
+}
+
+// parseObservationFilter builds an observation filter from the common
+// type, tag, meta, attr, fuzziness and mode query parameters
+func parseObservationFilter(req *http.Request) (storage.ObservationFilter, error) {
+	metadata, err := parseMapQueryParam(req, "meta")
+	if err != nil {
+		return storage.ObservationFilter{}, err
+	}
+	attributes, err := parseMapQueryParam(req, "attr")
+	if err != nil {
+		return storage.ObservationFilter{}, err
+	}
+	var fuzziness *int
+	if value := parseQueryParam(req, "fuzziness"); value != "" {
+		edits, err := strconv.Atoi(value)
+		if err != nil || edits < 0 {
+			return storage.ObservationFilter{}, fmt.Errorf("invalid fuzziness '%s': expected the number of edits to tolerate, such as 0, 1 or 2", value)
+		}
+		fuzziness = &edits
+	}
+	mode, err := storage.ParseSearchMode(parseQueryParam(req, "mode"))
+	if err != nil {
+		return storage.ObservationFilter{}, err
+	}
+
+	return storage.ObservationFilter{
+		EntityType:        parseQueryParam(req, "type"),
+		Tags:              parseListQueryParam(req, "tag"),
+		Metadata:          metadata,
+		IncludeExpired:    parseBoolQueryParam(req, "includeExpired", false),
+		IncludeSuperseded: parseBoolQueryParam(req, "includeSuperseded", false),
+		MinImportance:     parseIntQueryParam(req, "minImportance", 0),
+		PinnedOnly:        parseBoolQueryParam(req, "pinned", false),
+		FilePath:          parseQueryParam(req, "file"),
+		Symbol:            parseQueryParam(req, "symbol"),
+		Repository:        parseQueryParam(req, "repo"),
+		Attributes:        attributes,
+		Fuzziness:         fuzziness,
+		Mode:              mode,
+	}, nil
+}
+
+// buildObservation creates and validates an observation from the payload fields
+func (f ObservationFields) buildObservation(text string) (models.Observation, error) {
+	observation := models.NewObservation(text)
+	if f.Source != "" {
+		observation.Source = f.Source
+	}
+	observation.Tags = f.Tags
+	observation.Metadata = f.Metadata
+	observation.Importance = f.Importance
+	observation.Confidence = f.Confidence
+	observation.Pinned = f.Pinned
+	observation.Supersedes = f.Supersedes
+	observation.Provenance = f.Provenance
+
+	if err := applyExpiry(&observation, f.TTL, f.ExpiresAt); err != nil {
+		return observation, err
+	}
+	return observation, observation.Validate()
+}
+
+// addObservation appends an observation to the entity, linking it to any
+// observations it supersedes
+func addObservation(entity *models.Entity, observation models.Observation) (*models.Observation, error) {
+	if len(observation.Supersedes) > 0 {
+		return entity.Supersede(observation)
+	}
+	return entity.AppendObservation(observation), nil
+}
+
+// applyExpiry sets an observation's expiry from either a TTL such as "3d"
+// or an absolute expiry time
+func applyExpiry(obs *models.Observation, ttl string, expiresAt *time.Time) error {
+	if ttl != "" && expiresAt != nil {
+		return fmt.Errorf("specify either ttl or expiresAt, not both")
+	}
+
+	if ttl != "" {
+		duration, err := models.ParseTTL(ttl)
+		if err != nil {
+			return err
+		}
+		obs.SetTTL(duration)
+	}
+	if expiresAt != nil {
+		obs.ExpiresAt = expiresAt
+	}
+	return nil
 }
 
 // handleMemoryRemember handles the /memory/remember endpoint
@@ -60,48 +197,72 @@ func (r *Router) handleMemoryRemember(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	// Check if entity exists, create if it doesn't
+	// Build and validate the observation before touching storage
+	observation, err := rememberReq.buildObservation(rememberReq.Observation)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+		return
+	}
+
+	// Build the whole entity before storing it, so a new entity is validated
+	// with its observation, aliases and attributes
 	var entity *models.Entity
-	var err error
+	exists := r.store.EntityExists(rememberReq.EntityName)
 
-	if r.store.EntityExists(rememberReq.EntityName) {
+	if exists {
 		// Get existing entity
 		entity, err = r.store.GetEntity(ctx, rememberReq.EntityName)
 		if err != nil {
 			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
 			return
 		}
+		// Change a copy so a rejected observation leaves the cached entity untouched
+		entity = entity.Clone()
+	} else if len(observation.Supersedes) > 0 {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Cannot supersede observations of an entity that does not exist")
+		return
 	} else {
-		// Create new entity
 		entityType := rememberReq.EntityType
 		if entityType == "" {
 			entityType = "memory" // Default type
 		}
-
 		entity = models.NewEntity(rememberReq.EntityName, entityType)
-		if err := r.store.CreateEntity(ctx, entity); err != nil {
-			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to create entity: "+err.Error())
-			return
-		}
 	}
 
 	// Add the observation
-	if rememberReq.Source != "" {
-		entity.AddObservationWithSource(rememberReq.Observation, rememberReq.Source)
-	} else {
-		entity.AddObservation(rememberReq.Observation)
+	stored, err := addObservation(entity, observation)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid observation: "+err.Error())
+		return
+	}
+	for _, alias := range rememberReq.Aliases {
+		entity.AddAlias(alias)
+	}
+	if len(rememberReq.Attributes) > 0 {
+		entity.SetAttributes(rememberReq.Attributes)
 	}
 
-	// Update entity in storage
-	if err := r.store.UpdateEntity(ctx, entity); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update entity: "+err.Error())
+	// Create or update the entity in storage
+	save := r.store.UpdateEntity
+	if !exists {
+		save = r.store.CreateEntity
+	}
+	if err := save(ctx, entity); err != nil {
+		switch {
+		case storage.IsAlreadyExists(err):
+			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		case storage.IsInvalidInput(err):
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save entity: "+err.Error())
+		}
 		return
 	}
 
 	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"message":     "Memory stored successfully",
 		"entity":      entity.Name,
-		"observation": entity.Observations[len(entity.Observations)-1], // Return the newly added observation
+		"observation": stored, // Return the newly added observation
 	})
 }
 
@@ -123,41 +284,15 @@ func (r *Router) handleMemoryRecall(w http.ResponseWriter, req *http.Request) {
 // handleMemoryRecallGET handles GET requests to /memory/recall with query parameters
 func (r *Router) handleMemoryRecallGET(w http.ResponseWriter, req *http.Request, ctx context.Context) {
 	entityName := parseQueryParam(req, "entity")
-	entityType := parseQueryParam(req, "type")
 	query := parseQueryParam(req, "query")
 
-	if entityName != "" {
-		// Recall specific entity
-		entity, err := r.store.GetEntity(ctx, entityName)
-		if err != nil {
-			if err.Error() == "entity '"+entityName+"' not found" {
-				r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
-			} else {
-				r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
-			}
-			return
-		}
-
-		r.writeSuccessResponse(w, entity, "Entity recalled successfully")
-	} else if query != "" {
-		// Search across observations
-		results, err := r.store.SearchObservations(ctx, query, entityType)
-		if err != nil {
-			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
-			return
-		}
-
-		r.writeSuccessResponse(w, results, "Memory search completed")
-	} else {
-		// List entities by type
-		entities, err := r.store.ListEntities(ctx, entityType)
-		if err != nil {
-			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list entities: "+err.Error())
-			return
-		}
-
-		r.writeSuccessResponse(w, entities, "Entities recalled successfully")
+	filter, err := parseObservationFilter(req)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
+
+	r.recallMemory(w, ctx, entityName, query, filter, parseQueryParam(req, "rank"))
 }
 
 // handleMemoryRecallPOST handles POST requests to /memory/recall with JSON payload
@@ -173,11 +308,45 @@ func (r *Router) handleMemoryRecallPOST(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	if recallReq.EntityName != "" {
+	filter := storage.ObservationFilter{
+		EntityType:        recallReq.EntityType,
+		Tags:              recallReq.Tags,
+		Metadata:          recallReq.Metadata,
+		IncludeExpired:    recallReq.IncludeExpired,
+		IncludeSuperseded: recallReq.IncludeSuperseded,
+		MinImportance:     recallReq.MinImportance,
+		PinnedOnly:        recallReq.PinnedOnly,
+		FilePath:          recallReq.FilePath,
+		Symbol:            recallReq.Symbol,
+		Repository:        recallReq.Repository,
+		Attributes:        recallReq.Attributes,
+	}
+
+	r.recallMemory(w, ctx, recallReq.EntityName, recallReq.Query, filter, recallReq.Rank)
+}
+
+// recallMemory recalls a specific entity, searches observations or lists
+// entities depending on which criteria were supplied. With rank=centrality,
+// observations and entities are ordered by the PageRank of their entity.
+func (r *Router) recallMemory(w http.ResponseWriter, ctx context.Context, entityName, query string, filter storage.ObservationFilter, rank string) {
+	rank, err := graph.ParseRank(rank)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var scores map[string]float64
+	if rank == graph.RankCentrality && entityName == "" {
+		if scores, err = graph.New(r.store).PageRank(ctx); err != nil {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to rank entities: "+err.Error())
+			return
+		}
+	}
+
+	if entityName != "" {
 		// Recall specific entity
-		entity, err := r.store.GetEntity(ctx, recallReq.EntityName)
+		entity, err := r.store.GetEntity(ctx, entityName)
 		if err != nil {
-			if err.Error() == "entity '"+recallReq.EntityName+"' not found" {
+			if err.Error() == "entity '"+entityName+"' not found" {
 				r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
 			} else {
 				r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
@@ -185,23 +354,36 @@ func (r *Router) handleMemoryRecallPOST(w http.ResponseWriter, req *http.Request
 			return
 		}
 
+		// Hide expired facts, apply observation criteria and rank the rest
+		entity = entity.FilterObservations(filter.Matches)
+		models.SortObservations(entity.Observations)
+
 		r.writeSuccessResponse(w, entity, "Entity recalled successfully")
-	} else if recallReq.Query != "" {
+	} else if query != "" || filter.HasObservationCriteria() {
 		// Search across observations
-		results, err := r.store.SearchObservations(ctx, recallReq.Query, recallReq.EntityType)
+		results, err := r.store.SearchObservationsFiltered(ctx, query, filter)
 		if err != nil {
-			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+			r.writeSearchError(w, err)
 			return
 		}
+		if scores != nil {
+			graph.RankResults(results, scores)
+		}
 
 		r.writeSuccessResponse(w, results, "Memory search completed")
 	} else {
-		// List entities by type
-		entities, err := r.store.ListEntities(ctx, recallReq.EntityType)
+		// List entities by type and attributes
+		entities, err := r.store.ListEntitiesFiltered(ctx, storage.EntityFilter{
+			EntityType: &filter.EntityType,
+			Attributes: filter.Attributes,
+		})
 		if err != nil {
 			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list entities: "+err.Error())
 			return
 		}
+		if scores != nil {
+			graph.RankEntities(entities, scores)
+		}
 
 		r.writeSuccessResponse(w, entities, "Entities recalled successfully")
 	}
@@ -222,10 +404,38 @@ func (r *Router) handleMemorySearch(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// writeSearchError reports a failed search, as a bad request when the query
+// does not parse
+func (r *Router) writeSearchError(w http.ResponseWriter, err error) {
+	var syntaxErr *search.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		r.writeErrorResponse(w, http.StatusBadRequest, syntaxErr.Error())
+		return
+	}
+	r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+}
+
+// writeSearchResponse writes search results, with "did you mean"
+// suggestions when there are none
+func (r *Router) writeSearchResponse(w http.ResponseWriter, ctx context.Context, query string, filter storage.ObservationFilter, results []storage.SearchResult) {
+	response := map[string]interface{}{
+		"data":    results,
+		"message": "Memory search completed",
+		"count":   len(results),
+		"query":   query,
+	}
+	if len(results) == 0 {
+		if suggestions, err := r.store.SuggestSearch(ctx, query, filter); err == nil && len(suggestions) > 0 {
+			response["suggestions"] = suggestions
+			response["message"] = fmt.Sprintf("No results; did you mean '%s'?", suggestions[0])
+		}
+	}
+	r.writeJSONResponse(w, http.StatusOK, response)
+}
+
 // handleMemorySearchGET handles GET requests to /memory/search with query parameters
 func (r *Router) handleMemorySearchGET(w http.ResponseWriter, req *http.Request, ctx context.Context) {
 	query := parseQueryParam(req, "q")
-	entityType := parseQueryParam(req, "type")
 	limit := parseIntQueryParam(req, "limit", 50) // Default limit of 50
 
 	if query == "" {
@@ -233,9 +443,15 @@ func (r *Router) handleMemorySearchGET(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	results, err := r.store.SearchObservations(ctx, query, entityType)
+	filter, err := parseObservationFilter(req)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := r.store.SearchObservationsFiltered(ctx, query, filter)
 	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+		r.writeSearchError(w, err)
 		return
 	}
 
@@ -244,12 +460,7 @@ func (r *Router) handleMemorySearchGET(w http.ResponseWriter, req *http.Request,
 		results = results[:limit]
 	}
 
-	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"data":    results,
-		"message": "Memory search completed",
-		"count":   len(results),
-		"query":   query,
-	})
+	r.writeSearchResponse(w, ctx, query, filter, results)
 }
 
 // handleMemorySearchPOST handles POST requests to /memory/search with JSON payload
@@ -270,9 +481,34 @@ func (r *Router) handleMemorySearchPOST(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	results, err := r.store.SearchObservations(ctx, searchReq.Query, searchReq.EntityType)
+	filter := storage.ObservationFilter{
+		EntityType:        searchReq.EntityType,
+		Tags:              searchReq.Tags,
+		Metadata:          searchReq.Metadata,
+		IncludeExpired:    searchReq.IncludeExpired,
+		IncludeSuperseded: searchReq.IncludeSuperseded,
+		MinImportance:     searchReq.MinImportance,
+		PinnedOnly:        searchReq.PinnedOnly,
+		FilePath:          searchReq.FilePath,
+		Symbol:            searchReq.Symbol,
+		Repository:        searchReq.Repository,
+		Attributes:        searchReq.Attributes,
+		Fuzziness:         searchReq.Fuzziness,
+	}
+	if searchReq.Fuzziness != nil && *searchReq.Fuzziness < 0 {
+		r.writeErrorResponse(w, http.StatusBadRequest, "fuzziness must not be negative")
+		return
+	}
+	mode, err := storage.ParseSearchMode(searchReq.Mode)
 	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Mode = mode
+
+	results, err := r.store.SearchObservationsFiltered(ctx, searchReq.Query, filter)
+	if err != nil {
+		r.writeSearchError(w, err)
 		return
 	}
 
@@ -281,10 +517,55 @@ func (r *Router) handleMemorySearchPOST(w http.ResponseWriter, req *http.Request
 		results = results[:searchReq.Limit]
 	}
 
-	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"data":    results,
-		"message": "Memory search completed",
-		"count":   len(results),
-		"query":   searchReq.Query,
-	})
+	r.writeSearchResponse(w, ctx, searchReq.Query, filter, results)
+}
+
+// handleSearch handles GET /search, searching entities and relations as well
+// as observations. It takes the /memory/search parameters, plus kinds to
+// choose among entity, relation and observation hits; limit applies to each
+// kind.
+func (r *Router) handleSearch(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	query := parseQueryParam(req, "q")
+	if query == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Query parameter 'q' is required")
+		return
+	}
+	kinds, err := storage.ParseHitKinds(parseListQueryParam(req, "kinds"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseObservationFilter(req)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hits, err := r.store.SearchAll(ctx, query, kinds, filter)
+	if err != nil {
+		r.writeSearchError(w, err)
+		return
+	}
+	hits = storage.LimitHits(hits, parseIntQueryParam(req, "limit", 50))
+
+	response := map[string]interface{}{
+		"data":    hits,
+		"message": "Search completed",
+		"count":   len(hits),
+		"query":   query,
+	}
+	if len(hits) == 0 {
+		if suggestions, err := r.store.SuggestSearch(ctx, query, filter); err == nil && len(suggestions) > 0 {
+			response["suggestions"] = suggestions
+			response["message"] = fmt.Sprintf("No results; did you mean '%s'?", suggestions[0])
+		}
+	}
+	r.writeJSONResponse(w, http.StatusOK, response)
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/provenance"
+)
+
+// handleMemoryProvenance handles the /memory/provenance endpoint
+// It lists the facts recorded about a file, directory, symbol or repository
+func (r *Router) handleMemoryProvenance(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	filter, err := parseObservationFilter(req)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !filter.HasProvenanceCriteria() {
+		r.writeErrorResponse(w, http.StatusBadRequest, "At least one of 'file', 'symbol' or 'repo' is required")
+		return
+	}
+
+	results, err := r.store.SearchObservationsFiltered(ctx, "", filter)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+		return
+	}
+
+	r.writeSuccessResponse(w, results, "Provenance query completed")
+}
+
+// handleMemoryProvenanceStale handles the /memory/provenance/stale endpoint
+// It reports facts whose referenced file, line or symbol no longer exists in
+// the working tree given by the 'root' parameter, which must lie within the
+// workspace (defaults to the workspace itself)
+func (r *Router) handleMemoryProvenanceStale(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	root, err := provenance.ResolveRoot(r.workspace, parseQueryParam(req, "root"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	checker, err := provenance.NewChecker(root)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseObservationFilter(req)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := r.store.SearchObservationsFiltered(ctx, "", filter)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to search observations: "+err.Error())
+		return
+	}
+
+	stale := checker.FindStale(results)
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    stale,
+		"message": "Stale fact detection completed",
+		"count":   len(stale),
+		"root":    root,
+	})
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestProvenanceStaleRootStaysInWorkspace(t *testing.T) {
+	router := setupTestRouter(t)
+	workspace := t.TempDir()
+	router.SetWorkspace(workspace)
+	handler := router.SetupRoutes()
+
+	if rec := doRequest(handler, http.MethodGet, "/memory/provenance/stale", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the workspace to be checked by default, got %d: %s", rec.Code, rec.Body)
+	}
+	for _, root := range []string{"..", t.TempDir()} {
+		rec := doRequest(handler, http.MethodGet, "/memory/provenance/stale?root="+url.QueryEscape(root), "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected root %q outside the workspace to be rejected, got %d: %s", root, rec.Code, rec.Body)
+		}
+	}
+}
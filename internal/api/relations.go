@@ -4,22 +4,36 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 // CreateRelationRequest represents the request payload for creating a relation
 type CreateRelationRequest struct {
-	From         string `json:"from"`
-	To           string `json:"to"`
-	RelationType string `json:"relationType"`
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	RelationType string            `json:"relationType"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	Weight       float64           `json:"weight,omitempty"`
+	ValidFrom    *time.Time        `json:"validFrom,omitempty"`
+	ValidTo      *time.Time        `json:"validTo,omitempty"`
+	Source       string            `json:"source,omitempty"`
 }
 
 // UpdateRelationRequest represents the request payload for updating a relation
+// Omitted fields are left unchanged; properties replace the existing set
 type UpdateRelationRequest struct {
-	From         string `json:"from,omitempty"`
-	To           string `json:"to,omitempty"`
-	RelationType string `json:"relationType,omitempty"`
+	From         string            `json:"from,omitempty"`
+	To           string            `json:"to,omitempty"`
+	RelationType string            `json:"relationType,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	Weight       *float64          `json:"weight,omitempty"`
+	ValidFrom    *time.Time        `json:"validFrom,omitempty"`
+	ValidTo      *time.Time        `json:"validTo,omitempty"`
+	Source       *string           `json:"source,omitempty"`
 }
 
 // handleRelations handles requests to /relations
@@ -45,6 +59,10 @@ func (r *Router) handleRelationByID(w http.ResponseWriter, req *http.Request) {
 		r.writeErrorResponse(w, http.StatusBadRequest, "Relation ID is required")
 		return
 	}
+	if relationID == "dedupe" {
+		r.handleDedupeRelations(w, req, ctx)
+		return
+	}
 
 	switch req.Method {
 	case http.MethodGet:
@@ -58,34 +76,75 @@ func (r *Router) handleRelationByID(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// handleListRelations lists all relations with optional filtering
+// handleListRelations lists all relations with optional filtering by
+// endpoints, type, properties (prop=key:value, repeatable), minWeight, source
+// and validAt (RFC3339 or "now"). Relations with a registered inverse or
+// symmetric type are also listed in the other direction unless inverse=false.
 func (r *Router) handleListRelations(w http.ResponseWriter, req *http.Request, ctx context.Context) {
-	fromEntity := parseQueryParam(req, "from")
-	toEntity := parseQueryParam(req, "to")
-	relationType := parseQueryParam(req, "type")
+	filter := storage.RelationFilter{
+		From:         r.canonicalEntityName(ctx, parseQueryParam(req, "from")),
+		To:           r.canonicalEntityName(ctx, parseQueryParam(req, "to")),
+		RelationType: parseQueryParam(req, "type"),
+		Source:       parseQueryParam(req, "source"),
+	}
 
-	relations, err := r.store.GetRelations(ctx)
+	properties, err := parseMapQueryParam(req, "prop")
 	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	filter.Properties = properties
 
-	// Apply filters
-	var filteredRelations []models.Relation
-	for _, relation := range relations.Relations {
-		include := true
-
-		if fromEntity != "" && relation.From != fromEntity {
-			include = false
+	if value := parseQueryParam(req, "minWeight"); value != "" {
+		minWeight, err := strconv.ParseFloat(value, 64)
+		if err != nil || minWeight < 0 {
+			r.writeErrorResponse(w, http.StatusBadRequest, "minWeight must be a non-negative number")
+			return
 		}
-		if toEntity != "" && relation.To != toEntity {
-			include = false
+		filter.MinWeight = minWeight
+	}
+
+	if value := parseQueryParam(req, "validAt"); value != "" {
+		validAt := time.Now()
+		if value != "now" {
+			validAt, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				r.writeErrorResponse(w, http.StatusBadRequest, "validAt must be an RFC3339 timestamp or 'now'")
+				return
+			}
 		}
-		if relationType != "" && relation.RelationType != relationType {
-			include = false
+		filter.ValidAt = &validAt
+	}
+
+	// Inverse relations of an entity are stored relations pointing the other
+	// way, so with inverses the index can only narrow by entity. They are
+	// listed by default only when from or to asks for a direction.
+	var candidates []models.Relation
+	if parseBoolQueryParam(req, "inverse", filter.From != "" || filter.To != "") {
+		switch {
+		case filter.From != "":
+			candidates, err = r.store.GetEntityRelations(ctx, filter.From)
+		case filter.To != "":
+			candidates, err = r.store.GetEntityRelations(ctx, filter.To)
+		default:
+			var relations *models.RelationSet
+			if relations, err = r.store.GetRelations(ctx); err == nil {
+				candidates = relations.Relations
+			}
 		}
+		candidates = r.store.RelationTypeRegistry().Expand(candidates)
+	} else {
+		candidates, err = r.store.QueryRelations(ctx, filter)
+	}
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
+		return
+	}
 
-		if include {
+	// Apply filters
+	var filteredRelations []models.Relation
+	for _, relation := range candidates {
+		if filter.Matches(relation) {
 			filteredRelations = append(filteredRelations, relation)
 		}
 	}
@@ -120,65 +179,61 @@ func (r *Router) handleCreateRelation(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	// Verify that both entities exist
-	if !r.store.EntityExists(createReq.From) {
+	// Verify that both entities exist, storing their canonical names
+	from, err := r.store.ResolveEntityName(ctx, createReq.From)
+	if err != nil {
 		r.writeErrorResponse(w, http.StatusBadRequest, "Source entity '"+createReq.From+"' does not exist")
 		return
 	}
-	if !r.store.EntityExists(createReq.To) {
+	to, err := r.store.ResolveEntityName(ctx, createReq.To)
+	if err != nil {
 		r.writeErrorResponse(w, http.StatusBadRequest, "Target entity '"+createReq.To+"' does not exist")
 		return
 	}
+	createReq.From, createReq.To = from, to
 
-	// Get current relations
-	relations, err := r.store.GetRelations(ctx)
-	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
-		return
-	}
+	newRelation := models.NewRelation(createReq.From, createReq.To, createReq.RelationType)
+	newRelation.Properties = createReq.Properties
+	newRelation.Weight = createReq.Weight
+	newRelation.ValidFrom = createReq.ValidFrom
+	newRelation.ValidTo = createReq.ValidTo
+	newRelation.Source = createReq.Source
 
-	// Check if relation already exists
-	for _, existing := range relations.Relations {
-		if existing.From == createReq.From && existing.To == createReq.To && existing.RelationType == createReq.RelationType {
-			r.writeErrorResponse(w, http.StatusConflict, "Relation already exists")
-			return
+	// Repeating a create returns the existing relation
+	relation, created, err := r.store.CreateRelation(ctx, &newRelation)
+	if err != nil {
+		if storage.IsInvalidInput(err) {
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save relation: "+err.Error())
 		}
+		return
 	}
-
-	// Add new relation
-	relations.AddRelation(createReq.From, createReq.To, createReq.RelationType)
-
-	// Save relations
-	if err := r.store.SaveRelations(ctx, relations); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save relation: "+err.Error())
+	if !created {
+		r.writeSuccessResponse(w, relation, "Relation already exists")
 		return
 	}
 
 	// Return the newly created relation
-	newRelation := relations.Relations[len(relations.Relations)-1]
 	r.writeJSONResponse(w, http.StatusCreated, map[string]interface{}{
-		"data":    newRelation,
+		"data":    relation,
 		"message": "Relation created successfully",
 	})
 }
 
 // handleGetRelation retrieves a specific relation by ID
 func (r *Router) handleGetRelation(w http.ResponseWriter, req *http.Request, ctx context.Context, relationID string) {
-	relations, err := r.store.GetRelations(ctx)
+	relation, err := r.store.GetRelation(ctx, relationID)
 	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
-		return
-	}
-
-	// Find relation by ID
-	for _, relation := range relations.Relations {
-		if relation.ID == relationID {
-			r.writeSuccessResponse(w, relation, "Relation retrieved successfully")
-			return
+		if storage.IsNotFound(err) {
+			r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relation: "+err.Error())
 		}
+		return
 	}
 
-	r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
+	r.writeSuccessResponse(w, relation, "Relation retrieved successfully")
 }
 
 // handleUpdateRelation updates a specific relation
@@ -188,23 +243,13 @@ func (r *Router) handleUpdateRelation(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	relations, err := r.store.GetRelations(ctx)
+	stored, err := r.store.GetRelation(ctx, relationID)
 	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
-		return
-	}
-
-	// Find relation by ID
-	var relationIndex = -1
-	for i, relation := range relations.Relations {
-		if relation.ID == relationID {
-			relationIndex = i
-			break
+		if storage.IsNotFound(err) {
+			r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relation: "+err.Error())
 		}
-	}
-
-	if relationIndex == -1 {
-		r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
 		return
 	}
 
@@ -214,56 +259,93 @@ func (r *Router) handleUpdateRelation(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	// Update relation fields
-	relation := &relations.Relations[relationIndex]
+	relation := *stored
 	if updateReq.From != "" {
-		if !r.store.EntityExists(updateReq.From) {
+		from, err := r.store.ResolveEntityName(ctx, updateReq.From)
+		if err != nil {
 			r.writeErrorResponse(w, http.StatusBadRequest, "Source entity '"+updateReq.From+"' does not exist")
 			return
 		}
-		relation.From = updateReq.From
+		relation.From = from
 	}
 	if updateReq.To != "" {
-		if !r.store.EntityExists(updateReq.To) {
+		to, err := r.store.ResolveEntityName(ctx, updateReq.To)
+		if err != nil {
 			r.writeErrorResponse(w, http.StatusBadRequest, "Target entity '"+updateReq.To+"' does not exist")
 			return
 		}
-		relation.To = updateReq.To
+		relation.To = to
 	}
 	if updateReq.RelationType != "" {
 		relation.RelationType = updateReq.RelationType
 	}
+	if updateReq.Properties != nil {
+		relation.Properties = updateReq.Properties
+	}
+	if updateReq.Weight != nil {
+		relation.Weight = *updateReq.Weight
+	}
+	if updateReq.ValidFrom != nil {
+		relation.ValidFrom = updateReq.ValidFrom
+	}
+	if updateReq.ValidTo != nil {
+		relation.ValidTo = updateReq.ValidTo
+	}
+	if updateReq.Source != nil {
+		relation.Source = *updateReq.Source
+	}
 
-	// Save relations
-	if err := r.store.SaveRelations(ctx, relations); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save relation: "+err.Error())
+	// Save the relation, stored in its registered direction
+	if err := r.store.UpdateRelation(ctx, &relation); err != nil {
+		if storage.IsDuplicateRelation(err) {
+			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		} else if storage.IsInvalidInput(err) {
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save relation: "+err.Error())
+		}
 		return
 	}
 
-	r.writeSuccessResponse(w, *relation, "Relation updated successfully")
+	r.writeSuccessResponse(w, relation, "Relation updated successfully")
 }
 
 // handleDeleteRelation deletes a specific relation
 func (r *Router) handleDeleteRelation(w http.ResponseWriter, req *http.Request, ctx context.Context, relationID string) {
-	relations, err := r.store.GetRelations(ctx)
-	if err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get relations: "+err.Error())
+	if err := r.store.DeleteRelation(ctx, relationID); err != nil {
+		if storage.IsNotFound(err) {
+			r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete relation: "+err.Error())
+		}
 		return
 	}
 
-	// Remove relation by ID
-	if !relations.RemoveRelation(relationID) {
-		r.writeErrorResponse(w, http.StatusNotFound, "Relation not found")
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Relation deleted successfully",
+	})
+}
+
+// handleDedupeRelations handles POST /relations/dedupe
+// It removes relations that repeat the endpoints and type of another
+func (r *Router) handleDedupeRelations(w http.ResponseWriter, req *http.Request, ctx context.Context) {
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Save relations
-	if err := r.store.SaveRelations(ctx, relations); err != nil {
-		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to save relations: "+err.Error())
+	removed, err := r.store.DedupeRelations(ctx)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to dedupe relations: "+err.Error())
 		return
 	}
+	if removed == nil {
+		removed = []models.Relation{}
+	}
 
 	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"message": "Relation deleted successfully",
+		"data":    removed,
+		"message": "Duplicate relations removed",
+		"count":   len(removed),
 	})
 }
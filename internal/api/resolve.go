@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// AliasRequest represents the request payload for adding entity aliases
+type AliasRequest struct {
+	Aliases []string `json:"aliases"`
+}
+
+// canonicalEntityName resolves a name or alias to the stored entity name,
+// returning the input unchanged if it does not refer to any entity
+func (r *Router) canonicalEntityName(ctx context.Context, name string) string {
+	if name == "" {
+		return name
+	}
+	if canonical, err := r.store.ResolveEntityName(ctx, name); err == nil {
+		return canonical
+	}
+	return name
+}
+
+// handleResolve handles the /resolve endpoint
+// It reports which entity a name or alias refers to
+func (r *Router) handleResolve(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	name := parseQueryParam(req, "name")
+	if name == "" {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Query parameter 'name' is required")
+		return
+	}
+
+	canonical, err := r.store.ResolveEntityName(ctx, name)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		return
+	}
+
+	r.writeSuccessResponse(w, map[string]interface{}{
+		"name":     name,
+		"resolved": canonical,
+	}, "Entity name resolved successfully")
+}
+
+// handleResolveDuplicates handles the /resolve/duplicates endpoint
+// It proposes pairs of entities whose names suggest they are duplicates
+func (r *Router) handleResolveDuplicates(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	threshold := storage.DefaultDuplicateThreshold
+	if value := parseQueryParam(req, "threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			r.writeErrorResponse(w, http.StatusBadRequest, "threshold must be a number between 0 and 1")
+			return
+		}
+		threshold = parsed
+	}
+
+	entities, err := r.store.ListEntities(ctx, parseQueryParam(req, "type"))
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list entities: "+err.Error())
+		return
+	}
+
+	candidates := storage.ProposeDuplicates(entities, threshold)
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    candidates,
+		"message": "Duplicate detection completed",
+		"count":   len(candidates),
+	})
+}
+
+// handleEntityAliases handles requests to /entities/{name}/aliases and
+// /entities/{name}/aliases/{alias}
+func (r *Router) handleEntityAliases(w http.ResponseWriter, req *http.Request, ctx context.Context, entityName, alias string) {
+	entity, err := r.store.GetEntity(ctx, entityName)
+	if err != nil {
+		if err.Error() == "entity '"+entityName+"' not found" {
+			r.writeErrorResponse(w, http.StatusNotFound, "Entity not found")
+		} else {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get entity: "+err.Error())
+		}
+		return
+	}
+
+	switch {
+	case req.Method == http.MethodGet && alias == "":
+		r.writeSuccessResponse(w, entity.Aliases, "Aliases retrieved successfully")
+	case req.Method == http.MethodPost && alias == "":
+		r.handleAddAliases(w, req, ctx, entity)
+	case req.Method == http.MethodDelete && alias != "":
+		// Work on a copy so a failed save leaves the cached entity untouched
+		updated := entity.Clone()
+		if !updated.RemoveAlias(alias) {
+			r.writeErrorResponse(w, http.StatusNotFound, "Alias not found")
+			return
+		}
+		if err := r.store.UpdateEntity(ctx, updated); err != nil {
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update entity: "+err.Error())
+			return
+		}
+		r.writeSuccessResponse(w, updated, "Alias removed successfully")
+	default:
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAddAliases adds aliases to an entity
+func (r *Router) handleAddAliases(w http.ResponseWriter, req *http.Request, ctx context.Context, entity *models.Entity) {
+	if err := validateJSONRequest(req); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var aliasReq AliasRequest
+	if err := json.NewDecoder(req.Body).Decode(&aliasReq); err != nil {
+		r.writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if len(aliasReq.Aliases) == 0 {
+		r.writeErrorResponse(w, http.StatusBadRequest, "At least one alias is required")
+		return
+	}
+
+	// Work on a copy so a rejected alias leaves the cached entity untouched
+	updated := entity.Clone()
+	for _, alias := range aliasReq.Aliases {
+		updated.AddAlias(alias)
+	}
+
+	if err := r.store.UpdateEntity(ctx, updated); err != nil {
+		switch {
+		case storage.IsAlreadyExists(err):
+			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		case storage.IsInvalidInput(err):
+			r.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to add aliases: "+err.Error())
+		}
+		return
+	}
+
+	r.writeSuccessResponse(w, updated, "Aliases added successfully")
+}
@@ -8,16 +8,24 @@ import (
 
 // Router handles HTTP routing for the memory context API
 type Router struct {
-	store storage.Storage
+	store     storage.Storage
+	workspace string
 }
 
 // NewRouter creates a new API router with the given storage backend
 func NewRouter(store storage.Storage) *Router {
 	return &Router{
-		store: store,
+		store:     store,
+		workspace: ".",
 	}
 }
 
+// SetWorkspace changes the directory that working trees checked for stale
+// facts must lie within (defaults to the server's working directory)
+func (r *Router) SetWorkspace(dir string) {
+	r.workspace = dir
+}
+
 // SetupRoutes configures all API routes and returns the HTTP handler
 func (r *Router) SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
@@ -25,16 +33,35 @@ func (r *Router) SetupRoutes() http.Handler {
 	// Entity endpoints
 	mux.HandleFunc("/entities", r.handleEntities)
 	mux.HandleFunc("/entities/", r.handleEntityByName)
+	mux.HandleFunc("/trash", r.handleTrash)
+	mux.HandleFunc("/trash/", r.handleTrashEntry)
+	mux.HandleFunc("/types", r.handleTypes)
+	mux.HandleFunc("/types/", r.handleTypeByName)
+	mux.HandleFunc("/relation-types", r.handleRelationTypes)
+	mux.HandleFunc("/resolve", r.handleResolve)
+	mux.HandleFunc("/resolve/duplicates", r.handleResolveDuplicates)
 
 	// Memory operation endpoints
 	mux.HandleFunc("/memory/remember", r.handleMemoryRemember)
 	mux.HandleFunc("/memory/recall", r.handleMemoryRecall)
 	mux.HandleFunc("/memory/search", r.handleMemorySearch)
+	mux.HandleFunc("/search", r.handleSearch)
+	mux.HandleFunc("/memory/provenance", r.handleMemoryProvenance)
+	mux.HandleFunc("/memory/provenance/stale", r.handleMemoryProvenanceStale)
 
 	// Relation endpoints
 	mux.HandleFunc("/relations", r.handleRelations)
 	mux.HandleFunc("/relations/", r.handleRelationByID)
 
+	// Graph endpoints
+	mux.HandleFunc("/graph/neighbors", r.handleGraphNeighbors)
+	mux.HandleFunc("/graph/subgraph", r.handleGraphSubgraph)
+	mux.HandleFunc("/graph/path", r.handleGraphPath)
+	mux.HandleFunc("/graph/paths", r.handleGraphPaths)
+	mux.HandleFunc("/graph/query", r.handleGraphQuery)
+	mux.HandleFunc("/graph/export", r.handleGraphExport)
+	mux.HandleFunc("/graph/stats", r.handleGraphStats)
+
 	// MCP-specific endpoints
 	mux.HandleFunc("/mcp/resources", r.handleMCPResources)
 	mux.HandleFunc("/mcp/resources/", r.handleMCPResourceByURI)
@@ -42,6 +69,15 @@ func (r *Router) SetupRoutes() http.Handler {
 	mux.HandleFunc("/mcp/tools/recall_facts", r.handleMCPRecallFacts)
 	mux.HandleFunc("/mcp/tools/search_memory", r.handleMCPSearchMemory)
 	mux.HandleFunc("/mcp/tools/forget_fact", r.handleMCPForgetFact)
+	mux.HandleFunc("/mcp/tools/supersede_fact", r.handleMCPSupersedeFact)
+	mux.HandleFunc("/mcp/tools/update_fact", r.handleMCPUpdateFact)
+	mux.HandleFunc("/mcp/tools/fact_history", r.handleMCPFactHistory)
+	mux.HandleFunc("/mcp/tools/find_stale_facts", r.handleMCPFindStaleFacts)
+	mux.HandleFunc("/mcp/tools/rename_entity", r.handleMCPRenameEntity)
+	mux.HandleFunc("/mcp/tools/set_attributes", r.handleMCPSetAttributes)
+	mux.HandleFunc("/mcp/tools/explore_graph", r.handleMCPExploreGraph)
+	mux.HandleFunc("/mcp/tools/explain_connection", r.handleMCPExplainConnection)
+	mux.HandleFunc("/mcp/tools/query_graph", r.handleMCPQueryGraph)
 
 	// Health check endpoint
 	mux.HandleFunc("/health", r.handleHealth)
@@ -54,7 +90,7 @@ func (r *Router) SetupRoutes() http.Handler {
 func (r *Router) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if req.Method == "OPTIONS" {
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// handleTrash handles the /trash endpoint
+// It lists deleted entities that can still be restored
+func (r *Router) handleTrash(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+
+	entries, err := r.store.ListTrash(ctx)
+	if err != nil {
+		r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to list trash: "+err.Error())
+		return
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":    entries,
+		"message": "Trash retrieved successfully",
+		"count":   len(entries),
+	})
+}
+
+// handleTrashEntry handles requests to /trash/{name}/restore
+func (r *Router) handleTrashEntry(w http.ResponseWriter, req *http.Request) {
+	ctx := context.Background()
+	entityName, action, _ := strings.Cut(extractPathParam(req, "/trash/"), "/")
+
+	if entityName == "" || action != "restore" {
+		r.writeErrorResponse(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	if req.Method != http.MethodPost {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	entity, err := r.store.RestoreEntity(ctx, entityName)
+	if err != nil {
+		switch {
+		case storage.IsNotFound(err):
+			r.writeErrorResponse(w, http.StatusNotFound, "No restorable entity named '"+entityName+"' in trash")
+		case storage.IsAlreadyExists(err):
+			r.writeErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			r.writeErrorResponse(w, http.StatusInternalServerError, "Failed to restore entity: "+err.Error())
+		}
+		return
+	}
+
+	r.writeSuccessResponse(w, entity, "Entity restored successfully")
+}
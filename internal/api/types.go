@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+)
+
+// handleTypes handles the /types endpoint
+// It lists the registered entity types
+func (r *Router) handleTypes(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	registry := r.store.TypeRegistry()
+	types := []schema.TypeDefinition{}
+	if registry.Enabled() {
+		types = registry.Types
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":              types,
+		"message":           "Entity types retrieved successfully",
+		"count":             len(types),
+		"allowUnknownTypes": !registry.Enabled() || registry.AllowUnknownTypes,
+	})
+}
+
+// handleTypeByName handles requests to /types/{name}
+func (r *Router) handleTypeByName(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := extractPathParam(req, "/types/")
+	def, ok := r.store.TypeRegistry().Lookup(name)
+	if !ok {
+		r.writeErrorResponse(w, http.StatusNotFound, "Entity type '"+name+"' is not registered")
+		return
+	}
+
+	r.writeSuccessResponse(w, def, "Entity type retrieved successfully")
+}
+
+// handleRelationTypes handles the /relation-types endpoint
+// It lists the registered relation types with their inverses
+func (r *Router) handleRelationTypes(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		r.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	registry := r.store.RelationTypeRegistry()
+	types := []schema.RelationTypeDefinition{}
+	if registry != nil {
+		types = registry.Types
+	}
+
+	r.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"data":               types,
+		"message":            "Relation types retrieved successfully",
+		"count":              len(types),
+		"rejectUnknownTypes": registry != nil && registry.RejectUnknownTypes,
+	})
+}
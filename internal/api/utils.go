@@ -89,6 +89,54 @@ func parseIntQueryParam(r *http.Request, key string, defaultValue int) int {
 	return intValue
 }
 
+// parseBoolQueryParam extracts a query parameter and converts it to bool
+func parseBoolQueryParam(r *http.Request, key string, defaultValue bool) bool {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return boolValue
+}
+
+// parseListQueryParam extracts a list query parameter, accepting both repeated
+// keys (?tag=a&tag=b) and comma-separated values (?tag=a,b)
+func parseListQueryParam(r *http.Request, key string) []string {
+	var values []string
+	for _, raw := range r.URL.Query()[key] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// parseMapQueryParam extracts repeated key:value query parameters into a map
+// Example: ?meta=team:payments&meta=env:prod
+func parseMapQueryParam(r *http.Request, key string) (map[string]string, error) {
+	raw := r.URL.Query()[key]
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(raw))
+	for _, pair := range raw {
+		k, v, ok := strings.Cut(pair, ":")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("query parameter '%s' must be in key:value form", key)
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return values, nil
+}
+
 // validateJSONRequest validates that the request has JSON content type
 func validateJSONRequest(r *http.Request) error {
 	contentType := r.Header.Get("Content-Type")
@@ -0,0 +1,53 @@
+// Package embed turns text into vectors whose cosine similarity reflects how
+// close the texts are in meaning, for semantic search.
+package embed
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder computes embedding vectors for texts
+type Embedder interface {
+	// Name identifies the embedder and its model. Vectors from embedders
+	// with different names are not comparable.
+	Name() string
+
+	// Embed returns one vector per text, in order. Vectors are normalized to
+	// unit length, so their dot product is their cosine similarity.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Cosine returns the cosine similarity of two vectors, from -1 to 1; 0 if
+// their lengths differ or either is zero
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// Normalize scales a vector to unit length in place; zero vectors are left
+// as they are
+func Normalize(v []float32) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+}
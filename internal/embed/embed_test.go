@@ -0,0 +1,88 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHashEmbedder(t *testing.T) {
+	e := NewHashEmbedder(0)
+	texts := []string{
+		"DB connection pool",
+		"The database connection pool holds 20 connections",
+		"The team prefers tabs over spaces",
+		"Backups of postgres run nightly",
+		"postgresql backups",
+	}
+	vectors, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Failed to embed: %v", err)
+	}
+	if len(vectors) != len(texts) || len(vectors[0]) != DefaultDimensions {
+		t.Fatalf("Expected %d vectors of %d dimensions", len(texts), DefaultDimensions)
+	}
+	if norm := Cosine(vectors[0], vectors[0]); math.Abs(norm-1) > 1e-6 {
+		t.Errorf("Expected unit vectors, got self-similarity %f", norm)
+	}
+
+	related, unrelated := Cosine(vectors[0], vectors[1]), Cosine(vectors[0], vectors[2])
+	if related < 0.5 || unrelated > 0.1 {
+		t.Errorf("Expected abbreviations to embed alike: related %f, unrelated %f", related, unrelated)
+	}
+	if similarity := Cosine(vectors[3], vectors[4]); similarity < 0.2 {
+		t.Errorf("Expected trigrams to relate postgres and postgresql, got %f", similarity)
+	}
+
+	again, _ := e.Embed(context.Background(), texts[:1])
+	if Cosine(again[0], vectors[0]) < 0.999999 {
+		t.Error("Expected embeddings to be deterministic")
+	}
+	if e.Name() != "hash-512" {
+		t.Errorf("Name() = %q", e.Name())
+	}
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	responses := map[string]any{
+		"/api/embed": map[string]any{"embeddings": [][]float32{{3, 4}, {0, 2}}},
+		"/v1/embeddings": map[string]any{"data": []map[string]any{
+			{"index": 1, "embedding": []float32{0, 2}},
+			{"index": 0, "embedding": []float32{3, 4}},
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic-embed-text" || len(req.Input) != 2 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, "model not loaded", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	for path := range responses {
+		e := NewHTTPEmbedder(server.URL+path, "nomic-embed-text")
+		vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Errorf("%s: failed to embed: %v", path, err)
+			continue
+		}
+		if len(vectors) != 2 || vectors[0][0] != 0.6 || vectors[0][1] != 0.8 || vectors[1][1] != 1 {
+			t.Errorf("%s: expected normalized vectors in input order, got %v", path, vectors)
+		}
+	}
+
+	e := NewHTTPEmbedder(server.URL+"/missing", "nomic-embed-text")
+	if _, err := e.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("Expected an error for a failed request")
+	}
+}
@@ -0,0 +1,127 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// DefaultDimensions is the length of HashEmbedder vectors unless configured
+const DefaultDimensions = 512
+
+// Feature weights: whole words count most, word pairs capture some order
+// and character trigrams relate inflections and compounds such as
+// "postgres" and "postgresql"
+const (
+	wordWeight    = 1.0
+	pairWeight    = 0.5
+	trigramWeight = 0.6 // shared by all the trigrams of a word
+)
+
+// abbreviations expands common technical shorthand, so "DB" and "database"
+// embed alike
+var abbreviations = map[string]string{
+	"app":    "application",
+	"auth":   "authentication",
+	"cfg":    "configuration",
+	"config": "configuration",
+	"db":     "database",
+	"deps":   "dependencies",
+	"dev":    "development",
+	"doc":    "documentation",
+	"docs":   "documentation",
+	"env":    "environment",
+	"err":    "error",
+	"fn":     "function",
+	"func":   "function",
+	"impl":   "implementation",
+	"k8s":    "kubernetes",
+	"lib":    "library",
+	"msg":    "message",
+	"perf":   "performance",
+	"pkg":    "package",
+	"prod":   "production",
+	"repo":   "repository",
+	"req":    "request",
+	"resp":   "response",
+	"svc":    "service",
+	"tmp":    "temporary",
+}
+
+// HashEmbedder is an offline embedder that hashes words, word pairs and
+// character trigrams into a fixed number of dimensions. It needs no model or
+// network, and relates texts sharing words in any form; paraphrases without
+// shared words need a model behind an HTTPEmbedder.
+type HashEmbedder struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates a hashing embedder; dimensions of 0 or less use
+// DefaultDimensions
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Name implements Embedder
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-%d", e.dimensions)
+}
+
+// Embed implements Embedder
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	var previous string
+	for _, word := range textutil.Words(text) {
+		if expanded, ok := abbreviations[word]; ok {
+			word = expanded
+		}
+		term := textutil.Stem(word)
+		e.add(vector, "w:"+term, wordWeight)
+		if previous != "" {
+			e.add(vector, "p:"+previous+" "+term, pairWeight)
+		}
+		previous = term
+
+		trigrams := trigramsOf(word)
+		for _, trigram := range trigrams {
+			e.add(vector, "t:"+trigram, trigramWeight/float64(len(trigrams)))
+		}
+	}
+	Normalize(vector)
+	return vector
+}
+
+// add hashes a feature to a dimension and a sign, so collisions cancel out
+// on average rather than adding up
+func (e *HashEmbedder) add(vector []float32, feature string, weight float64) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[sum%uint64(e.dimensions)] += float32(weight)
+}
+
+// trigramsOf returns the character trigrams of a word marked at both ends
+func trigramsOf(word string) []string {
+	runes := []rune("<" + word + ">")
+	trigrams := make([]string, 0, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams = append(trigrams, string(runes[i:i+3]))
+	}
+	return trigrams
+}
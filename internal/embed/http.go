@@ -0,0 +1,99 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// HTTPEmbedder calls a local embedding server, such as Ollama's /api/embed or
+// an OpenAI-compatible /v1/embeddings endpoint served by llama.cpp or LM
+// Studio. Both accept {"model": ..., "input": [...]}; responses may carry
+// either "embeddings" (Ollama) or "data" (OpenAI).
+type HTTPEmbedder struct {
+	url    string
+	model  string
+	client *http.Client
+}
+
+// NewHTTPEmbedder creates an embedder posting to url with the given model
+func NewHTTPEmbedder(url, model string) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		url:    url,
+		model:  model,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name implements Embedder
+func (e *HTTPEmbedder) Name() string {
+	return "http:" + e.model + "@" + e.url
+}
+
+type embedRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embedResponse struct {
+	Embeddings [][]float32      `json:"embeddings"`
+	Data       []embeddingEntry `json:"data"`
+}
+
+type embeddingEntry struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// Embed implements Embedder
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(embedRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding server returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	var decoded embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	vectors := decoded.Embeddings
+	if len(vectors) == 0 && len(decoded.Data) > 0 {
+		slices.SortFunc(decoded.Data, func(a, b embeddingEntry) int {
+			return a.Index - b.Index
+		})
+		for _, item := range decoded.Data {
+			vectors = append(vectors, item.Embedding)
+		}
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding server returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for _, vector := range vectors {
+		Normalize(vector)
+	}
+	return vectors, nil
+}
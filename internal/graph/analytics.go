@@ -0,0 +1,380 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// Analytics limits
+const (
+	DefaultTop = 10
+	MaxTop     = 100
+)
+
+// PageRank parameters
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+)
+
+// RankCentrality orders recalled entities and facts by the PageRank of their
+// entity, most central first
+const RankCentrality = "centrality"
+
+// StatsOptions restrict graph analytics
+type StatsOptions struct {
+	// RelationTypes limits the relations counted; empty counts all
+	RelationTypes []string
+
+	// Top caps the number of central entities and components listed
+	Top int
+}
+
+// Centrality measures how connected an entity is
+type Centrality struct {
+	Name       string `json:"name"`
+	EntityType string `json:"entityType,omitempty"`
+	InDegree   int    `json:"inDegree"`
+	OutDegree  int    `json:"outDegree"`
+
+	// PageRank is the entity's share of a random walk along relations; the
+	// scores of all entities add up to 1
+	PageRank float64 `json:"pageRank"`
+}
+
+// Degree is the number of relations starting or ending at the entity
+func (c Centrality) Degree() int {
+	return c.InDegree + c.OutDegree
+}
+
+// Component is a set of entities connected by relations in either direction
+type Component struct {
+	Size     int      `json:"size"`
+	Entities []string `json:"entities"`
+}
+
+// Stats summarizes the shape of the graph
+type Stats struct {
+	Entities  int `json:"entities"`
+	Relations int `json:"relations"`
+
+	// Central lists the entities with the highest PageRank
+	Central []Centrality `json:"central"`
+
+	// ComponentCount counts connected components, orphans included
+	ComponentCount int `json:"componentCount"`
+
+	// Components lists the largest components of more than one entity
+	Components []Component `json:"components"`
+
+	// Orphans are entities no relation starts or ends at
+	Orphans []string `json:"orphans"`
+
+	// Unobserved are entities without observations
+	Unobserved []string `json:"unobserved"`
+
+	// Missing are entities relations name but that do not exist
+	Missing []string `json:"missing"`
+}
+
+// network is the graph loaded for analytics. Entities come first in nodes,
+// followed by the missing entities relations name.
+type network struct {
+	nodes    []string
+	types    map[string]string
+	index    map[string]int
+	entities int
+
+	relations []models.Relation
+}
+
+// load reads the entities and the relations of the requested types
+func (g *Graph) load(ctx context.Context, relationTypes []string) (*network, []*models.Entity, error) {
+	entities, err := g.store.ListEntities(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	relations, err := g.store.GetRelations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := &network{
+		types:    make(map[string]string, len(entities)),
+		index:    make(map[string]int, len(entities)),
+		entities: len(entities),
+	}
+	add := func(name string) {
+		if _, ok := n.index[name]; !ok {
+			n.index[name] = len(n.nodes)
+			n.nodes = append(n.nodes, name)
+		}
+	}
+	for _, entity := range entities {
+		add(entity.Name)
+		n.types[entity.Name] = entity.EntityType
+	}
+	for _, rel := range relations.Relations {
+		if !matchesType(relationTypes, rel.RelationType) {
+			continue
+		}
+		add(rel.From)
+		add(rel.To)
+		n.relations = append(n.relations, rel)
+	}
+	return n, entities, nil
+}
+
+// pageRank computes the PageRank of every node, following relations in
+// their stored direction. Weighted relations pass on rank in proportion to
+// their weight, and nodes without outgoing relations spread theirs evenly.
+func (n *network) pageRank() []float64 {
+	size := len(n.nodes)
+	if size == 0 {
+		return nil
+	}
+
+	outWeight := make([]float64, size)
+	for _, rel := range n.relations {
+		outWeight[n.index[rel.From]] += relationWeight(rel)
+	}
+
+	rank := make([]float64, size)
+	for i := range rank {
+		rank[i] = 1 / float64(size)
+	}
+	next := make([]float64, size)
+	for iteration := 0; iteration < pageRankIterations; iteration++ {
+		sink := 0.0
+		for i, weight := range outWeight {
+			if weight == 0 {
+				sink += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(size) + pageRankDamping*sink/float64(size)
+		for i := range next {
+			next[i] = base
+		}
+		for _, rel := range n.relations {
+			from := n.index[rel.From]
+			next[n.index[rel.To]] += pageRankDamping * rank[from] * relationWeight(rel) / outWeight[from]
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// relationWeight is the weight a relation passes rank on with, 1 if it has none
+func relationWeight(rel models.Relation) float64 {
+	if rel.Weight > 0 {
+		return rel.Weight
+	}
+	return 1
+}
+
+// components groups the nodes into weakly connected components
+func (n *network) components() [][]string {
+	parent := make([]int, len(n.nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, rel := range n.relations {
+		a, b := find(n.index[rel.From]), find(n.index[rel.To])
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	groups := make(map[int]int)
+	var components [][]string
+	for i, name := range n.nodes {
+		root := find(i)
+		group, ok := groups[root]
+		if !ok {
+			group = len(components)
+			groups[root] = group
+			components = append(components, nil)
+		}
+		components[group] = append(components[group], name)
+	}
+	return components
+}
+
+// Stats computes centrality, connected components, orphans and entities
+// without observations
+func (g *Graph) Stats(ctx context.Context, opts StatsOptions) (*Stats, error) {
+	top := opts.Top
+	if top <= 0 {
+		top = DefaultTop
+	}
+
+	n, entities, err := g.load(ctx, opts.RelationTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Entities:   n.entities,
+		Relations:  len(n.relations),
+		Central:    []Centrality{},
+		Components: []Component{},
+		Orphans:    []string{},
+		Unobserved: []string{},
+		Missing:    slices.Clone(n.nodes[n.entities:]),
+	}
+	for _, entity := range entities {
+		if entity.GetObservationCount() == 0 {
+			stats.Unobserved = append(stats.Unobserved, entity.Name)
+		}
+	}
+
+	centrality := make([]Centrality, len(n.nodes))
+	for i, rank := range n.pageRank() {
+		centrality[i] = Centrality{Name: n.nodes[i], EntityType: n.types[n.nodes[i]], PageRank: rank}
+	}
+	for _, rel := range n.relations {
+		centrality[n.index[rel.From]].OutDegree++
+		centrality[n.index[rel.To]].InDegree++
+	}
+	for _, c := range centrality[:n.entities] {
+		if c.Degree() == 0 {
+			stats.Orphans = append(stats.Orphans, c.Name)
+		}
+	}
+	slices.SortStableFunc(centrality, func(a, b Centrality) int {
+		if a.PageRank != b.PageRank {
+			return compareDesc(a.PageRank, b.PageRank)
+		}
+		return b.Degree() - a.Degree()
+	})
+	stats.Central = append(stats.Central, centrality[:min(top, len(centrality))]...)
+
+	components := n.components()
+	stats.ComponentCount = len(components)
+	slices.SortStableFunc(components, func(a, b []string) int {
+		return len(b) - len(a)
+	})
+	for _, component := range components {
+		if len(component) < 2 || len(stats.Components) == top {
+			break
+		}
+		stats.Components = append(stats.Components, Component{Size: len(component), Entities: component})
+	}
+	return stats, nil
+}
+
+// PageRank returns the PageRank of every entity, for ranking recall results
+func (g *Graph) PageRank(ctx context.Context) (map[string]float64, error) {
+	n, _, err := g.load(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(n.nodes))
+	for i, rank := range n.pageRank() {
+		scores[n.nodes[i]] = rank
+	}
+	return scores, nil
+}
+
+// RankResults orders search results by the PageRank of their entity, keeping
+// the existing order of results for the same entity
+func RankResults(results []storage.SearchResult, scores map[string]float64) {
+	slices.SortStableFunc(results, func(a, b storage.SearchResult) int {
+		return compareDesc(scores[a.EntityName], scores[b.EntityName])
+	})
+}
+
+// RankEntities orders entities by PageRank, keeping the existing order for ties
+func RankEntities(entities []*models.Entity, scores map[string]float64) {
+	slices.SortStableFunc(entities, func(a, b *models.Entity) int {
+		return compareDesc(scores[a.Name], scores[b.Name])
+	})
+}
+
+// ParseRank validates a recall ranking, where empty keeps the observation rank
+func ParseRank(value string) (string, error) {
+	switch rank := strings.ToLower(value); rank {
+	case "", RankCentrality:
+		return rank, nil
+	}
+	return "", fmt.Errorf("rank must be '%s', got '%s'", RankCentrality, value)
+}
+
+func compareDesc(a, b float64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+// Format renders graph statistics as a text report
+func (s *Stats) Format() string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%d entities, %d relations, %d connected component(s)\n",
+		s.Entities, s.Relations, s.ComponentCount)
+
+	if len(s.Central) > 0 {
+		text.WriteString("\nMost central entities:\n")
+		for i, c := range s.Central {
+			fmt.Fprintf(&text, "%d. %s", i+1, c.Name)
+			if c.EntityType != "" {
+				fmt.Fprintf(&text, " (%s)", c.EntityType)
+			}
+			fmt.Fprintf(&text, " - PageRank %.4f, %d in, %d out\n", c.PageRank, c.InDegree, c.OutDegree)
+		}
+	}
+	if len(s.Components) > 0 {
+		text.WriteString("\nLargest components:\n")
+		for i, component := range s.Components {
+			fmt.Fprintf(&text, "%d. %d entities: %s\n", i+1, component.Size, summarizeNames(component.Entities))
+		}
+	}
+
+	for _, section := range []struct {
+		title string
+		names []string
+	}{
+		{"Orphans (no relations)", s.Orphans},
+		{"Entities without observations", s.Unobserved},
+		{"Missing entities named by relations", s.Missing},
+	} {
+		if len(section.names) > 0 {
+			fmt.Fprintf(&text, "\n%s: %s\n", section.title, strings.Join(section.names, ", "))
+		}
+	}
+	return text.String()
+}
+
+// summarizeNames joins the first few names of a list
+func summarizeNames(names []string) string {
+	const shown = 8
+	if len(names) <= shown {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:shown], ", "), len(names)-shown)
+}
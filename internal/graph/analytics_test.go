@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+func TestStats(t *testing.T) {
+	g := newTestGraph(t,
+		models.NewRelation("web", "api", "depends_on"),
+		models.NewRelation("mobile", "api", "depends_on"),
+		models.NewRelation("cli", "api", "depends_on"),
+		models.NewRelation("api", "db", "depends_on"),
+		models.NewRelation("docs", "guide", "references"),
+		models.NewRelation("guide", "wiki", "references"),
+	)
+	ctx := context.Background()
+
+	lonely := models.NewEntity("lonely", "component")
+	lonely.AddObservation("Nothing depends on this")
+	if err := g.store.CreateEntity(ctx, lonely); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	relations := mustRelations(t, g)
+	relations = append(relations, models.NewRelation("api", "ghost", "calls"))
+	if err := g.store.SaveRelations(ctx, &models.RelationSet{Relations: relations}); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+
+	stats, err := g.Stats(ctx, StatsOptions{Top: 3})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Entities != 9 || stats.Relations != 7 {
+		t.Errorf("Expected 9 entities and 7 relations, got %d and %d", stats.Entities, stats.Relations)
+	}
+	if len(stats.Central) != 3 || stats.Central[0].Name != "api" && stats.Central[0].Name != "db" {
+		t.Errorf("Expected api or db to be most central, got %+v", stats.Central)
+	}
+	for _, c := range stats.Central {
+		if c.Name == "api" && (c.InDegree != 3 || c.OutDegree != 2) {
+			t.Errorf("Expected api to have 3 incoming and 2 outgoing relations, got %+v", c)
+		}
+	}
+	if stats.ComponentCount != 3 || len(stats.Components) != 2 || stats.Components[0].Size != 6 || stats.Components[1].Size != 3 {
+		t.Errorf("Expected components of 6, 3 and 1 entities, got %d: %+v", stats.ComponentCount, stats.Components)
+	}
+	if strings.Join(stats.Orphans, ",") != "lonely" || strings.Join(stats.Missing, ",") != "ghost" {
+		t.Errorf("Expected orphan lonely and missing ghost, got %v and %v", stats.Orphans, stats.Missing)
+	}
+	if len(stats.Unobserved) != 8 {
+		t.Errorf("Expected 8 entities without observations, got %v", stats.Unobserved)
+	}
+	if report := stats.Format(); !strings.Contains(report, "Orphans (no relations): lonely") {
+		t.Errorf("Unexpected report:\n%s", report)
+	}
+
+	filtered, err := g.Stats(ctx, StatsOptions{RelationTypes: []string{"references"}})
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if filtered.Relations != 2 || len(filtered.Orphans) != 6 || len(filtered.Missing) != 0 {
+		t.Errorf("Expected only references to be counted, got %+v", filtered)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	g := newTestGraph(t,
+		models.NewRelation("web", "api", "depends_on"),
+		models.NewRelation("mobile", "api", "depends_on"),
+		weighted("api", "db", "depends_on", 0.9),
+		weighted("api", "cache", "depends_on", 0.1),
+	)
+	scores, err := g.PageRank(context.Background())
+	if err != nil {
+		t.Fatalf("PageRank failed: %v", err)
+	}
+
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	if total < 0.999 || total > 1.001 {
+		t.Errorf("Expected scores to add up to 1, got %f", total)
+	}
+	if !(scores["db"] > scores["cache"] && scores["api"] > scores["web"]) {
+		t.Errorf("Expected strong and shared relations to pass on more rank, got %v", scores)
+	}
+
+	results := []storage.SearchResult{
+		{EntityName: "web", Observation: models.NewObservation("first")},
+		{EntityName: "db", Observation: models.NewObservation("second")},
+		{EntityName: "web", Observation: models.NewObservation("third")},
+	}
+	RankResults(results, scores)
+	var order []string
+	for _, result := range results {
+		order = append(order, result.Observation.Text)
+	}
+	if strings.Join(order, ",") != "second,first,third" {
+		t.Errorf("Expected results ordered by entity rank, got %v", order)
+	}
+
+	if _, err := ParseRank("recency"); err == nil {
+		t.Error("Expected unknown rank to be rejected")
+	}
+}
@@ -0,0 +1,315 @@
+package graph
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+// ExportFormat is a text format the graph can be exported as
+type ExportFormat string
+
+const (
+	// FormatDOT is the Graphviz DOT language
+	FormatDOT ExportFormat = "dot"
+
+	// FormatMermaid is a Mermaid flowchart
+	FormatMermaid ExportFormat = "mermaid"
+
+	// FormatGraphML is the GraphML XML format
+	FormatGraphML ExportFormat = "graphml"
+)
+
+// ParseExportFormat parses an export format name, defaulting to Mermaid
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(strings.ToLower(value)); format {
+	case "":
+		return FormatMermaid, nil
+	case FormatDOT, FormatMermaid, FormatGraphML:
+		return format, nil
+	case "graphviz", "gv":
+		return FormatDOT, nil
+	}
+	return "", fmt.Errorf("format must be one of dot, mermaid or graphml, got '%s'", value)
+}
+
+// ContentType returns the media type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case FormatDOT:
+		return "text/vnd.graphviz; charset=utf-8"
+	case FormatGraphML:
+		return "application/graphml+xml; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// ExportOptions select the part of the graph to export
+type ExportOptions struct {
+	// Root limits the export to the subgraph around an entity; empty exports
+	// the whole graph
+	Root string
+
+	// Options restrict the traversal from Root. Direction and RelationTypes
+	// also apply to whole graph exports.
+	Options
+
+	// EntityTypes limits the exported entities to these types, dropping the
+	// relations of other entities; empty exports all types
+	EntityTypes []string
+}
+
+// Export collects the part of the graph to render
+func (g *Graph) Export(ctx context.Context, opts ExportOptions) (*Subgraph, error) {
+	var sg *Subgraph
+	var err error
+	if opts.Root != "" {
+		sg, err = g.Subgraph(ctx, opts.Root, opts.Options)
+	} else {
+		sg, err = g.all(ctx, opts.Options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.EntityTypes) > 0 {
+		registry := g.store.TypeRegistry()
+		keep := make(map[string]bool)
+		sg.Nodes = slices.DeleteFunc(sg.Nodes, func(node Node) bool {
+			keep[node.Name] = node.Name == sg.Root || slices.ContainsFunc(opts.EntityTypes, func(t string) bool {
+				return node.EntityType != "" && registry.CanonicalType(t) == node.EntityType
+			})
+			return !keep[node.Name]
+		})
+		sg.Relations = slices.DeleteFunc(sg.Relations, func(rel models.Relation) bool {
+			return !keep[rel.From] || !keep[rel.To]
+		})
+	}
+	return sg, nil
+}
+
+// all returns every entity and every relation of the requested types,
+// including entities relations name but that do not exist
+func (g *Graph) all(ctx context.Context, opts Options) (*Subgraph, error) {
+	entities, err := g.store.ListEntities(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	relations, err := g.store.GetRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sg := &Subgraph{Relations: []models.Relation{}}
+	known := make(map[string]bool, len(entities))
+	for _, entity := range entities {
+		known[entity.Name] = true
+		sg.Nodes = append(sg.Nodes, Node{Name: entity.Name, EntityType: entity.EntityType, Entity: entity})
+	}
+	for _, rel := range relations.Relations {
+		if !matchesType(opts.RelationTypes, rel.RelationType) {
+			continue
+		}
+		sg.Relations = append(sg.Relations, rel)
+		for _, name := range []string{rel.From, rel.To} {
+			if !known[name] {
+				known[name] = true
+				sg.Nodes = append(sg.Nodes, Node{Name: name})
+			}
+		}
+	}
+	return sg, nil
+}
+
+// Render renders a subgraph in an export format
+func (sg *Subgraph) Render(format ExportFormat) (string, error) {
+	switch format {
+	case FormatDOT:
+		return sg.renderDOT(), nil
+	case FormatMermaid:
+		return sg.renderMermaid(), nil
+	case FormatGraphML:
+		return sg.renderGraphML()
+	}
+	return "", fmt.Errorf("unsupported export format '%s'", format)
+}
+
+// renderDOT renders a Graphviz digraph. Entities that relations name but
+// that do not exist are drawn dashed, and the root is drawn bold.
+func (sg *Subgraph) renderDOT() string {
+	var text strings.Builder
+	text.WriteString("digraph memory {\n  rankdir=LR;\n  node [shape=box, style=rounded];\n")
+	for _, node := range sg.Nodes {
+		label := node.Name
+		var attrs []string
+		if node.EntityType != "" {
+			label += "\n(" + node.EntityType + ")"
+		} else {
+			attrs = append(attrs, `style="rounded,dashed"`)
+		}
+		if node.Name == sg.Root {
+			attrs = append(attrs, "penwidth=2")
+		}
+		attrs = append([]string{"label=" + dotQuote(label)}, attrs...)
+		fmt.Fprintf(&text, "  %s [%s];\n", dotQuote(node.Name), strings.Join(attrs, ", "))
+	}
+	for _, rel := range sg.Relations {
+		label := rel.RelationType
+		if rel.Weight > 0 {
+			label += " (" + strconv.FormatFloat(rel.Weight, 'f', -1, 64) + ")"
+		}
+		attrs := []string{"label=" + dotQuote(label)}
+		if rel.Inferred {
+			attrs = append(attrs, "style=dotted")
+		}
+		fmt.Fprintf(&text, "  %s -> %s [%s];\n", dotQuote(rel.From), dotQuote(rel.To), strings.Join(attrs, ", "))
+	}
+	text.WriteString("}\n")
+	return text.String()
+}
+
+// dotQuote quotes a DOT identifier, keeping \n line breaks in labels
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + strings.ReplaceAll(value, "\n", `\n`) + `"`
+}
+
+// renderMermaid renders a Mermaid flowchart. Entity names may contain
+// characters Mermaid does not allow in node IDs, so nodes are numbered.
+func (sg *Subgraph) renderMermaid() string {
+	var text strings.Builder
+	text.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string, len(sg.Nodes))
+	var missing []string
+	for i, node := range sg.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[node.Name] = id
+		label := mermaidEscape(node.Name)
+		if node.EntityType != "" {
+			label += "<br/>(" + mermaidEscape(node.EntityType) + ")"
+		} else {
+			missing = append(missing, id)
+		}
+		fmt.Fprintf(&text, "  %s[\"%s\"]\n", id, label)
+	}
+	for _, rel := range sg.Relations {
+		arrow := "-->"
+		if rel.Inferred {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&text, "  %s %s|\"%s\"| %s\n", ids[rel.From], arrow, mermaidEscape(rel.RelationType), ids[rel.To])
+	}
+	if len(missing) > 0 {
+		text.WriteString("  classDef missing stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&text, "  class %s missing\n", strings.Join(missing, ","))
+	}
+	if id, ok := ids[sg.Root]; ok {
+		fmt.Fprintf(&text, "  style %s stroke-width:3px\n", id)
+	}
+	return text.String()
+}
+
+// mermaidEscape escapes text inside a quoted Mermaid label
+func mermaidEscape(value string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(value)
+}
+
+// GraphML document structure
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys declares the node and edge attributes a GraphML export uses
+var graphMLKeys = []graphMLKey{
+	{ID: "entityType", For: "node", AttrName: "entityType", AttrType: "string"},
+	{ID: "observations", For: "node", AttrName: "observations", AttrType: "int"},
+	{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"},
+	{ID: "relationType", For: "edge", AttrName: "relationType", AttrType: "string"},
+	{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+	{ID: "source", For: "edge", AttrName: "source", AttrType: "string"},
+}
+
+// renderGraphML renders a GraphML document, with the entity type and
+// relation type, weight and source as data attributes
+func (sg *Subgraph) renderGraphML() (string, error) {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "memory", EdgeDefault: "directed"},
+	}
+	for _, node := range sg.Nodes {
+		element := graphMLNode{ID: node.Name}
+		if node.EntityType != "" {
+			element.Data = append(element.Data, graphMLData{Key: "entityType", Value: node.EntityType})
+		}
+		if node.Entity != nil {
+			element.Data = append(element.Data, graphMLData{Key: "observations", Value: strconv.Itoa(node.Entity.GetObservationCount())})
+		}
+		if sg.Root != "" {
+			element.Data = append(element.Data, graphMLData{Key: "depth", Value: strconv.Itoa(node.Depth)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, element)
+	}
+	for i, rel := range sg.Relations {
+		element := graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: rel.From,
+			Target: rel.To,
+			Data:   []graphMLData{{Key: "relationType", Value: rel.RelationType}},
+		}
+		if rel.Weight > 0 {
+			element.Data = append(element.Data, graphMLData{Key: "weight", Value: strconv.FormatFloat(rel.Weight, 'f', -1, 64)})
+		}
+		if rel.Source != "" {
+			element.Data = append(element.Data, graphMLData{Key: "source", Value: rel.Source})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, element)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
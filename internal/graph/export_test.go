@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+func TestExport(t *testing.T) {
+	g := newTestGraph(t,
+		weighted("web", "api", "depends_on", 0.5),
+		models.NewRelation("api", "auth", "depends_on"),
+		models.NewRelation("auth", "user_db", "depends_on"),
+	)
+	ctx := context.Background()
+
+	// A relation to an entity that was never created
+	dangling := models.NewRelation("api", `say "hi"`, "references")
+	if err := g.store.SaveRelations(ctx, &models.RelationSet{Relations: append(mustRelations(t, g), dangling)}); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+	if err := g.store.CreateEntity(ctx, models.NewEntity("guide", "document")); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	all, err := g.Export(ctx, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(all.Nodes) != 6 || len(all.Relations) != 4 {
+		t.Errorf("Expected 6 entities and 4 relations, got %d and %d", len(all.Nodes), len(all.Relations))
+	}
+
+	sub, err := g.Export(ctx, ExportOptions{Root: "api", Options: Options{Depth: 1}, EntityTypes: []string{"component"}})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(sub.Nodes) != 3 || len(sub.Relations) != 2 {
+		t.Errorf("Expected api, web and auth with 2 relations, got %+v", sub)
+	}
+
+	dot, err := all.Render(FormatDOT)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{
+		`"web" -> "api" [label="depends_on (0.5)"];`,
+		`"say \"hi\"" [label="say \"hi\"", style="rounded,dashed"];`,
+		`"api" [label="api\n(component)"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT output to contain %s:\n%s", want, dot)
+		}
+	}
+
+	mermaid, err := sub.Render(FormatMermaid)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{"flowchart LR\n", `n0["api<br/>(component)"]`, `n1 -->|"depends_on"| n0`, "style n0 stroke-width:3px"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Expected Mermaid output to contain %s:\n%s", want, mermaid)
+		}
+	}
+
+	graphml, err := all.Render(FormatGraphML)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal([]byte(graphml), &doc); err != nil {
+		t.Fatalf("Expected valid GraphML, got %v:\n%s", err, graphml)
+	}
+	if len(doc.Graph.Nodes) != 6 || len(doc.Graph.Edges) != 4 || doc.Graph.Edges[0].Data[1].Value != "0.5" {
+		t.Errorf("Unexpected GraphML document:\n%s", graphml)
+	}
+
+	if _, err := ParseExportFormat("svg"); err == nil {
+		t.Error("Expected unknown format to be rejected")
+	}
+}
+
+func mustRelations(t *testing.T, g *Graph) []models.Relation {
+	t.Helper()
+	relations, err := g.store.GetRelations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+	return relations.Relations
+}
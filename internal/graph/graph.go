@@ -0,0 +1,278 @@
+// Package graph walks the relations between entities: neighbors of an entity,
+// the subgraph within a number of hops of it and the paths between two
+// entities.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// Direction selects which relations of an entity a traversal follows
+type Direction string
+
+const (
+	// Outgoing follows relations that start at the entity
+	Outgoing Direction = "out"
+
+	// Incoming follows relations that end at the entity
+	Incoming Direction = "in"
+
+	// Both follows relations in either direction
+	Both Direction = "both"
+)
+
+// Traversal limits
+const (
+	DefaultDepth     = 2
+	DefaultPathDepth = 4
+	MaxDepth         = 5
+	DefaultMaxNodes  = 500
+	DefaultMaxPaths  = 20
+
+	// MaxQuerySteps caps the entities a graph query may expand and the
+	// partial matches it may try
+	MaxQuerySteps = 200000
+)
+
+// ParseDirection parses a direction name, defaulting to Both
+func ParseDirection(value string) (Direction, error) {
+	switch Direction(strings.ToLower(value)) {
+	case "", Both:
+		return Both, nil
+	case Outgoing, "outgoing":
+		return Outgoing, nil
+	case Incoming, "incoming":
+		return Incoming, nil
+	}
+	return "", fmt.Errorf("direction must be one of out, in or both, got '%s'", value)
+}
+
+// Options restrict a traversal
+type Options struct {
+	// Depth is the number of hops to follow for subgraphs, and the maximum
+	// path length when listing all paths
+	Depth int
+
+	// Direction selects the relations followed. Relations with a registered
+	// inverse or symmetric type are also followed the other way, under
+	// their inverse name.
+	Direction Direction
+
+	// RelationTypes limits the relation types followed; empty follows all
+	RelationTypes []string
+
+	// MaxNodes caps the number of entities a subgraph collects
+	MaxNodes int
+
+	// MaxPaths caps the number of paths listed
+	MaxPaths int
+
+	// Weighted makes paths through strong relations shorter: a relation
+	// costs 1/weight, or 1 if it has no weight
+	Weighted bool
+}
+
+// Neighbor is an entity one relation away from another
+type Neighbor struct {
+	Entity    string          `json:"entity"`
+	Direction Direction       `json:"direction"`
+	Relation  models.Relation `json:"relation"`
+}
+
+// Node is an entity in a subgraph
+type Node struct {
+	Name string `json:"name"`
+
+	// EntityType is empty if the entity a relation points to does not exist
+	EntityType string `json:"entityType,omitempty"`
+
+	// Depth is the number of hops from the root
+	Depth int `json:"depth"`
+
+	Entity *models.Entity `json:"entity,omitempty"`
+}
+
+// Subgraph is the part of the graph within some hops of a root entity
+type Subgraph struct {
+	Root      string            `json:"root"`
+	Depth     int               `json:"depth"`
+	Nodes     []Node            `json:"nodes"`
+	Relations []models.Relation `json:"relations"`
+
+	// Truncated reports that MaxNodes stopped the traversal early
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Graph traverses the relations of an entity store
+type Graph struct {
+	store storage.EntityStore
+}
+
+// New creates a graph over an entity store
+func New(store storage.EntityStore) *Graph {
+	return &Graph{store: store}
+}
+
+// Neighbors returns the entities one relation away from an entity
+func (g *Graph) Neighbors(ctx context.Context, name string, opts Options) ([]Neighbor, error) {
+	canonical, err := g.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return g.neighbors(ctx, canonical, opts)
+}
+
+// Subgraph returns the entities within opts.Depth hops of root together with
+// the relations that connect them. Each entity is visited once, so cycles
+// are safe.
+func (g *Graph) Subgraph(ctx context.Context, root string, opts Options) (*Subgraph, error) {
+	canonical, err := g.resolve(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = DefaultDepth
+	}
+	opts.Depth = min(opts.Depth, MaxDepth)
+	if opts.MaxNodes <= 0 {
+		opts.MaxNodes = DefaultMaxNodes
+	}
+
+	sg := &Subgraph{Root: canonical, Depth: opts.Depth, Relations: []models.Relation{}}
+	depths := map[string]int{canonical: 0}
+	order := []string{canonical}
+	seen := make(map[models.RelationKey]bool)
+
+	for frontier := []string{canonical}; len(frontier) > 0 && !sg.Truncated; {
+		var next []string
+		for _, name := range frontier {
+			if depths[name] >= opts.Depth {
+				continue
+			}
+			neighbors, err := g.neighbors(ctx, name, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, neighbor := range neighbors {
+				if _, visited := depths[neighbor.Entity]; !visited {
+					if len(order) >= opts.MaxNodes {
+						sg.Truncated = true
+						continue
+					}
+					depths[neighbor.Entity] = depths[name] + 1
+					order = append(order, neighbor.Entity)
+					next = append(next, neighbor.Entity)
+				}
+				if key := neighbor.Relation.Key(); !seen[key] {
+					seen[key] = true
+					sg.Relations = append(sg.Relations, neighbor.Relation)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	for _, name := range order {
+		node := Node{Name: name, Depth: depths[name]}
+		if entity, err := g.store.GetEntity(ctx, name); err == nil {
+			node.EntityType = entity.EntityType
+			node.Entity = entity
+		}
+		sg.Nodes = append(sg.Nodes, node)
+	}
+	return sg, nil
+}
+
+// resolve returns the stored name of an entity, reporting unknown names as
+// storage.ErrNotFound
+func (g *Graph) resolve(ctx context.Context, name string) (string, error) {
+	canonical, err := g.store.ResolveEntityName(ctx, name)
+	if err != nil {
+		return "", storage.NewStorageError("resolve", "entity", name, fmt.Errorf("%w: %w", storage.ErrNotFound, err))
+	}
+	return canonical, nil
+}
+
+// neighbors returns the relations of an entity as seen from it, in the
+// requested direction and of the requested types
+func (g *Graph) neighbors(ctx context.Context, name string, opts Options) ([]Neighbor, error) {
+	relations, err := g.store.GetEntityRelations(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	registry := g.store.RelationTypeRegistry()
+	direction := opts.Direction
+	if direction == "" {
+		direction = Both
+	}
+
+	var neighbors []Neighbor
+	add := func(rel models.Relation, dir Direction) {
+		if !matchesType(opts.RelationTypes, rel.RelationType) {
+			return
+		}
+		other := rel.To
+		if dir == Incoming {
+			other = rel.From
+		}
+		neighbors = append(neighbors, Neighbor{Entity: other, Direction: dir, Relation: rel})
+	}
+
+	for _, rel := range relations {
+		stored := Outgoing
+		if rel.To == name && rel.From != name {
+			stored = Incoming
+		}
+		if direction == Both || direction == stored {
+			add(rel, stored)
+			continue
+		}
+		// A relation pointing the other way may still be followed under its
+		// inverse name, e.g. "auth used_by api" for a stored "api depends_on auth"
+		if inverse, ok := registry.InverseOf(rel.RelationType); ok {
+			add(rel.Reversed(inverse), direction)
+		}
+	}
+	return neighbors, nil
+}
+
+func matchesType(types []string, relationType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(types, func(t string) bool {
+		return textutil.NormalizeName(t) == textutil.NormalizeName(relationType)
+	})
+}
+
+// Format renders a subgraph as text: its entities indented by depth, then
+// its relations
+func (sg *Subgraph) Format() string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Subgraph of '%s' within %d hop(s): %d entities, %d relations\n",
+		sg.Root, sg.Depth, len(sg.Nodes), len(sg.Relations))
+	for _, node := range sg.Nodes {
+		entityType := node.EntityType
+		if entityType == "" {
+			entityType = "missing"
+		}
+		fmt.Fprintf(&text, "%s- %s (%s)\n", strings.Repeat("  ", node.Depth), node.Name, entityType)
+	}
+	if len(sg.Relations) > 0 {
+		text.WriteString("Relations:\n")
+		for _, rel := range sg.Relations {
+			fmt.Fprintf(&text, "- %s %s %s%s\n", rel.From, rel.RelationType, rel.To, rel.Details())
+		}
+	}
+	if sg.Truncated {
+		text.WriteString("(truncated: too many entities; narrow the depth or relation types)\n")
+	}
+	return text.String()
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage/filestore"
+)
+
+// newTestGraph builds a store holding the given relations and an entity for
+// each endpoint
+func newTestGraph(t *testing.T, relations ...models.Relation) *Graph {
+	t.Helper()
+	fs := filestore.NewFileStore(t.TempDir())
+	if err := fs.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+
+	ctx := context.Background()
+	created := make(map[string]bool)
+	for _, rel := range relations {
+		for _, name := range []string{rel.From, rel.To} {
+			if created[name] {
+				continue
+			}
+			created[name] = true
+			if err := fs.CreateEntity(ctx, models.NewEntity(name, "component")); err != nil {
+				t.Fatalf("Failed to create entity %s: %v", name, err)
+			}
+		}
+		if _, _, err := fs.CreateRelation(ctx, &rel); err != nil {
+			t.Fatalf("Failed to create relation: %v", err)
+		}
+	}
+	return New(fs)
+}
+
+func neighborNames(neighbors []Neighbor) []string {
+	names := make([]string, len(neighbors))
+	for i, neighbor := range neighbors {
+		names[i] = neighbor.Entity + ":" + neighbor.Relation.RelationType
+	}
+	return names
+}
+
+func TestNeighbors(t *testing.T) {
+	g := newTestGraph(t,
+		models.NewRelation("api", "auth", "depends_on"),
+		models.NewRelation("web", "api", "depends_on"),
+		models.NewRelation("api", "docs", "documented_in"),
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"both", Options{}, "auth:depends_on,web:depends_on,docs:documented_in"},
+		{"outgoing", Options{Direction: Outgoing}, "auth:depends_on,web:used_by,docs:documented_in"},
+		{"incoming", Options{Direction: Incoming}, "auth:used_by,web:depends_on"},
+		{"types", Options{Direction: Outgoing, RelationTypes: []string{"Used-By"}}, "web:used_by"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neighbors, err := g.Neighbors(ctx, "API", tt.opts)
+			if err != nil {
+				t.Fatalf("Neighbors failed: %v", err)
+			}
+			if got := strings.Join(neighborNames(neighbors), ","); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := g.Neighbors(ctx, "missing", Options{}); !storage.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	g := newTestGraph(t,
+		models.NewRelation("a", "b", "depends_on"),
+		models.NewRelation("b", "c", "depends_on"),
+		models.NewRelation("c", "a", "depends_on"),
+		models.NewRelation("c", "d", "depends_on"),
+		models.NewRelation("d", "e", "references"),
+	)
+	ctx := context.Background()
+
+	sg, err := g.Subgraph(ctx, "a", Options{Depth: 1, Direction: Outgoing})
+	if err != nil {
+		t.Fatalf("Subgraph failed: %v", err)
+	}
+	if len(sg.Nodes) != 3 || len(sg.Relations) != 2 {
+		t.Errorf("Expected a's direct neighbors b and c (via the cycle), got %+v", sg)
+	}
+
+	// The cycle a -> b -> c -> a visits each entity once
+	sg, err = g.Subgraph(ctx, "a", Options{Depth: 5, Direction: Outgoing, RelationTypes: []string{"depends_on"}})
+	if err != nil {
+		t.Fatalf("Subgraph failed: %v", err)
+	}
+	depths := make(map[string]int)
+	for _, node := range sg.Nodes {
+		depths[node.Name] = node.Depth
+	}
+	if len(depths) != 4 || depths["b"] != 1 || depths["c"] != 2 || depths["d"] != 3 {
+		t.Errorf("Expected a, b, c and d at increasing depths, got %v", depths)
+	}
+	if len(sg.Relations) != 4 {
+		t.Errorf("Expected the 4 depends_on relations, got %+v", sg.Relations)
+	}
+
+	sg, err = g.Subgraph(ctx, "a", Options{Depth: 5, MaxNodes: 2})
+	if err != nil {
+		t.Fatalf("Subgraph failed: %v", err)
+	}
+	if len(sg.Nodes) != 2 || !sg.Truncated {
+		t.Errorf("Expected a truncated subgraph of 2 entities, got %+v", sg)
+	}
+	if !strings.Contains(sg.Format(), "truncated") {
+		t.Errorf("Expected formatted subgraph to mention truncation:\n%s", sg.Format())
+	}
+}
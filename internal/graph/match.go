@@ -0,0 +1,329 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+// QueryResult is the table a query returns, one row per match
+type QueryResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+
+	// Truncated reports that the query's limit cut the rows short
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// errLimitReached stops a match once the query's limit is exceeded
+var errLimitReached = errors.New("limit reached")
+
+// ErrQueryTooComplex is returned when matching a query takes more than
+// MaxQuerySteps steps
+var ErrQueryTooComplex = errors.New("query too complex")
+
+// Query parses and runs a graph query. Entity variables return the entity
+// name and relation variables the relation. Entity fields are name, type,
+// observations and attribute names; relation fields are type, from, to,
+// weight, source, id and property names. Parse errors are *QueryError.
+func (g *Graph) Query(ctx context.Context, input string) (*QueryResult, error) {
+	q, err := ParseQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	return g.Run(ctx, q)
+}
+
+// Run executes a parsed query
+func (g *Graph) Run(ctx context.Context, q *Query) (*QueryResult, error) {
+	m := &matcher{
+		graph:    g,
+		query:    q,
+		entities: make(map[string]*models.Entity),
+		nodes:    make([]string, len(q.Nodes)),
+		edges:    make([]models.Relation, len(q.Relations)),
+		result:   &QueryResult{Columns: make([]string, len(q.Return)), Rows: [][]any{}},
+		seen:     make(map[string]bool),
+	}
+	for i, item := range q.Return {
+		m.result.Columns[i] = item.String()
+	}
+	for _, rel := range q.Relations {
+		m.neighbors = append(m.neighbors, g.cachedNeighbors(Options{Direction: rel.Direction, RelationTypes: rel.Types}))
+	}
+
+	start, err := g.store.ListEntities(ctx, q.Nodes[0].EntityType)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range start {
+		m.entities[entity.Name] = entity
+		m.nodes[0] = entity.Name
+		if err := m.extend(ctx, 0); err != nil {
+			if errors.Is(err, errLimitReached) {
+				m.result.Truncated = true
+				break
+			}
+			return nil, err
+		}
+	}
+	return m.result, nil
+}
+
+// matcher binds a query pattern to the graph one step at a time
+type matcher struct {
+	graph     *Graph
+	query     *Query
+	entities  map[string]*models.Entity // nil for names without an entity
+	neighbors []func(context.Context, string) ([]Neighbor, error)
+
+	nodes []string          // entity bound to each node pattern so far
+	edges []models.Relation // relation bound to each single-hop relation pattern
+
+	result *QueryResult
+	seen   map[string]bool // rows already returned, for DISTINCT
+	steps  int             // work done so far, against MaxQuerySteps
+}
+
+// extend matches the relation after node step and the nodes beyond it,
+// emitting a row for each complete match
+func (m *matcher) extend(ctx context.Context, step int) error {
+	if err := m.step(ctx); err != nil {
+		return err
+	}
+	if step == len(m.query.Relations) {
+		return m.emit(ctx)
+	}
+
+	pattern := m.query.Relations[step]
+	node := m.query.Nodes[step+1]
+	ends, err := m.reach(ctx, step, m.nodes[step], pattern)
+	if err != nil {
+		return err
+	}
+	for _, end := range ends {
+		if !m.matchesNode(ctx, node, step+1, end.Entity) {
+			continue
+		}
+		m.nodes[step+1] = end.Entity
+		m.edges[step] = end.Relation
+		if err := m.extend(ctx, step+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reach returns the entities a relation pattern leads to from an entity. A
+// variable-length pattern yields each entity once, if its shortest distance
+// from the entity lies within the pattern's hops; the search tracks the
+// entities reached rather than listing paths.
+func (m *matcher) reach(ctx context.Context, step int, from string, pattern RelationPattern) ([]Neighbor, error) {
+	neighbors := m.neighbors[step]
+	if pattern.MinHops == 1 && pattern.MaxHops == 1 {
+		if err := m.step(ctx); err != nil {
+			return nil, err
+		}
+		return neighbors(ctx, from)
+	}
+
+	var ends []Neighbor
+	reached := map[string]bool{from: true}
+	frontier := []string{from}
+	for hops := 1; hops <= pattern.MaxHops && len(frontier) > 0; hops++ {
+		var next []string
+		for _, name := range frontier {
+			if err := m.step(ctx); err != nil {
+				return nil, err
+			}
+			adjacent, err := neighbors(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, neighbor := range adjacent {
+				if reached[neighbor.Entity] {
+					continue
+				}
+				reached[neighbor.Entity] = true
+				next = append(next, neighbor.Entity)
+				if hops >= pattern.MinHops {
+					ends = append(ends, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+	return ends, nil
+}
+
+// step counts a unit of work against the query's budget, stopping the match
+// when the budget is spent or the context is done
+func (m *matcher) step(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.steps++
+	if m.steps > MaxQuerySteps {
+		return fmt.Errorf("%w: more than %d steps; add types or conditions, or fewer hops", ErrQueryTooComplex, MaxQuerySteps)
+	}
+	return nil
+}
+
+// matchesNode reports whether an entity fits the node pattern at position i
+func (m *matcher) matchesNode(ctx context.Context, node NodePattern, i int, name string) bool {
+	if node.Variable != "" {
+		for j, earlier := range m.query.Nodes[:i] {
+			if earlier.Variable == node.Variable && m.nodes[j] != name {
+				return false
+			}
+		}
+	}
+	if node.EntityType == "" {
+		return true
+	}
+	entity := m.entity(ctx, name)
+	return entity != nil && entity.EntityType == m.graph.store.TypeRegistry().CanonicalType(node.EntityType)
+}
+
+// entity returns a bound entity, or nil if a relation names a missing one
+func (m *matcher) entity(ctx context.Context, name string) *models.Entity {
+	entity, ok := m.entities[name]
+	if !ok {
+		// Relations may name entities that were never created
+		entity, _ = m.graph.store.GetEntity(ctx, name)
+		m.entities[name] = entity
+	}
+	return entity
+}
+
+// emit adds the current match as a row if it satisfies the WHERE clause
+func (m *matcher) emit(ctx context.Context) error {
+	env := func(item ReturnItem) any { return m.value(ctx, item) }
+	if m.query.Where != nil && !m.query.Where.holds(env) {
+		return nil
+	}
+
+	row := make([]any, len(m.query.Return))
+	for i, item := range m.query.Return {
+		row[i] = env(item)
+	}
+	if m.query.Distinct {
+		key, _ := json.Marshal(row)
+		if m.seen[string(key)] {
+			return nil
+		}
+		m.seen[string(key)] = true
+	}
+
+	if len(m.result.Rows) >= m.query.Limit {
+		return errLimitReached
+	}
+	m.result.Rows = append(m.result.Rows, row)
+	return nil
+}
+
+// value returns what a variable or field is bound to, or nil if it has no
+// value
+func (m *matcher) value(ctx context.Context, item ReturnItem) any {
+	for i, node := range m.query.Nodes {
+		if node.Variable == item.Variable {
+			return m.entityField(ctx, m.nodes[i], item.Field)
+		}
+	}
+	for i, rel := range m.query.Relations {
+		if rel.Variable == item.Variable {
+			return relationField(m.edges[i], item.Field)
+		}
+	}
+	return nil
+}
+
+func (m *matcher) entityField(ctx context.Context, name, field string) any {
+	if field == "" || field == "name" {
+		return name
+	}
+	entity := m.entity(ctx, name)
+	if entity == nil {
+		return nil
+	}
+
+	switch field {
+	case "type":
+		return entity.EntityType
+	case "observations":
+		return float64(entity.GetObservationCount())
+	}
+	switch value := entity.Attributes[field].(type) {
+	case nil:
+		return nil
+	case float64:
+		return value
+	default:
+		return models.FormatAttributeValue(value)
+	}
+}
+
+func relationField(rel models.Relation, field string) any {
+	switch field {
+	case "":
+		return rel
+	case "type":
+		return rel.RelationType
+	case "from":
+		return rel.From
+	case "to":
+		return rel.To
+	case "id":
+		return rel.ID
+	case "weight":
+		return rel.Weight
+	case "source":
+		if rel.Source == "" {
+			return nil
+		}
+		return rel.Source
+	}
+	if value, ok := rel.Properties[field]; ok {
+		return value
+	}
+	return nil
+}
+
+// formatValue renders a bound value as text
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case models.Relation:
+		return v.From + " " + v.RelationType + " " + v.To
+	}
+	return fmt.Sprint(value)
+}
+
+// Format renders a query result as a text table
+func (r *QueryResult) Format() string {
+	var text strings.Builder
+	text.WriteString(strings.Join(r.Columns, " | ") + "\n")
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = formatValue(value)
+		}
+		text.WriteString(strings.Join(cells, " | ") + "\n")
+	}
+	fmt.Fprintf(&text, "(%d row(s)", len(r.Rows))
+	if r.Truncated {
+		text.WriteString(", truncated by the limit")
+	}
+	text.WriteString(")\n")
+	return text.String()
+}
@@ -0,0 +1,334 @@
+package graph
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+// ErrNoPath is returned when no path connects two entities
+var ErrNoPath = errors.New("no path between entities")
+
+// Path is a chain of relations connecting two entities. Relations[i]
+// connects Entities[i] and Entities[i+1].
+type Path struct {
+	Entities  []string          `json:"entities"`
+	Relations []models.Relation `json:"relations"`
+
+	// Cost is the number of hops, or the summed relation costs if weighted
+	Cost float64 `json:"cost"`
+}
+
+// Hops returns the number of relations in the path
+func (p Path) Hops() int {
+	return len(p.Relations)
+}
+
+// Format renders a path as the chain of entities followed by one line per
+// relation
+func (p Path) Format() string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s (%d hop(s)", strings.Join(p.Entities, " → "), p.Hops())
+	if p.Cost != float64(p.Hops()) {
+		fmt.Fprintf(&text, ", cost %.2f", p.Cost)
+	}
+	text.WriteString(")\n")
+	for _, rel := range p.Relations {
+		fmt.Fprintf(&text, "  - %s %s %s%s\n", rel.From, rel.RelationType, rel.To, rel.Details())
+	}
+	return text.String()
+}
+
+// Explain describes how two entities are connected as readable text: the
+// shortest path between them or, if all is set, every simple path within
+// opts.Depth hops
+func (g *Graph) Explain(ctx context.Context, from, to string, all bool, opts Options) (string, error) {
+	var paths []Path
+	if all {
+		found, err := g.AllPaths(ctx, from, to, opts)
+		if err != nil {
+			return "", err
+		}
+		paths = found
+	} else {
+		path, err := g.ShortestPath(ctx, from, to, opts)
+		if err != nil && !errors.Is(err, ErrNoPath) {
+			return "", err
+		}
+		if path != nil {
+			paths = append(paths, *path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return fmt.Sprintf("No connection found between '%s' and '%s'.\n", from, to), nil
+	}
+	var text strings.Builder
+	if all {
+		fmt.Fprintf(&text, "Found %d path(s) between '%s' and '%s':\n", len(paths), from, to)
+	} else {
+		fmt.Fprintf(&text, "Shortest path between '%s' and '%s':\n", from, to)
+	}
+	for i, path := range paths {
+		fmt.Fprintf(&text, "%d. %s", i+1, path.Format())
+	}
+	return text.String(), nil
+}
+
+// ShortestPath returns the cheapest path from one entity to another: the one
+// with the fewest hops, or the lowest summed cost if opts.Weighted is set.
+// opts.Depth is ignored. ErrNoPath is returned if the entities are not
+// connected.
+func (g *Graph) ShortestPath(ctx context.Context, from, to string, opts Options) (*Path, error) {
+	source, target, err := g.resolvePair(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type reached struct {
+		from string
+		via  models.Relation
+	}
+	neighbors := g.cachedNeighbors(opts)
+	costs := map[string]float64{source: 0}
+	previous := make(map[string]reached)
+	done := make(map[string]bool)
+	queue := &pathQueue{{name: source}}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queueItem)
+		if done[item.name] {
+			continue
+		}
+		done[item.name] = true
+
+		if item.name == target {
+			path := &Path{Relations: []models.Relation{}, Cost: item.cost}
+			for name := target; name != source; name = previous[name].from {
+				path.Entities = append(path.Entities, name)
+				path.Relations = append(path.Relations, previous[name].via)
+			}
+			path.Entities = append(path.Entities, source)
+			slices.Reverse(path.Entities)
+			slices.Reverse(path.Relations)
+			return path, nil
+		}
+
+		next, err := neighbors(ctx, item.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, neighbor := range next {
+			cost := item.cost + opts.cost(neighbor.Relation)
+			if known, ok := costs[neighbor.Entity]; ok && known <= cost {
+				continue
+			}
+			costs[neighbor.Entity] = cost
+			previous[neighbor.Entity] = reached{from: item.name, via: neighbor.Relation}
+			heap.Push(queue, queueItem{name: neighbor.Entity, cost: cost, hops: item.hops + 1})
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s' and '%s'", ErrNoPath, source, target)
+}
+
+// AllPaths returns the simple paths (visiting no entity twice) from one
+// entity to another of at most opts.Depth hops, shortest first, up to
+// opts.MaxPaths of them. With opts.Weighted the paths are ordered by cost.
+func (g *Graph) AllPaths(ctx context.Context, from, to string, opts Options) ([]Path, error) {
+	source, target, err := g.resolvePair(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Depth <= 0 {
+		opts.Depth = DefaultPathDepth
+	}
+	opts.Depth = min(opts.Depth, MaxDepth)
+	if opts.MaxPaths <= 0 {
+		opts.MaxPaths = DefaultMaxPaths
+	}
+	if source == target {
+		return []Path{{Entities: []string{source}, Relations: []models.Relation{}}}, nil
+	}
+
+	// Hops from each entity to the target prune branches that cannot reach
+	// it within the remaining depth
+	distances, err := g.distancesTo(ctx, target, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := g.cachedNeighbors(opts)
+	paths := []Path{}
+	onPath := map[string]bool{source: true}
+	entities := []string{source}
+	var relations []models.Relation
+
+	var visit func(name string, remaining int) error
+	visit = func(name string, remaining int) error {
+		if name == target {
+			if remaining == 0 {
+				paths = append(paths, opts.newPath(entities, relations))
+			}
+			return nil
+		}
+		next, err := neighbors(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, neighbor := range next {
+			if len(paths) >= opts.MaxPaths {
+				return nil
+			}
+			distance, reachable := distances[neighbor.Entity]
+			if onPath[neighbor.Entity] || !reachable || distance > remaining-1 {
+				continue
+			}
+			onPath[neighbor.Entity] = true
+			entities = append(entities, neighbor.Entity)
+			relations = append(relations, neighbor.Relation)
+			if err := visit(neighbor.Entity, remaining-1); err != nil {
+				return err
+			}
+			entities = entities[:len(entities)-1]
+			relations = relations[:len(relations)-1]
+			delete(onPath, neighbor.Entity)
+		}
+		return nil
+	}
+
+	// Searching one length at a time lists shorter paths before longer ones
+	// even when MaxPaths cuts the search short
+	shortest, reachable := distances[source]
+	for length := shortest; reachable && length <= opts.Depth && len(paths) < opts.MaxPaths; length++ {
+		if err := visit(source, length); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Weighted {
+		sort.SliceStable(paths, func(i, j int) bool { return paths[i].Cost < paths[j].Cost })
+	}
+	return paths, nil
+}
+
+// distancesTo returns the number of hops from each entity within opts.Depth
+// hops of target to target, following relations against opts.Direction
+func (g *Graph) distancesTo(ctx context.Context, target string, opts Options) (map[string]int, error) {
+	reversed := opts
+	switch opts.Direction {
+	case Outgoing:
+		reversed.Direction = Incoming
+	case Incoming:
+		reversed.Direction = Outgoing
+	}
+
+	distances := map[string]int{target: 0}
+	for frontier := []string{target}; len(frontier) > 0; {
+		var next []string
+		for _, name := range frontier {
+			if distances[name] >= opts.Depth {
+				continue
+			}
+			neighbors, err := g.neighbors(ctx, name, reversed)
+			if err != nil {
+				return nil, err
+			}
+			for _, neighbor := range neighbors {
+				if _, seen := distances[neighbor.Entity]; !seen {
+					distances[neighbor.Entity] = distances[name] + 1
+					next = append(next, neighbor.Entity)
+				}
+			}
+		}
+		frontier = next
+	}
+	return distances, nil
+}
+
+// resolvePair resolves the endpoints of a path query
+func (g *Graph) resolvePair(ctx context.Context, from, to string) (string, string, error) {
+	source, err := g.resolve(ctx, from)
+	if err != nil {
+		return "", "", err
+	}
+	target, err := g.resolve(ctx, to)
+	if err != nil {
+		return "", "", err
+	}
+	return source, target, nil
+}
+
+// cachedNeighbors returns a neighbors lookup that remembers the neighbors of
+// each entity for the length of one search
+func (g *Graph) cachedNeighbors(opts Options) func(context.Context, string) ([]Neighbor, error) {
+	cache := make(map[string][]Neighbor)
+	return func(ctx context.Context, name string) ([]Neighbor, error) {
+		if neighbors, ok := cache[name]; ok {
+			return neighbors, nil
+		}
+		neighbors, err := g.neighbors(ctx, name, opts)
+		if err != nil {
+			return nil, err
+		}
+		cache[name] = neighbors
+		return neighbors, nil
+	}
+}
+
+// cost returns the cost of following a relation
+func (opts Options) cost(rel models.Relation) float64 {
+	if opts.Weighted && rel.Weight > 0 {
+		return 1 / rel.Weight
+	}
+	return 1
+}
+
+// newPath copies the entities and relations of a path being searched
+func (opts Options) newPath(entities []string, relations []models.Relation) Path {
+	path := Path{
+		Entities:  append([]string(nil), entities...),
+		Relations: append([]models.Relation(nil), relations...),
+	}
+	for _, rel := range relations {
+		path.Cost += opts.cost(rel)
+	}
+	return path
+}
+
+// queueItem is an entity reached by a shortest path search
+type queueItem struct {
+	name string
+	cost float64
+	hops int
+}
+
+// pathQueue orders entities by cost, then hops, then name
+type pathQueue []queueItem
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	if q[i].hops != q[j].hops {
+		return q[i].hops < q[j].hops
+	}
+	return q[i].name < q[j].name
+}
+
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x any) { *q = append(*q, x.(queueItem)) }
+
+func (q *pathQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+func weighted(from, to, relationType string, weight float64) models.Relation {
+	rel := models.NewRelation(from, to, relationType)
+	rel.Weight = weight
+	return rel
+}
+
+func TestShortestPath(t *testing.T) {
+	g := newTestGraph(t,
+		weighted("web", "api", "depends_on", 0.1),
+		weighted("api", "db", "depends_on", 0.1),
+		weighted("web", "cache", "depends_on", 1),
+		weighted("cache", "queue", "depends_on", 1),
+		weighted("queue", "db", "depends_on", 1),
+		models.NewRelation("island", "shore", "related_to"),
+	)
+	ctx := context.Background()
+
+	path, err := g.ShortestPath(ctx, "web", "db", Options{})
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+	if got := strings.Join(path.Entities, ","); got != "web,api,db" || path.Cost != 2 {
+		t.Errorf("Expected web,api,db at cost 2, got %s at cost %v", got, path.Cost)
+	}
+
+	path, err = g.ShortestPath(ctx, "web", "db", Options{Weighted: true})
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+	if got := strings.Join(path.Entities, ","); got != "web,cache,queue,db" || path.Cost != 3 {
+		t.Errorf("Expected the strong relations web,cache,queue,db at cost 3, got %s at cost %v", got, path.Cost)
+	}
+
+	// Against the direction of depends_on, the path follows used_by
+	path, err = g.ShortestPath(ctx, "db", "web", Options{Direction: Outgoing})
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+	if path.Hops() != 2 || path.Relations[0].RelationType != "used_by" {
+		t.Errorf("Expected a 2 hop used_by path, got %+v", path)
+	}
+
+	if _, err := g.ShortestPath(ctx, "web", "db", Options{RelationTypes: []string{"related_to"}}); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected no path over related_to relations, got %v", err)
+	}
+	if _, err := g.ShortestPath(ctx, "web", "shore", Options{}); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Expected no path to a disconnected entity, got %v", err)
+	}
+}
+
+func TestAllPaths(t *testing.T) {
+	g := newTestGraph(t,
+		models.NewRelation("web", "api", "depends_on"),
+		models.NewRelation("api", "db", "depends_on"),
+		models.NewRelation("web", "cache", "depends_on"),
+		models.NewRelation("cache", "queue", "depends_on"),
+		models.NewRelation("queue", "db", "depends_on"),
+		models.NewRelation("db", "web", "references"),
+	)
+	ctx := context.Background()
+
+	paths, err := g.AllPaths(ctx, "web", "db", Options{Direction: Outgoing, RelationTypes: []string{"depends_on"}})
+	if err != nil {
+		t.Fatalf("AllPaths failed: %v", err)
+	}
+	if len(paths) != 2 || paths[0].Hops() != 2 || paths[1].Hops() != 3 {
+		t.Fatalf("Expected the 2 and 3 hop paths, shortest first, got %+v", paths)
+	}
+
+	paths, err = g.AllPaths(ctx, "web", "db", Options{Depth: 2})
+	if err != nil {
+		t.Fatalf("AllPaths failed: %v", err)
+	}
+	if len(paths) != 2 || paths[0].Hops() != 1 || paths[1].Hops() != 2 {
+		t.Errorf("Expected the direct reference and the path through api, got %+v", paths)
+	}
+
+	paths, err = g.AllPaths(ctx, "web", "db", Options{MaxPaths: 1})
+	if err != nil {
+		t.Fatalf("AllPaths failed: %v", err)
+	}
+	if len(paths) != 1 || paths[0].Hops() != 1 {
+		t.Errorf("Expected only the shortest path, got %+v", paths)
+	}
+
+	text, err := g.Explain(ctx, "web", "db", true, Options{Direction: Outgoing, RelationTypes: []string{"depends_on"}})
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if !strings.Contains(text, "Found 2 path(s)") || !strings.Contains(text, "web → cache → queue → db") {
+		t.Errorf("Unexpected explanation:\n%s", text)
+	}
+}
@@ -0,0 +1,716 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a parsed graph query: a pattern of entities joined by relations,
+// an optional condition on what the pattern binds, and the columns to
+// return. For example:
+//
+//	(a:service)-[:depends_on*1..3]->(b) WHERE b.name =~ "db" RETURN a, b.name LIMIT 10
+type Query struct {
+	Nodes     []NodePattern
+	Relations []RelationPattern // Relations[i] joins Nodes[i] and Nodes[i+1]
+	Where     Condition
+	Return    []ReturnItem
+	Distinct  bool
+	Limit     int
+}
+
+// NodePattern matches an entity, optionally of a type
+type NodePattern struct {
+	Variable   string
+	EntityType string
+}
+
+// RelationPattern matches a chain of MinHops to MaxHops relations in a
+// direction, optionally restricted to some types
+type RelationPattern struct {
+	Variable  string
+	Types     []string
+	Direction Direction
+	MinHops   int
+	MaxHops   int
+}
+
+// ReturnItem is a returned column: a variable or one of its fields
+type ReturnItem struct {
+	Variable string
+	Field    string
+}
+
+// String returns the column name
+func (item ReturnItem) String() string {
+	if item.Field == "" {
+		return item.Variable
+	}
+	return item.Variable + "." + item.Field
+}
+
+// Condition is a WHERE clause, evaluated against the values bound by a match
+type Condition interface {
+	holds(env func(ReturnItem) any) bool
+}
+
+// Query limits
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// QueryError reports a query that cannot be parsed
+type QueryError struct {
+	Position int // byte offset in the query, from 0
+	Message  string
+}
+
+// Error implements the error interface
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query error at position %d: %s", e.Position+1, e.Message)
+}
+
+// ParseQuery parses a graph query. The syntax is
+//
+//	[MATCH] pattern [WHERE condition] [RETURN [DISTINCT] item, ...] [LIMIT n]
+//
+// where a pattern is a chain of nodes (var:type) joined by relations
+// -[var:type|type*min..max]-> (or <-[...]- and -[...]- for incoming and
+// either direction, and -->, <-- and -- for any relation). Conditions
+// compare var or var.field with =, !=, <, <=, >, >=, CONTAINS and =~
+// (regular expression), combined with AND, OR, NOT and parentheses.
+func ParseQuery(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, variables: make(map[string]variableKind)}
+	return p.parseQuery()
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe renders a token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// twoCharSymbols are the symbols longer than one character
+var twoCharSymbols = []string{"..", "!=", "=~", "<=", ">="}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c == '"' || c == '\'':
+			text, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end
+		case isDigit(input, i):
+			start := i
+			for isDigit(input, i) {
+				i++
+			}
+			// A fraction, but not the start of a range such as 1..3
+			if i+1 < len(input) && input[i] == '.' && isDigit(input, i+1) {
+				for i++; isDigit(input, i); i++ {
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:i], pos: start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(input) {
+				c, size := utf8.DecodeRuneInString(input[i:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:i], pos: start})
+		default:
+			symbol := string(c)
+			for _, candidate := range twoCharSymbols {
+				if strings.HasPrefix(input[i:], candidate) {
+					symbol = candidate
+					break
+				}
+			}
+			if symbol == "!" || !strings.Contains("()[]:,-<>*.=|!", symbol[:1]) {
+				return nil, &QueryError{Position: i, Message: fmt.Sprintf("unexpected character '%s'", symbol)}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol, pos: i})
+			i += len(symbol)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+func isDigit(input string, i int) bool {
+	return i < len(input) && input[i] >= '0' && input[i] <= '9'
+}
+
+// lexString reads a quoted string starting at input[start], returning its
+// unescaped text and the offset after the closing quote
+func lexString(input string, start int) (string, int, error) {
+	quote := input[start]
+	var text strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case quote:
+			return text.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(input) {
+				i++
+			}
+		}
+		text.WriteByte(input[i])
+	}
+	return "", 0, &QueryError{Position: start, Message: "unterminated string"}
+}
+
+type parser struct {
+	tokens    []token
+	pos       int
+	variables map[string]variableKind
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given symbol
+func (p *parser) accept(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// acceptKeyword consumes the next token if it is the given keyword, in any case
+func (p *parser) acceptKeyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(symbol string) error {
+	if !p.accept(symbol) {
+		return p.errorf("expected '%s', found %s", symbol, p.peek().describe())
+	}
+	return nil
+}
+
+// errorf reports an error at the next token
+func (p *parser) errorf(format string, args ...any) error {
+	return &QueryError{Position: p.peek().pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// name reads an identifier or quoted string
+func (p *parser) name(what string) (string, error) {
+	if t := p.peek(); t.kind == tokenIdent || t.kind == tokenString {
+		p.pos++
+		return t.text, nil
+	}
+	return "", p.errorf("expected %s, found %s", what, p.peek().describe())
+}
+
+// integer reads a whole number
+func (p *parser) integer(what string) (int, error) {
+	t := p.peek()
+	if t.kind == tokenNumber {
+		if n, err := strconv.Atoi(t.text); err == nil {
+			p.pos++
+			return n, nil
+		}
+	}
+	return 0, p.errorf("expected %s, found %s", what, t.describe())
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{Limit: DefaultQueryLimit}
+	p.acceptKeyword("MATCH")
+
+	node, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	q.Nodes = append(q.Nodes, node)
+	for p.peek().text == "-" || p.peek().text == "<" {
+		rel, err := p.parseRelation()
+		if err != nil {
+			return nil, err
+		}
+		node, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		q.Relations = append(q.Relations, rel)
+		q.Nodes = append(q.Nodes, node)
+	}
+	if p.acceptKeyword("WHERE") {
+		if q.Where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("RETURN") {
+		q.Distinct = p.acceptKeyword("DISTINCT")
+		for {
+			item, err := p.parseItem()
+			if err != nil {
+				return nil, err
+			}
+			q.Return = append(q.Return, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	} else {
+		for _, node := range q.Nodes {
+			if node.Variable != "" && !containsItem(q.Return, node.Variable) {
+				q.Return = append(q.Return, ReturnItem{Variable: node.Variable})
+			}
+		}
+		for _, rel := range q.Relations {
+			if rel.Variable != "" {
+				q.Return = append(q.Return, ReturnItem{Variable: rel.Variable})
+			}
+		}
+		if len(q.Return) == 0 {
+			return nil, p.errorf("the pattern names no variables to return")
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		limit, err := p.integer("a limit")
+		if err != nil {
+			return nil, err
+		}
+		if limit < 1 || limit > MaxQueryLimit {
+			return nil, &QueryError{Position: p.tokens[p.pos-1].pos, Message: fmt.Sprintf("limit must be between 1 and %d", MaxQueryLimit)}
+		}
+		q.Limit = limit
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", t.describe())
+	}
+	return q, nil
+}
+
+func containsItem(items []ReturnItem, variable string) bool {
+	for _, item := range items {
+		if item.Variable == variable {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNode parses (var:type)
+func (p *parser) parseNode() (NodePattern, error) {
+	var node NodePattern
+	if err := p.expect("("); err != nil {
+		return node, err
+	}
+	if t := p.peek(); t.kind == tokenIdent {
+		if err := p.declare(p.next(), nodeVariable); err != nil {
+			return node, err
+		}
+		node.Variable = t.text
+	}
+	if p.accept(":") {
+		entityType, err := p.name("an entity type")
+		if err != nil {
+			return node, err
+		}
+		node.EntityType = entityType
+	}
+	return node, p.expect(")")
+}
+
+// parseRelation parses -[var:type|type*min..max]-> and its variants
+func (p *parser) parseRelation() (RelationPattern, error) {
+	rel := RelationPattern{MinHops: 1, MaxHops: 1}
+	start := p.peek().pos
+	incoming := p.accept("<")
+	if err := p.expect("-"); err != nil {
+		return rel, err
+	}
+
+	if p.accept("[") {
+		variable := p.peek()
+		if variable.kind == tokenIdent {
+			if err := p.declare(p.next(), relationVariable); err != nil {
+				return rel, err
+			}
+			rel.Variable = variable.text
+		}
+		if p.accept(":") {
+			for {
+				relationType, err := p.name("a relation type")
+				if err != nil {
+					return rel, err
+				}
+				rel.Types = append(rel.Types, relationType)
+				if !p.accept("|") {
+					break
+				}
+				p.accept(":")
+			}
+		}
+		if p.accept("*") {
+			if err := p.parseHops(&rel); err != nil {
+				return rel, err
+			}
+			if rel.Variable != "" {
+				return rel, &QueryError{Position: variable.pos, Message: fmt.Sprintf("variable-length relation '%s' cannot be named", rel.Variable)}
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return rel, err
+		}
+	}
+
+	if err := p.expect("-"); err != nil {
+		return rel, err
+	}
+	outgoing := p.accept(">")
+
+	switch {
+	case incoming && outgoing:
+		return rel, &QueryError{Position: start, Message: "a relation cannot point both ways; use -[...]- for either direction"}
+	case incoming:
+		rel.Direction = Incoming
+	case outgoing:
+		rel.Direction = Outgoing
+	default:
+		rel.Direction = Both
+	}
+	return rel, nil
+}
+
+// parseHops parses the range after * in a variable-length relation:
+// *, *n, *min..max, *..max or *min..
+func (p *parser) parseHops(rel *RelationPattern) error {
+	start := p.tokens[p.pos-1].pos
+	rel.MinHops, rel.MaxHops = 1, MaxDepth
+
+	ranged := true
+	if p.peek().kind == tokenNumber {
+		n, err := p.integer("a number of hops")
+		if err != nil {
+			return err
+		}
+		rel.MinHops, rel.MaxHops = n, n
+		if ranged = p.accept(".."); ranged {
+			rel.MaxHops = MaxDepth
+		}
+	} else if !p.accept("..") {
+		return nil
+	}
+	if ranged && p.peek().kind == tokenNumber {
+		n, err := p.integer("a number of hops")
+		if err != nil {
+			return err
+		}
+		rel.MaxHops = n
+	}
+
+	switch {
+	case rel.MinHops < 1:
+		return &QueryError{Position: start, Message: "relations must span at least 1 hop"}
+	case rel.MaxHops > MaxDepth:
+		return &QueryError{Position: start, Message: fmt.Sprintf("relations may span at most %d hops", MaxDepth)}
+	case rel.MaxHops < rel.MinHops:
+		return &QueryError{Position: start, Message: "the hop range is empty"}
+	}
+	return nil
+}
+
+// variableKind is what a query variable is bound to
+type variableKind int
+
+const (
+	nodeVariable variableKind = iota + 1
+	relationVariable
+)
+
+// declare records a variable bound by the pattern. An entity variable may
+// repeat, requiring the same entity at each place; a relation variable may not.
+func (p *parser) declare(t token, kind variableKind) error {
+	switch declared := p.variables[t.text]; {
+	case declared == 0:
+		p.variables[t.text] = kind
+		return nil
+	case declared == nodeVariable && kind == nodeVariable:
+		return nil
+	}
+	return &QueryError{Position: t.pos, Message: fmt.Sprintf("variable '%s' is already used", t.text)}
+}
+
+// parseItem parses var or var.field, checking that var is declared
+func (p *parser) parseItem() (ReturnItem, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return ReturnItem{}, p.errorf("expected a variable, found %s", t.describe())
+	}
+	if p.variables[t.text] == 0 {
+		return ReturnItem{}, p.errorf("unknown variable '%s'", t.text)
+	}
+	p.next()
+
+	item := ReturnItem{Variable: t.text}
+	if p.accept(".") {
+		field, err := p.name("a field name")
+		if err != nil {
+			return item, err
+		}
+		item.Field = field
+	}
+	return item, nil
+}
+
+func (p *parser) parseOr() (Condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Condition, error) {
+	if p.acceptKeyword("NOT") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{inner}, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+// comparisonOperators are the symbol operators a comparison may use
+var comparisonOperators = []string{"=", "!=", "<", "<=", ">", ">=", "=~"}
+
+func (p *parser) parseComparison() (Condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	c := comparison{left: left}
+	t := p.peek()
+	switch {
+	case t.kind == tokenSymbol && containsString(comparisonOperators, t.text):
+		c.op = t.text
+	case t.kind == tokenIdent && strings.EqualFold(t.text, "CONTAINS"):
+		c.op = "CONTAINS"
+	default:
+		return nil, p.errorf("expected a comparison operator, found %s", t.describe())
+	}
+	p.next()
+
+	if c.right, err = p.parseOperand(); err != nil {
+		return nil, err
+	}
+	if c.op == "=~" {
+		pattern, ok := c.right.literal.(string)
+		if !ok {
+			return nil, &QueryError{Position: t.pos, Message: "=~ must be followed by a quoted regular expression"}
+		}
+		if c.pattern, err = regexp.Compile("(?i)" + pattern); err != nil {
+			return nil, &QueryError{Position: t.pos, Message: "invalid regular expression: " + err.Error()}
+		}
+	}
+	return c, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// operand is a side of a comparison: a variable (or field) or a literal
+type operand struct {
+	item    ReturnItem
+	literal any // string or float64
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		p.next()
+		return operand{literal: t.text}, nil
+	case t.kind == tokenNumber, t.kind == tokenSymbol && t.text == "-":
+		negative := p.accept("-")
+		number := p.next()
+		value, err := strconv.ParseFloat(number.text, 64)
+		if number.kind != tokenNumber || err != nil {
+			return operand{}, &QueryError{Position: number.pos, Message: "expected a number, found " + number.describe()}
+		}
+		if negative {
+			value = -value
+		}
+		return operand{literal: value}, nil
+	case t.kind == tokenIdent && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")):
+		p.next()
+		return operand{literal: strings.ToLower(t.text)}, nil
+	}
+	item, err := p.parseItem()
+	return operand{item: item}, err
+}
+
+type andCondition struct{ left, right Condition }
+
+func (c andCondition) holds(env func(ReturnItem) any) bool {
+	return c.left.holds(env) && c.right.holds(env)
+}
+
+type orCondition struct{ left, right Condition }
+
+func (c orCondition) holds(env func(ReturnItem) any) bool {
+	return c.left.holds(env) || c.right.holds(env)
+}
+
+type notCondition struct{ inner Condition }
+
+func (c notCondition) holds(env func(ReturnItem) any) bool {
+	return !c.inner.holds(env)
+}
+
+// comparison compares two operands. Numbers compare numerically and
+// everything else as text ignoring case; a missing value never matches.
+type comparison struct {
+	left, right operand
+	op          string
+	pattern     *regexp.Regexp
+}
+
+func (c comparison) holds(env func(ReturnItem) any) bool {
+	left, right := c.left.value(env), c.right.value(env)
+	if left == nil || right == nil {
+		return false
+	}
+
+	if c.pattern != nil {
+		return c.pattern.MatchString(formatValue(left))
+	}
+
+	var order int
+	leftNumber, leftIsNumber := left.(float64)
+	rightNumber, rightIsNumber := right.(float64)
+	if leftIsNumber && rightIsNumber {
+		switch {
+		case leftNumber < rightNumber:
+			order = -1
+		case leftNumber > rightNumber:
+			order = 1
+		}
+	} else {
+		leftText, rightText := strings.ToLower(formatValue(left)), strings.ToLower(formatValue(right))
+		if c.op == "CONTAINS" {
+			return strings.Contains(leftText, rightText)
+		}
+		order = strings.Compare(leftText, rightText)
+	}
+
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+func (o operand) value(env func(ReturnItem) any) any {
+	if o.literal != nil {
+		return o.literal
+	}
+	return env(o.item)
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`MATCH (a:service)-[:depends_on|uses*1..3]->(b) WHERE b.name =~ "db" AND NOT a.owner = 'ops' RETURN DISTINCT a, b.name LIMIT 10`)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	if len(q.Nodes) != 2 || q.Nodes[0].EntityType != "service" || q.Nodes[1].Variable != "b" {
+		t.Errorf("Unexpected nodes %+v", q.Nodes)
+	}
+	rel := q.Relations[0]
+	if rel.Direction != Outgoing || rel.MinHops != 1 || rel.MaxHops != 3 || strings.Join(rel.Types, ",") != "depends_on,uses" {
+		t.Errorf("Unexpected relation %+v", rel)
+	}
+	if !q.Distinct || q.Limit != 10 || len(q.Return) != 2 || q.Return[1].String() != "b.name" {
+		t.Errorf("Unexpected return clause %+v", q)
+	}
+
+	hops := map[string][2]int{
+		"(a)-[*]->(b)":      {1, MaxDepth},
+		"(a)-[*2]->(b)":     {2, 2},
+		"(a)-[*..3]->(b)":   {1, 3},
+		"(a)-[*2..]->(b)":   {2, MaxDepth},
+		"(a)<-[r]-(b)":      {1, 1},
+		"(a)--(b)":          {1, 1},
+		"(a)-[:x*1..1]-(b)": {1, 1},
+	}
+	for input, want := range hops {
+		q, err := ParseQuery(input)
+		if err != nil {
+			t.Errorf("ParseQuery(%s) failed: %v", input, err)
+			continue
+		}
+		if got := [2]int{q.Relations[0].MinHops, q.Relations[0].MaxHops}; got != want {
+			t.Errorf("ParseQuery(%s): expected hops %v, got %v", input, want, got)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		message  string
+	}{
+		{"(a", 2, "expected ')', found end of query"},
+		{"(a)-[:x]->", 10, "expected '(', found end of query"},
+		{"(a)<-[:x]->(b)", 3, "cannot point both ways"},
+		{"(a)-[*7]->(b)", 5, "at most 5 hops"},
+		{"(a)-[r*2]->(b)", 5, "cannot be named"},
+		{"(a)-[a]->(b)", 5, "already used"},
+		{"(a) WHERE c.name = 'x'", 10, "unknown variable 'c'"},
+		{"(a) WHERE a.name ~ 'x'", 17, "unexpected character '~'"},
+		{"(a) WHERE a.name =~ '('", 17, "invalid regular expression"},
+		{"(a) WHERE a.name = 'x", 19, "unterminated string"},
+		{"(a) RETURN a LIMIT 0", 19, "limit must be between"},
+		{"(a) RETURN a b", 13, "unexpected 'b'"},
+		{"()", 2, "names no variables"},
+		{"(a {name: 'x'})", 3, "unexpected character '{'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.input)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("ParseQuery(%s): expected a query error, got %v", tt.input, err)
+			continue
+		}
+		if queryErr.Position != tt.position || !strings.Contains(queryErr.Message, tt.message) {
+			t.Errorf("ParseQuery(%s): expected %q at %d, got %q at %d",
+				tt.input, tt.message, tt.position, queryErr.Message, queryErr.Position)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ownership := weighted("api", "team", "owned_by", 0.9)
+	ownership.Source = "CODEOWNERS"
+	g := newTestGraph(t,
+		models.NewRelation("web", "api", "depends_on"),
+		models.NewRelation("api", "auth", "depends_on"),
+		models.NewRelation("auth", "user_db", "depends_on"),
+		models.NewRelation("api", "orders_db", "depends_on"),
+		ownership,
+	)
+	ctx := context.Background()
+	if err := g.store.CreateEntity(ctx, models.NewEntity("docs", "document")); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Variable-length patterns list nearer entities first
+		{`(a)-[:depends_on*1..3]->(b) WHERE b.name =~ "db" RETURN a, b`,
+			"api,orders_db;api,user_db;auth,user_db;web,orders_db;web,user_db"},
+		{`(a)-[:depends_on*2]->(b) RETURN a, b`, "api,user_db;web,auth;web,orders_db"},
+		{`(a)<-[:depends_on]-(b) WHERE a.name = "API" RETURN b`, "web"},
+		{`(a)-[r:owned_by]->(t) WHERE r.weight >= 0.5 RETURN a, r.source, t.type`, "api,CODEOWNERS,component"},
+		// Outgoing includes inverses, so web reaches itself through "api used_by web"
+		{`(a)-->(b)-->(c) WHERE a = 'web' RETURN c`, "web;auth;orders_db;team"},
+		{`(a)--(b)--(a) RETURN DISTINCT a LIMIT 1`, "api"},
+		{`(a:document)`, "docs"},
+		{`(a)-[:depends_on]->(b) WHERE a.name CONTAINS "EB" OR (b = "user_db" AND NOT a = "web") RETURN a, b`, "auth,user_db;web,api"},
+	}
+	for _, tt := range tests {
+		result, err := g.Query(ctx, tt.query)
+		if err != nil {
+			t.Errorf("Query(%s) failed: %v", tt.query, err)
+			continue
+		}
+		var rows []string
+		for _, row := range result.Rows {
+			cells := make([]string, len(row))
+			for i, value := range row {
+				cells[i] = fmt.Sprint(value)
+			}
+			rows = append(rows, strings.Join(cells, ","))
+		}
+		if got := strings.Join(rows, ";"); got != tt.want {
+			t.Errorf("Query(%s): expected %s, got %s", tt.query, tt.want, got)
+		}
+	}
+
+	result, err := g.Query(ctx, `(a)-->(b) RETURN a LIMIT 2`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Rows) != 2 || !result.Truncated || !strings.Contains(result.Format(), "truncated") {
+		t.Errorf("Expected 2 rows truncated by the limit, got %+v", result)
+	}
+}
+
+func TestQueryBudget(t *testing.T) {
+	// Every pair of 12 entities is related
+	var relations []models.Relation
+	for i := range 12 {
+		for j := i + 1; j < 12; j++ {
+			relations = append(relations, models.NewRelation(fmt.Sprintf("n%02d", i), fmt.Sprintf("n%02d", j), "related_to"))
+		}
+	}
+	g := newTestGraph(t, relations...)
+	ctx := context.Background()
+
+	// Variable-length patterns track the entities reached, not every path
+	result, err := g.Query(ctx, `(a)-[*1..5]-(b) WHERE b.name = "zzz"`)
+	if err != nil || len(result.Rows) != 0 {
+		t.Errorf("Expected no rows, got %+v, %v", result, err)
+	}
+
+	// Chaining patterns multiplies the matches tried, up to the budget
+	if _, err := g.Query(ctx, `(a)--(b)--(c)--(d)--(e)--(f) WHERE f.name = "zzz"`); !errors.Is(err, ErrQueryTooComplex) {
+		t.Errorf("Expected the query to exceed the step budget, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := g.Query(cancelled, `(a)-[*]-(b)`); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled query to stop, got %v", err)
+	}
+}
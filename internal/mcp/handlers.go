@@ -2,10 +2,17 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/provenance"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 // handleRememberFact implements the remember_fact tool
@@ -36,11 +43,50 @@ func (s *StdioServer) handleRememberFact(ctx context.Context, args map[string]in
 	s.logToStderr("Processing: entityName=%s, entityType=%s, observation=%s, source=%s",
 		entityName, entityType, observation, source)
 
-	// Get or create entity
+	// Build and validate the observation before touching storage
+	obs := models.NewObservation(observation)
+	if source != "" {
+		obs.Source = source
+	}
+	obs.Tags = stringSliceArg(args, "tags")
+	obs.Metadata = stringMapArg(args, "metadata")
+	obs.Importance = int(numberArg(args, "importance"))
+	obs.Confidence = numberArg(args, "confidence")
+	obs.Pinned, _ = args["pinned"].(bool)
+	prov, err := provenanceArg(args, "provenance")
+	if err != nil {
+		s.logToStderr("Invalid provenance: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: Invalid provenance: " + err.Error()}},
+			IsError: true,
+		}
+	}
+	obs.Provenance = prov
+	if ttl, _ := args["ttl"].(string); ttl != "" {
+		duration, err := models.ParseTTL(ttl)
+		if err != nil {
+			s.logToStderr("Invalid ttl: %v", err)
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+				IsError: true,
+			}
+		}
+		obs.SetTTL(duration)
+	}
+	if err := obs.Validate(); err != nil {
+		s.logToStderr("Invalid observation: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: Invalid observation: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	// Get or build the entity; a new one is only stored once it is complete,
+	// so it is validated with its observation, aliases and attributes
 	var entity *models.Entity
-	var err error
+	exists := s.store.EntityExists(entityName)
 
-	if s.store.EntityExists(entityName) {
+	if exists {
 		s.logToStderr("Entity exists, loading: %s", entityName)
 		entity, err = s.store.GetEntity(ctx, entityName)
 		if err != nil {
@@ -50,45 +96,51 @@ func (s *StdioServer) handleRememberFact(ctx context.Context, args map[string]in
 				IsError: true,
 			}
 		}
+		// Change a copy so a rejected fact leaves the cached entity untouched
+		entity = entity.Clone()
 	} else {
 		if entityType == "" {
 			entityType = "memory"
 		}
 		s.logToStderr("Creating new entity: %s (type: %s)", entityName, entityType)
 		entity = models.NewEntity(entityName, entityType)
-		if err := s.store.CreateEntity(ctx, entity); err != nil {
-			s.logToStderr("Failed to create entity: %v", err)
-			return CallToolResult{
-				Content: []ToolContent{{Type: "text", Text: "Error: Failed to create entity"}},
-				IsError: true,
-			}
-		}
 	}
 
 	// Add observation
-	if source != "" {
-		entity.AddObservationWithSource(observation, source)
-		s.logToStderr("Added observation with source")
-	} else {
-		entity.AddObservation(observation)
-		s.logToStderr("Added observation without source")
+	entity.AppendObservation(obs)
+	for _, alias := range stringSliceArg(args, "aliases") {
+		entity.AddAlias(alias)
+	}
+	if attributes, ok := args["attributes"].(map[string]interface{}); ok {
+		entity.SetAttributes(attributes)
 	}
+	s.logToStderr("Added observation with source %s and %d tags", obs.Source, len(obs.Tags))
 
-	s.logToStderr("Updating entity with %d observations", entity.GetObservationCount())
-	if err := s.store.UpdateEntity(ctx, entity); err != nil {
-		s.logToStderr("Failed to update entity: %v", err)
+	if exists {
+		s.logToStderr("Updating entity with %d observations", entity.GetObservationCount())
+		if err := s.store.UpdateEntity(ctx, entity); err != nil {
+			s.logToStderr("Failed to update entity: %v", err)
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Error: Failed to update entity: " + err.Error()}},
+				IsError: true,
+			}
+		}
+	} else if err := s.store.CreateEntity(ctx, entity); err != nil {
+		s.logToStderr("Failed to create entity: %v", err)
 		return CallToolResult{
-			Content: []ToolContent{{Type: "text", Text: "Error: Failed to update entity"}},
+			Content: []ToolContent{{Type: "text", Text: "Error: Failed to create entity: " + err.Error()}},
 			IsError: true,
 		}
 	}
 
 	s.logToStderr("Successfully saved entity to storage")
+	text := fmt.Sprintf("✓ Remembered: %s", observation)
+	if obs.ExpiresAt != nil {
+		text += fmt.Sprintf(" (expires %s)", obs.ExpiresAt.Format(time.RFC3339))
+	}
+
 	return CallToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: fmt.Sprintf("✓ Remembered: %s", observation),
-		}},
+		Content: []ToolContent{{Type: "text", Text: text}},
 	}
 }
 
@@ -96,6 +148,41 @@ func (s *StdioServer) handleRememberFact(ctx context.Context, args map[string]in
 func (s *StdioServer) handleRecallFacts(ctx context.Context, args map[string]interface{}) CallToolResult {
 	entityName, _ := args["entityName"].(string)
 	entityType, _ := args["entityType"].(string)
+	pinnedOnly, _ := args["pinnedOnly"].(bool)
+	showLineage, _ := args["showLineage"].(bool)
+	includeSuperseded, _ := args["includeSuperseded"].(bool)
+	filePath, _ := args["filePath"].(string)
+	symbol, _ := args["symbol"].(string)
+	filter := storage.ObservationFilter{
+		EntityType:        entityType,
+		Tags:              stringSliceArg(args, "tags"),
+		Metadata:          stringMapArg(args, "metadata"),
+		Attributes:        stringMapArg(args, "attributes"),
+		MinImportance:     int(numberArg(args, "minImportance")),
+		PinnedOnly:        pinnedOnly,
+		IncludeSuperseded: includeSuperseded,
+		FilePath:          filePath,
+		Symbol:            symbol,
+	}
+
+	rankArg, _ := args["rank"].(string)
+	rank, err := graph.ParseRank(rankArg)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+	var scores map[string]float64
+	if rank == graph.RankCentrality && entityName == "" {
+		if scores, err = graph.New(s.store).PageRank(ctx); err != nil {
+			s.logToStderr("Failed to rank entities: %v", err)
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Error ranking entities"}},
+				IsError: true,
+			}
+		}
+	}
 
 	if entityName != "" {
 		// Recall specific entity
@@ -105,26 +192,67 @@ func (s *StdioServer) handleRecallFacts(ctx context.Context, args map[string]int
 				Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
 			}
 		}
+		full := entity
+		entity = entity.FilterObservations(filter.Matches)
+		models.SortObservations(entity.Observations)
 
 		var text strings.Builder
 		text.WriteString(fmt.Sprintf("Entity: %s (%s)\n", entity.Name, entity.EntityType))
+		if len(entity.Aliases) > 0 {
+			text.WriteString(fmt.Sprintf("Also known as: %s\n", strings.Join(entity.Aliases, ", ")))
+		}
+		if len(entity.Attributes) > 0 {
+			text.WriteString(fmt.Sprintf("Attributes: %s\n", entity.AttributeSummary()))
+		}
 		text.WriteString("Observations:\n")
 		for i, obs := range entity.Observations {
-			text.WriteString(fmt.Sprintf("%d. %s\n", i+1, obs.Text))
+			text.WriteString(fmt.Sprintf("%d. %s%s {id: %s}\n", i+1, obs.Text, formatObservationDetails(obs), obs.ID))
+			if showLineage {
+				text.WriteString(formatLineage(full, obs))
+			}
+		}
+
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: text.String()}},
+		}
+	} else if filter.HasObservationCriteria() {
+		// Recall matching observations across entities
+		results, err := s.store.SearchObservationsFiltered(ctx, "", filter)
+		if err != nil {
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Error retrieving observations"}},
+				IsError: true,
+			}
+		}
+		if scores != nil {
+			graph.RankResults(results, scores)
+		}
+
+		var text strings.Builder
+		text.WriteString("Matching observations:\n")
+		for i, result := range results {
+			text.WriteString(fmt.Sprintf("%d. [%s] %s: %s%s\n",
+				i+1, result.EntityType, result.EntityName, result.Observation.Text, formatObservationDetails(result.Observation)))
 		}
 
 		return CallToolResult{
 			Content: []ToolContent{{Type: "text", Text: text.String()}},
 		}
 	} else {
-		// List entities by type
-		entities, err := s.store.ListEntities(ctx, entityType)
+		// List entities by type and attributes
+		entities, err := s.store.ListEntitiesFiltered(ctx, storage.EntityFilter{
+			EntityType: &entityType,
+			Attributes: filter.Attributes,
+		})
 		if err != nil {
 			return CallToolResult{
 				Content: []ToolContent{{Type: "text", Text: "Error retrieving entities"}},
 				IsError: true,
 			}
 		}
+		if scores != nil {
+			graph.RankEntities(entities, scores)
+		}
 
 		var text strings.Builder
 		if entityType != "" {
@@ -133,9 +261,23 @@ func (s *StdioServer) handleRecallFacts(ctx context.Context, args map[string]int
 			text.WriteString("All entities:\n")
 		}
 
+		var pinned []string
 		for _, entity := range entities {
 			text.WriteString(fmt.Sprintf("- %s (%s): %d observations\n",
 				entity.Name, entity.EntityType, entity.GetObservationCount()))
+			for _, obs := range entity.PinnedObservations() {
+				if filter.Matches(obs) {
+					pinned = append(pinned, fmt.Sprintf("- %s: %s\n", entity.Name, obs.Text))
+				}
+			}
+		}
+
+		// Pinned facts are always surfaced, even in an overview
+		if len(pinned) > 0 {
+			text.WriteString("\nPinned facts:\n")
+			for _, line := range pinned {
+				text.WriteString(line)
+			}
 		}
 
 		return CallToolResult{
@@ -155,9 +297,47 @@ func (s *StdioServer) handleSearchMemory(ctx context.Context, args map[string]in
 	}
 
 	entityType, _ := args["entityType"].(string)
+	pinnedOnly, _ := args["pinnedOnly"].(bool)
+	filePath, _ := args["filePath"].(string)
+	filter := storage.ObservationFilter{
+		EntityType:    entityType,
+		Tags:          stringSliceArg(args, "tags"),
+		Metadata:      stringMapArg(args, "metadata"),
+		Attributes:    stringMapArg(args, "attributes"),
+		MinImportance: int(numberArg(args, "minImportance")),
+		PinnedOnly:    pinnedOnly,
+		FilePath:      filePath,
+	}
+	if fuzziness, ok := args["fuzziness"].(float64); ok {
+		edits := max(int(fuzziness), 0)
+		filter.Fuzziness = &edits
+	}
+	modeArg, _ := args["mode"].(string)
+	mode, err := storage.ParseSearchMode(modeArg)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+	filter.Mode = mode
+	kinds, err := storage.ParseHitKinds(stringSliceArg(args, "kinds"))
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
 
-	results, err := s.store.SearchObservations(ctx, query, entityType)
+	hits, err := s.store.SearchAll(ctx, query, kinds, filter)
 	if err != nil {
+		var syntaxErr *search.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Error: " + syntaxErr.Error()}},
+				IsError: true,
+			}
+		}
 		return CallToolResult{
 			Content: []ToolContent{{Type: "text", Text: "Error searching memory"}},
 			IsError: true,
@@ -167,12 +347,322 @@ func (s *StdioServer) handleSearchMemory(ctx context.Context, args map[string]in
 	var text strings.Builder
 	text.WriteString(fmt.Sprintf("Search results for '%s':\n", query))
 
-	if len(results) == 0 {
+	if len(hits) == 0 {
 		text.WriteString("No results found.\n")
+		if suggestions, err := s.store.SuggestSearch(ctx, query, filter); err == nil && len(suggestions) > 0 {
+			text.WriteString("Did you mean: " + strings.Join(suggestions, " | ") + "\n")
+		}
 	} else {
-		for i, result := range results {
-			text.WriteString(fmt.Sprintf("%d. [%s] %s: %s\n",
-				i+1, result.EntityType, result.EntityName, result.Observation.Text))
+		for i, hit := range hits {
+			text.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatSearchHit(hit)))
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: text.String()}},
+	}
+}
+
+// handleUpdateFact implements the update_fact tool
+func (s *StdioServer) handleUpdateFact(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	observationID, _ := args["observationId"].(string)
+	text, _ := args["text"].(string)
+	if entityName == "" || observationID == "" || text == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName, observationId and text are required"}},
+			IsError: true,
+		}
+	}
+	author, _ := args["author"].(string)
+
+	entity, err := s.store.GetEntity(ctx, entityName)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
+		}
+	}
+	// Change a copy so a rejected edit leaves the cached entity untouched
+	entity = entity.Clone()
+
+	previous := entity.FindObservation(observationID)
+	if previous == nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Observation not found"}},
+		}
+	}
+	oldText := previous.Text
+
+	obs, err := entity.UpdateObservation(observationID, text, author)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	if err := s.store.UpdateEntity(ctx, entity); err != nil {
+		s.logToStderr("Failed to update entity: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: Failed to update entity: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{
+			Type: "text",
+			Text: fmt.Sprintf("✓ Updated fact %s (revision %d)\nWas: %s\nNow: %s", obs.ID, len(obs.Revisions)+1, oldText, obs.Text),
+		}},
+	}
+}
+
+// handleFactHistory implements the fact_history tool
+func (s *StdioServer) handleFactHistory(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	observationID, _ := args["observationId"].(string)
+	if entityName == "" || observationID == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName and observationId are required"}},
+			IsError: true,
+		}
+	}
+
+	entity, err := s.store.GetEntity(ctx, entityName)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
+		}
+	}
+
+	obs := entity.FindObservation(observationID)
+	if obs == nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Observation not found"}},
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: formatHistory(obs)}},
+	}
+}
+
+// handleRenameEntity implements the rename_entity tool
+func (s *StdioServer) handleRenameEntity(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	newName, _ := args["newName"].(string)
+	merge, _ := args["merge"].(bool)
+	if entityName == "" || newName == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName and newName are required"}},
+			IsError: true,
+		}
+	}
+
+	var entity *models.Entity
+	var err error
+	if merge {
+		entity, err = s.store.MergeEntities(ctx, entityName, newName)
+	} else {
+		entity, err = s.store.RenameEntity(ctx, entityName, newName)
+	}
+	if err != nil {
+		s.logToStderr("Failed to rename entity: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	text := fmt.Sprintf("✓ Renamed '%s' to '%s'", entityName, entity.Name)
+	if merge {
+		text = fmt.Sprintf("✓ Merged '%s' into '%s' (%d observations)", entityName, entity.Name, entity.GetObservationCount())
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: text}},
+	}
+}
+
+// handleSetAttributes implements the set_attributes tool
+func (s *StdioServer) handleSetAttributes(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	attributes, _ := args["attributes"].(map[string]interface{})
+	if entityName == "" || len(attributes) == 0 {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName and attributes are required"}},
+			IsError: true,
+		}
+	}
+
+	entity, err := s.store.GetEntity(ctx, entityName)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
+		}
+	}
+
+	updated := entity.Clone()
+	updated.SetAttributes(attributes)
+	if err := s.store.UpdateEntity(ctx, updated); err != nil {
+		s.logToStderr("Failed to set attributes: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: fmt.Sprintf("✓ Attributes of '%s': %s", updated.Name, updated.AttributeSummary())}},
+	}
+}
+
+// handleExploreGraph implements the explore_graph tool
+func (s *StdioServer) handleExploreGraph(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	if entityName == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName is required"}},
+			IsError: true,
+		}
+	}
+
+	direction, _ := args["direction"].(string)
+	dir, err := graph.ParseDirection(direction)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	subgraph, err := graph.New(s.store).Subgraph(ctx, entityName, graph.Options{
+		Depth:         int(numberArg(args, "depth")),
+		Direction:     dir,
+		RelationTypes: stringSliceArg(args, "relationTypes"),
+	})
+	if err != nil {
+		if storage.IsNotFound(err) {
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
+			}
+		}
+		s.logToStderr("Failed to explore graph: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: subgraph.Format()}},
+	}
+}
+
+// handleExplainConnection implements the explain_connection tool
+func (s *StdioServer) handleExplainConnection(ctx context.Context, args map[string]interface{}) CallToolResult {
+	from, _ := args["from"].(string)
+	to, _ := args["to"].(string)
+	if from == "" || to == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: from and to are required"}},
+			IsError: true,
+		}
+	}
+
+	direction, _ := args["direction"].(string)
+	dir, err := graph.ParseDirection(direction)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+	weighted, _ := args["weighted"].(bool)
+	all, _ := args["allPaths"].(bool)
+
+	text, err := graph.New(s.store).Explain(ctx, from, to, all, graph.Options{
+		Depth:         int(numberArg(args, "maxDepth")),
+		Direction:     dir,
+		RelationTypes: stringSliceArg(args, "relationTypes"),
+		Weighted:      weighted,
+	})
+	if err != nil {
+		if storage.IsNotFound(err) {
+			return CallToolResult{
+				Content: []ToolContent{{Type: "text", Text: "Entity not found: " + err.Error()}},
+			}
+		}
+		s.logToStderr("Failed to explain connection: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: text}},
+	}
+}
+
+// handleQueryGraph implements the query_graph tool
+func (s *StdioServer) handleQueryGraph(ctx context.Context, args map[string]interface{}) CallToolResult {
+	query, _ := args["query"].(string)
+	if query == "" {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: query is required"}},
+			IsError: true,
+		}
+	}
+
+	result, err := graph.New(s.store).Query(ctx, query)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{Type: "text", Text: result.Format()}},
+	}
+}
+
+// handleFindStaleFacts implements the find_stale_facts tool
+func (s *StdioServer) handleFindStaleFacts(ctx context.Context, args map[string]interface{}) CallToolResult {
+	root, _ := args["root"].(string)
+	filePath, _ := args["filePath"].(string)
+
+	var checker *provenance.Checker
+	root, err := provenance.ResolveRoot(s.workspace, root)
+	if err == nil {
+		checker, err = provenance.NewChecker(root)
+	}
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	results, err := s.store.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{FilePath: filePath})
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error retrieving observations"}},
+			IsError: true,
+		}
+	}
+
+	stale := checker.FindStale(results)
+
+	var text strings.Builder
+	if len(stale) == 0 {
+		text.WriteString("No stale facts found.\n")
+	} else {
+		text.WriteString(fmt.Sprintf("Found %d stale fact(s):\n", len(stale)))
+		for i, fact := range stale {
+			text.WriteString(fmt.Sprintf("%d. %s: %s {id: %s}\n   reason: %s\n",
+				i+1, fact.EntityName, fact.Observation.Text, fact.Observation.ID, fact.Reason))
 		}
 	}
 
@@ -180,3 +670,191 @@ func (s *StdioServer) handleSearchMemory(ctx context.Context, args map[string]in
 		Content: []ToolContent{{Type: "text", Text: text.String()}},
 	}
 }
+
+// handleSupersedeFact implements the supersede_fact tool
+func (s *StdioServer) handleSupersedeFact(ctx context.Context, args map[string]interface{}) CallToolResult {
+	entityName, _ := args["entityName"].(string)
+	observation, _ := args["observation"].(string)
+	supersedes := stringSliceArg(args, "observationIds")
+	if entityName == "" || observation == "" || len(supersedes) == 0 {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: entityName, observationIds and observation are required"}},
+			IsError: true,
+		}
+	}
+
+	entity, err := s.store.GetEntity(ctx, entityName)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Entity not found"}},
+		}
+	}
+
+	obs := models.NewObservation(observation)
+	if source, _ := args["source"].(string); source != "" {
+		obs.Source = source
+	}
+	obs.Tags = stringSliceArg(args, "tags")
+	obs.Supersedes = supersedes
+	if err := obs.Validate(); err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: Invalid observation: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	// Change a copy so a rejected fact leaves the cached entity untouched
+	entity = entity.Clone()
+	stored, err := entity.Supersede(obs)
+	if err != nil {
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	if err := s.store.UpdateEntity(ctx, entity); err != nil {
+		s.logToStderr("Failed to update entity: %v", err)
+		return CallToolResult{
+			Content: []ToolContent{{Type: "text", Text: "Error: Failed to update entity: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	return CallToolResult{
+		Content: []ToolContent{{
+			Type: "text",
+			Text: fmt.Sprintf("✓ Superseded %d fact(s) with: %s {id: %s}", len(stored.Supersedes), stored.Text, stored.ID),
+		}},
+	}
+}
+
+// formatLineage renders the other observations in a fact's supersession
+// chain, indented under it
+func formatLineage(entity *models.Entity, obs models.Observation) string {
+	var text strings.Builder
+	for _, linked := range entity.Lineage(obs.ID) {
+		if linked.ID == obs.ID {
+			continue
+		}
+		state := "current"
+		if linked.IsSuperseded() {
+			state = "superseded"
+		}
+		text.WriteString(fmt.Sprintf("   ↳ [%s %s] %s\n", state, linked.CreatedAt.Format("2006-01-02"), linked.Text))
+	}
+	return text.String()
+}
+
+// formatHistory renders every revision of an observation, oldest first
+func formatHistory(obs *models.Observation) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("History of fact %s:\n", obs.ID))
+	for i, rev := range obs.History() {
+		text.WriteString(fmt.Sprintf("%d. [%s by %s] %s\n", i+1, rev.Timestamp.Format(time.RFC3339), rev.Author, rev.Text))
+	}
+	return text.String()
+}
+
+// stringSliceArg extracts a list of strings from a tool argument.
+// Both JSON arrays and comma-separated strings are accepted.
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	var values []string
+	switch raw := args[key].(type) {
+	case []interface{}:
+		for _, item := range raw {
+			if str, ok := item.(string); ok && strings.TrimSpace(str) != "" {
+				values = append(values, strings.TrimSpace(str))
+			}
+		}
+	case string:
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// stringMapArg extracts a string map from a tool argument object
+func stringMapArg(args map[string]interface{}, key string) map[string]string {
+	raw, ok := args[key].(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[k] = fmt.Sprint(v)
+	}
+	return values
+}
+
+// provenanceArg decodes an optional provenance object from a tool argument
+func provenanceArg(args map[string]interface{}, key string) (*models.Provenance, error) {
+	raw, ok := args[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var prov models.Provenance
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return nil, fmt.Errorf("provenance must be an object: %w", err)
+	}
+	return &prov, nil
+}
+
+// numberArg extracts a numeric tool argument, returning 0 if absent
+func numberArg(args map[string]interface{}, key string) float64 {
+	value, _ := args[key].(float64)
+	return value
+}
+
+// formatSearchHit renders a unified search hit as a line of tool output:
+// entity and relation hits as their highlighted snippet, observations as
+// search_memory always listed them
+func formatSearchHit(hit storage.SearchHit) string {
+	if hit.Kind == storage.HitObservation {
+		return fmt.Sprintf("[%s] %s: %s%s", hit.EntityType, hit.EntityName, hit.Observation.Text, formatObservationDetails(*hit.Observation))
+	}
+	line := "Entity " + hit.Snippet
+	if hit.Kind == storage.HitRelation {
+		line = "Relation " + hit.Snippet
+	}
+	if len(hit.Fields) > 0 {
+		line += " (matched " + strings.Join(hit.Fields, ", ") + ")"
+	}
+	return line
+}
+
+// formatObservationDetails renders tags and ranking flags as a suffix for text output
+func formatObservationDetails(obs models.Observation) string {
+	var details strings.Builder
+	if len(obs.Tags) > 0 {
+		details.WriteString(" [" + strings.Join(obs.Tags, ", ") + "]")
+	}
+
+	var flags []string
+	if obs.Pinned {
+		flags = append(flags, "pinned")
+	}
+	if obs.Importance != 0 {
+		flags = append(flags, fmt.Sprintf("importance %d/%d", obs.Importance, models.MaxImportance))
+	}
+	if obs.Confidence != 0 {
+		flags = append(flags, fmt.Sprintf("confidence %.2f", obs.Confidence))
+	}
+	if len(flags) > 0 {
+		details.WriteString(" (" + strings.Join(flags, ", ") + ")")
+	}
+	if obs.Provenance != nil {
+		details.WriteString(" @ " + obs.Provenance.String())
+	}
+	return details.String()
+}
@@ -7,23 +7,33 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/tr4d3r/ghcp-memory-context/internal/graph"
 	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 // StdioServer represents an MCP server that communicates over stdin/stdout
 type StdioServer struct {
 	store       storage.Storage
+	workspace   string
 	initialized bool
 }
 
 // NewStdioServer creates a new MCP stdio server
 func NewStdioServer(store storage.Storage) *StdioServer {
 	return &StdioServer{
-		store: store,
+		store:     store,
+		workspace: ".",
 	}
 }
 
+// SetWorkspace changes the directory that working trees checked for stale
+// facts must lie within (defaults to the server's working directory)
+func (s *StdioServer) SetWorkspace(dir string) {
+	s.workspace = dir
+}
+
 // Run starts the stdio server loop
 func (s *StdioServer) Run() error {
 	scanner := bufio.NewScanner(os.Stdin)
@@ -135,7 +145,7 @@ func (s *StdioServer) handleToolsList(request MCPRequest) *MCPResponse {
 				Properties: map[string]interface{}{
 					"entityName": map[string]interface{}{
 						"type":        "string",
-						"description": "Name of the entity to store the fact about",
+						"description": "Name of the entity to store the fact about; existing entities are matched by name or alias, ignoring case and separators",
 					},
 					"entityType": map[string]interface{}{
 						"type":        "string",
@@ -145,10 +155,62 @@ func (s *StdioServer) handleToolsList(request MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "The fact or observation to remember",
 					},
+					"aliases": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Other names the entity is known by, e.g. 'AuthService' for 'auth_service' (optional)",
+					},
+					"attributes": map[string]interface{}{
+						"type":        "object",
+						"description": "Structured attributes to set on the entity, e.g. {\"status\": \"deprecated\"}; null removes an attribute (optional)",
+					},
 					"source": map[string]interface{}{
 						"type":        "string",
 						"description": "Source of the information (optional)",
 					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Tags classifying the fact, e.g. 'security', 'deprecated', 'frontend' (optional)",
+					},
+					"metadata": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Free-form string key/value metadata about the fact (optional)",
+					},
+					"ttl": map[string]interface{}{
+						"type":        "string",
+						"description": "Forget the fact after this long, e.g. '90m', '72h', '3d', '2w' (optional)",
+					},
+					"importance": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"maximum":     5,
+						"description": "How important the fact is, from 1 (trivial) to 5 (critical rule) (optional, default 3)",
+					},
+					"confidence": map[string]interface{}{
+						"type":        "number",
+						"minimum":     0,
+						"maximum":     1,
+						"description": "How certain the fact is, from 0 to 1 (optional, default 1)",
+					},
+					"pinned": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Always surface this fact first (optional)",
+					},
+					"provenance": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"repository": map[string]interface{}{"type": "string"},
+							"filePath":   map[string]interface{}{"type": "string"},
+							"startLine":  map[string]interface{}{"type": "integer", "minimum": 1},
+							"endLine":    map[string]interface{}{"type": "integer", "minimum": 1},
+							"symbol":     map[string]interface{}{"type": "string"},
+							"commit":     map[string]interface{}{"type": "string"},
+							"tool":       map[string]interface{}{"type": "string"},
+						},
+						"description": "Code location the fact was learned from: repository, file path relative to the repository root, line range, symbol, commit SHA and the tool or agent that recorded it (optional)",
+					},
 				},
 				Required: []string{"entityName", "observation"},
 			},
@@ -167,29 +229,337 @@ func (s *StdioServer) handleToolsList(request MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Filter by entity type (optional)",
 					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only return facts carrying all of these tags (optional)",
+					},
+					"metadata": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Only return facts whose metadata contains these key/value pairs (optional)",
+					},
+					"attributes": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Only return entities whose attributes have these values, e.g. {\"owner\": \"team-payments\"} (optional)",
+					},
+					"minImportance": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"maximum":     5,
+						"description": "Only return facts at least this important (optional)",
+					},
+					"pinnedOnly": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return pinned facts (optional)",
+					},
+					"includeSuperseded": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also return facts that were superseded by newer ones (optional)",
+					},
+					"showLineage": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Show the facts each returned fact superseded (optional)",
+					},
+					"filePath": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return facts learned from this file, or from files under this directory when it ends with '/' (optional)",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return facts about this code symbol (optional)",
+					},
+					"rank": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"centrality"},
+						"description": "Order facts and entities by how central their entity is in the graph (optional)",
+					},
 				},
 			},
 		},
 		{
 			Name:        "search_memory",
-			Description: "Search across all stored memory for relevant facts",
+			Description: "Search across all stored memory for matching entities, relations and facts",
 			InputSchema: ToolSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Search query to find relevant facts",
+						"description": "Search query; words must all match, \"quoted phrases\" match in order, and AND, OR, NOT (or -), parentheses and the fields type:, entity:, source:, tag: and created:>=2024-01-01 combine them",
 					},
 					"entityType": map[string]interface{}{
 						"type":        "string",
 						"description": "Filter results by entity type (optional)",
 					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only return facts carrying all of these tags (optional)",
+					},
+					"metadata": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Only return facts whose metadata contains these key/value pairs (optional)",
+					},
+					"attributes": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Only return entities whose attributes have these values, e.g. {\"owner\": \"team-payments\"} (optional)",
+					},
+					"minImportance": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"maximum":     5,
+						"description": "Only return facts at least this important (optional)",
+					},
+					"pinnedOnly": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return pinned facts (optional)",
+					},
+					"filePath": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return facts learned from this file or directory (optional)",
+					},
+					"fuzziness": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"maximum":     2,
+						"description": "Most typos tolerated per word, 0 for exact words (optional, default 2; short words tolerate fewer)",
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"lexical", "semantic", "hybrid"},
+						"description": "lexical matches the words (default); semantic matches by meaning, so \"DB pool\" finds \"database connection pool\"; hybrid combines both (optional)",
+					},
+					"kinds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": []string{"entity", "relation", "observation"}},
+						"description": "Kinds of hit to return: entities by name, alias or type, relations by type or endpoint, and facts (optional, default all)",
+					},
+				},
+				Required: []string{"query"},
+			},
+		},
+		{
+			Name:        "supersede_fact",
+			Description: "Record a new fact that replaces older ones (e.g. a changed decision); the old facts are kept for lineage but hidden from recall",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the entity the facts belong to",
+					},
+					"observationIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "IDs of the facts being superseded (shown by recall_facts)",
+					},
+					"observation": map[string]interface{}{
+						"type":        "string",
+						"description": "The new fact",
+					},
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Source of the information (optional)",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Tags classifying the new fact (optional)",
+					},
+				},
+				Required: []string{"entityName", "observationIds", "observation"},
+			},
+		},
+		{
+			Name:        "update_fact",
+			Description: "Correct the text of a stored fact in place, keeping its ID and recording the previous text in its history",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the entity the fact belongs to",
+					},
+					"observationId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the fact to update (shown by recall_facts)",
+					},
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "The corrected fact",
+					},
+					"author": map[string]interface{}{
+						"type":        "string",
+						"description": "Who is making the change (optional)",
+					},
+				},
+				Required: []string{"entityName", "observationId", "text"},
+			},
+		},
+		{
+			Name:        "fact_history",
+			Description: "Show every revision of a stored fact with timestamps and authors",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the entity the fact belongs to",
+					},
+					"observationId": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the fact (shown by recall_facts)",
+					},
+				},
+				Required: []string{"entityName", "observationId"},
+			},
+		},
+		{
+			Name:        "rename_entity",
+			Description: "Rename an entity, or merge it into an existing entity, rewriting its relations and keeping the old name as an alias",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Current name (or alias) of the entity",
+					},
+					"newName": map[string]interface{}{
+						"type":        "string",
+						"description": "New name, or the name of the entity to merge into when merge is true",
+					},
+					"merge": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Merge into the existing entity named newName instead of renaming; duplicate facts are dropped (optional)",
+					},
+				},
+				Required: []string{"entityName", "newName"},
+			},
+		},
+		{
+			Name:        "set_attributes",
+			Description: "Set structured attributes of an entity, such as owner, language or status",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Name (or alias) of the entity",
+					},
+					"attributes": map[string]interface{}{
+						"type":        "object",
+						"description": "Attributes to set, e.g. {\"owner\": \"team-payments\", \"language\": \"go\"}; values are strings, numbers, booleans or lists, and null removes an attribute",
+					},
+				},
+				Required: []string{"entityName", "attributes"},
+			},
+		},
+		{
+			Name:        "explore_graph",
+			Description: "Explore the entities related to an entity: returns every entity within some hops of it and the relations connecting them",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"entityName": map[string]interface{}{
+						"type":        "string",
+						"description": "Name (or alias) of the entity to start from",
+					},
+					"depth": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of hops to follow, 1 to 5 (optional, defaults to 2)",
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"out", "in", "both"},
+						"description": "Follow outgoing relations, incoming relations or both (optional, defaults to both)",
+					},
+					"relationTypes": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only follow relations of these types (optional)",
+					},
+				},
+				Required: []string{"entityName"},
+			},
+		},
+		{
+			Name:        "explain_connection",
+			Description: "Explain how two entities are connected: the shortest chain of relations between them, or every path up to a length",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Name (or alias) of the first entity",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Name (or alias) of the second entity",
+					},
+					"allPaths": map[string]interface{}{
+						"type":        "boolean",
+						"description": "List every path up to maxDepth hops instead of only the shortest (optional)",
+					},
+					"maxDepth": map[string]interface{}{
+						"type":        "number",
+						"description": "Longest path listed with allPaths, 1 to 5 (optional, defaults to 4)",
+					},
+					"weighted": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Prefer paths through relations with a high weight (optional)",
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"out", "in", "both"},
+						"description": "Follow outgoing relations, incoming relations or both (optional, defaults to both)",
+					},
+					"relationTypes": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only follow relations of these types (optional)",
+					},
+				},
+				Required: []string{"from", "to"},
+			},
+		},
+		{
+			Name:        "query_graph",
+			Description: "Query the graph with a pattern, e.g. (a:service)-[:depends_on*1..3]->(b) WHERE b.name =~ \"db\" RETURN a, b.name. Nodes are (var:type); relations are -[var:type|type*min..max]->, <-[...]- or -[...]-; conditions use =, !=, <, >, CONTAINS and =~ with AND, OR and NOT",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The query; entity fields are name, type, observations and attribute names, relation fields are type, from, to, weight, source and property names",
+					},
 				},
 				Required: []string{"query"},
 			},
 		},
+		{
+			Name:        "find_stale_facts",
+			Description: "Find facts whose referenced file, line range or symbol no longer exists in a working tree",
+			InputSchema: ToolSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"root": map[string]interface{}{
+						"type":        "string",
+						"description": "Root of the working tree to check against, within the server's workspace (optional, defaults to the workspace)",
+					},
+					"filePath": map[string]interface{}{
+						"type":        "string",
+						"description": "Only check facts about this file or directory (optional)",
+					},
+				},
+			},
+		},
 	}
 
+	s.advertiseEntityTypes(tools)
+
 	result := ToolsListResult{Tools: tools}
 	return &MCPResponse{
 		JSONRPC: "2.0",
@@ -198,6 +568,38 @@ func (s *StdioServer) handleToolsList(request MCPRequest) *MCPResponse {
 	}
 }
 
+// advertiseEntityTypes lists the registered entity types in each tool's
+// entityType parameter so clients only offer valid types
+func (s *StdioServer) advertiseEntityTypes(tools []Tool) {
+	registry := s.store.TypeRegistry()
+	if !registry.Enabled() {
+		return
+	}
+
+	described := make([]string, 0, len(registry.Types))
+	for _, def := range registry.Types {
+		text := def.Name
+		if def.Description != "" {
+			text += ": " + def.Description
+		}
+		if len(def.RequiredTags) > 0 {
+			text += " (facts must be tagged " + strings.Join(def.RequiredTags, ", ") + ")"
+		}
+		described = append(described, text)
+	}
+
+	for _, tool := range tools {
+		property, ok := tool.InputSchema.Properties["entityType"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		property["description"] = fmt.Sprintf("%s. Registered types: %s", property["description"], strings.Join(described, "; "))
+		if !registry.AllowUnknownTypes {
+			property["enum"] = registry.Names()
+		}
+	}
+}
+
 // handleToolsCall executes a tool
 func (s *StdioServer) handleToolsCall(request MCPRequest) *MCPResponse {
 	var params CallToolParams
@@ -215,6 +617,24 @@ func (s *StdioServer) handleToolsCall(request MCPRequest) *MCPResponse {
 		result = s.handleRecallFacts(ctx, params.Arguments)
 	case "search_memory":
 		result = s.handleSearchMemory(ctx, params.Arguments)
+	case "supersede_fact":
+		result = s.handleSupersedeFact(ctx, params.Arguments)
+	case "update_fact":
+		result = s.handleUpdateFact(ctx, params.Arguments)
+	case "fact_history":
+		result = s.handleFactHistory(ctx, params.Arguments)
+	case "find_stale_facts":
+		result = s.handleFindStaleFacts(ctx, params.Arguments)
+	case "rename_entity":
+		result = s.handleRenameEntity(ctx, params.Arguments)
+	case "set_attributes":
+		result = s.handleSetAttributes(ctx, params.Arguments)
+	case "explore_graph":
+		result = s.handleExploreGraph(ctx, params.Arguments)
+	case "explain_connection":
+		result = s.handleExplainConnection(ctx, params.Arguments)
+	case "query_graph":
+		result = s.handleQueryGraph(ctx, params.Arguments)
 	default:
 		return s.createErrorResponse(request.ID, MethodNotFound, "Tool not found: "+params.Name)
 	}
@@ -262,6 +682,13 @@ func (s *StdioServer) handleResourcesList(request MCPRequest) *MCPResponse {
 		MimeType:    "text/plain",
 	})
 
+	resources = append(resources, Resource{
+		URI:         "memory://graph",
+		Name:        "Entity Graph",
+		Description: "Mermaid flowchart of all entities and their relations",
+		MimeType:    "text/vnd.mermaid",
+	})
+
 	result := ResourcesListResult{Resources: resources}
 	return &MCPResponse{
 		JSONRPC: "2.0",
@@ -294,7 +721,7 @@ func (s *StdioServer) handleResourcesRead(request MCPRequest) *MCPResponse {
 
 		text := "Entity Relations:\n"
 		for _, rel := range relations.Relations {
-			text += fmt.Sprintf("- %s %s %s\n", rel.From, rel.RelationType, rel.To)
+			text += fmt.Sprintf("- %s %s %s%s\n", rel.From, rel.RelationType, rel.To, rel.Details())
 		}
 
 		content = ResourceContent{
@@ -302,6 +729,21 @@ func (s *StdioServer) handleResourcesRead(request MCPRequest) *MCPResponse {
 			MimeType: "text/plain",
 			Text:     text,
 		}
+	} else if params.URI == "memory://graph" {
+		subgraph, err := graph.New(s.store).Export(ctx, graph.ExportOptions{})
+		if err != nil {
+			return s.createErrorResponse(request.ID, InternalError, "Failed to export graph: "+err.Error())
+		}
+		text, err := subgraph.Render(graph.FormatMermaid)
+		if err != nil {
+			return s.createErrorResponse(request.ID, InternalError, "Failed to render graph: "+err.Error())
+		}
+
+		content = ResourceContent{
+			URI:      params.URI,
+			MimeType: "text/vnd.mermaid",
+			Text:     text,
+		}
 	} else if len(params.URI) > len("memory://entities/") && params.URI[:len("memory://entities/")] == "memory://entities/" {
 		entityName := params.URI[len("memory://entities/"):]
 		entity, err := s.store.GetEntity(ctx, entityName)
@@ -309,7 +751,13 @@ func (s *StdioServer) handleResourcesRead(request MCPRequest) *MCPResponse {
 			return s.createErrorResponse(request.ID, InternalError, "Entity not found")
 		}
 
-		text := fmt.Sprintf("Entity: %s\nType: %s\nObservations:\n", entity.Name, entity.EntityType)
+		entity = entity.FilterObservations(storage.ObservationFilter{}.Matches)
+
+		text := fmt.Sprintf("Entity: %s\nType: %s\n", entity.Name, entity.EntityType)
+		if len(entity.Aliases) > 0 {
+			text += fmt.Sprintf("Aliases: %s\n", strings.Join(entity.Aliases, ", "))
+		}
+		text += "Observations:\n"
 		for i, obs := range entity.Observations {
 			text += fmt.Sprintf("%d. %s (source: %s)\n", i+1, obs.Text, obs.Source)
 		}
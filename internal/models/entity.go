@@ -1,18 +1,43 @@
 package models
 
 import (
+	"cmp"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
 )
 
 var validate *validator.Validate
 
+// Observation ranking defaults. Zero importance or confidence means the value
+// was not specified and the default applies.
+const (
+	MaxImportance     = 5
+	DefaultImportance = 3
+	DefaultConfidence = 1.0
+)
+
+// tagPattern restricts tags to lowercase slugs such as "security" or "team:payments"
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-:./]*$`)
+
 func init() {
 	validate = validator.New()
+
+	// Register custom validation functions
+	err := validate.RegisterValidation("tag", validateTag)
+	if err != nil {
+		panic(err)
+	}
 }
 
 // Entity represents a memory context entity with atomic facts
@@ -20,8 +45,15 @@ type Entity struct {
 	Name         string        `json:"name" validate:"required,min=1,max=200"`
 	EntityType   string        `json:"entityType" validate:"required,min=1,max=100"`
 	Observations []Observation `json:"observations"`
-	CreatedAt    time.Time     `json:"createdAt"`
-	LastModified time.Time     `json:"lastModified"`
+	Aliases      []string      `json:"aliases,omitempty" validate:"max=50,dive,min=1,max=200"`
+
+	// Attributes hold typed key/value facts about the entity as a whole,
+	// such as owner, language or status. Values are strings, numbers,
+	// booleans or lists of those.
+	Attributes map[string]any `json:"attributes,omitempty" validate:"max=50,dive,keys,min=1,max=50,endkeys"`
+
+	CreatedAt    time.Time `json:"createdAt"`
+	LastModified time.Time `json:"lastModified"`
 }
 
 // Observation represents an atomic fact about an entity
@@ -30,6 +62,58 @@ type Observation struct {
 	Text      string    `json:"text" validate:"required,min=1,max=1000"`
 	CreatedAt time.Time `json:"createdAt"`
 	Source    string    `json:"source" validate:"required,min=1,max=100"`
+
+	// Tags classify the fact (e.g. "security", "deprecated", "frontend")
+	Tags []string `json:"tags,omitempty" validate:"max=20,dive,min=1,max=50,tag"`
+
+	// Metadata holds free-form string attributes about the fact
+	Metadata map[string]string `json:"metadata,omitempty" validate:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+
+	// ExpiresAt marks a temporary fact; nil means the fact never expires
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Importance ranks the fact from 1 (trivial) to 5 (critical)
+	Importance int `json:"importance,omitempty" validate:"min=0,max=5"`
+
+	// Confidence expresses how certain the fact is, from 0 to 1
+	Confidence float64 `json:"confidence,omitempty" validate:"min=0,max=1"`
+
+	// Pinned facts are always surfaced first
+	Pinned bool `json:"pinned,omitempty"`
+
+	// UpdatedAt and UpdatedBy record the latest in-place edit of Text
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+
+	// Revisions holds previous texts, oldest first
+	Revisions []ObservationRevision `json:"revisions,omitempty"`
+
+	// Supersedes lists the IDs of older observations this one replaces
+	Supersedes []string `json:"supersedes,omitempty"`
+
+	// SupersededBy is the ID of the newer observation that replaced this one
+	SupersededBy string `json:"supersededBy,omitempty"`
+
+	// Provenance records where in a code base the fact was learned
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance identifies the code location an observation was learned from
+type Provenance struct {
+	Repository string `json:"repository,omitempty" validate:"max=300"`
+	FilePath   string `json:"filePath,omitempty" validate:"max=500"`
+	StartLine  int    `json:"startLine,omitempty" validate:"min=0"`
+	EndLine    int    `json:"endLine,omitempty" validate:"omitempty,gtefield=StartLine"`
+	Symbol     string `json:"symbol,omitempty" validate:"max=200"`
+	Commit     string `json:"commit,omitempty" validate:"omitempty,hexadecimal,min=7,max=64"`
+	Tool       string `json:"tool,omitempty" validate:"max=100"`
+}
+
+// ObservationRevision records a previous text of an observation
+type ObservationRevision struct {
+	Text      string    `json:"text"`
+	Author    string    `json:"author"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // Relation represents a relationship between two entities
@@ -39,6 +123,25 @@ type Relation struct {
 	To           string    `json:"to" validate:"required,min=1,max=200"`
 	RelationType string    `json:"relationType" validate:"required,min=1,max=100"`
 	CreatedAt    time.Time `json:"createdAt"`
+
+	// Properties hold free-form string attributes of the relation (e.g. "since": "v2")
+	Properties map[string]string `json:"properties,omitempty" validate:"max=20,dive,keys,min=1,max=50,endkeys,max=500"`
+
+	// Weight expresses the strength of the relation; zero means unspecified
+	Weight float64 `json:"weight,omitempty" validate:"min=0"`
+
+	// ValidFrom and ValidTo bound the period the relation holds; nil is unbounded
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+	ValidTo   *time.Time `json:"validTo,omitempty"`
+
+	// Source records where the relation was learned
+	Source string `json:"source,omitempty" validate:"max=100"`
+
+	// Inferred marks a relation derived from a stored one read in the other
+	// direction; inferred relations are never saved. They have no ID of their
+	// own: InferredFrom holds the ID of the stored relation.
+	Inferred     bool   `json:"inferred,omitempty"`
+	InferredFrom string `json:"inferredFrom,omitempty"`
 }
 
 // RelationSet represents a collection of relations
@@ -46,6 +149,23 @@ type RelationSet struct {
 	Relations []Relation `json:"relations"`
 }
 
+// RelationKey identifies a relation by its endpoints and type. Relation types
+// that differ only in case or separators share a key.
+type RelationKey struct {
+	From         string
+	To           string
+	RelationType string
+}
+
+// Undirected returns the key with its endpoints in a fixed order, so a
+// symmetric relation has the same key read in either direction
+func (k RelationKey) Undirected() RelationKey {
+	if k.To < k.From {
+		k.From, k.To = k.To, k.From
+	}
+	return k
+}
+
 // Validate validates the Entity struct
 func (e *Entity) Validate() error {
 	// Set timestamps if not provided
@@ -56,6 +176,13 @@ func (e *Entity) Validate() error {
 		e.LastModified = time.Now()
 	}
 
+	// Drop aliases that duplicate the name or each other
+	e.Aliases = e.normalizedAliases()
+
+	if err := e.normalizeAttributes(); err != nil {
+		return err
+	}
+
 	// Validate each observation
 	for i := range e.Observations {
 		if err := e.Observations[i].Validate(); err != nil {
@@ -83,6 +210,19 @@ func (o *Observation) Validate() error {
 		o.Source = "user_input"
 	}
 
+	// Normalize tags so filtering is case-insensitive
+	o.Tags = NormalizeTags(o.Tags)
+
+	// Normalize file paths so provenance queries match regardless of spelling
+	if o.Provenance != nil {
+		o.Provenance.FilePath = NormalizeFilePath(o.Provenance.FilePath)
+	}
+
+	if o.ExpiresAt != nil && !o.ExpiresAt.After(o.CreatedAt) {
+		return fmt.Errorf("observation expiry %s must be after creation time %s",
+			o.ExpiresAt.Format(time.RFC3339), o.CreatedAt.Format(time.RFC3339))
+	}
+
 	return validate.Struct(o)
 }
 
@@ -98,6 +238,11 @@ func (r *Relation) Validate() error {
 		r.CreatedAt = time.Now()
 	}
 
+	if r.ValidFrom != nil && r.ValidTo != nil && !r.ValidTo.After(*r.ValidFrom) {
+		return fmt.Errorf("relation validTo %s must be after validFrom %s",
+			r.ValidTo.Format(time.RFC3339), r.ValidFrom.Format(time.RFC3339))
+	}
+
 	return validate.Struct(r)
 }
 
@@ -174,11 +319,151 @@ func (e *Entity) AddObservationWithSource(text, source string) {
 	e.LastModified = time.Now()
 }
 
+// FindObservation returns a pointer to the observation with the given ID, or nil
+func (e *Entity) FindObservation(observationID string) *Observation {
+	for i := range e.Observations {
+		if e.Observations[i].ID == observationID {
+			return &e.Observations[i]
+		}
+	}
+	return nil
+}
+
+// UpdateObservation replaces the text of an observation in place, keeping its
+// ID and recording the previous text as a revision
+func (e *Entity) UpdateObservation(observationID, text, author string) (*Observation, error) {
+	obs := e.FindObservation(observationID)
+	if obs == nil {
+		return nil, fmt.Errorf("observation '%s' not found", observationID)
+	}
+
+	if text == obs.Text {
+		return obs, nil
+	}
+
+	if author == "" {
+		author = "user_input"
+	}
+
+	// Validate the new text on a copy so a failed update leaves the entity untouched
+	updated := *obs
+	updated.Revisions = append(append([]ObservationRevision(nil), obs.Revisions...), obs.CurrentRevision())
+	updated.Text = text
+	now := time.Now()
+	updated.UpdatedAt = &now
+	updated.UpdatedBy = author
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+
+	*obs = updated
+	e.LastModified = now
+	return obs, nil
+}
+
+// PinnedObservations returns the observations flagged as pinned
+func (e *Entity) PinnedObservations() []Observation {
+	var pinned []Observation
+	for _, obs := range e.Observations {
+		if obs.Pinned {
+			pinned = append(pinned, obs)
+		}
+	}
+	return pinned
+}
+
+// AppendObservation adds a fully populated observation to the entity and
+// returns a pointer to the stored copy
+func (e *Entity) AppendObservation(observation Observation) *Observation {
+	e.Observations = append(e.Observations, observation)
+	e.LastModified = time.Now()
+	return &e.Observations[len(e.Observations)-1]
+}
+
+// Supersede appends observation as the replacement for every observation
+// listed in observation.Supersedes, which are kept but marked as superseded
+func (e *Entity) Supersede(observation Observation) (*Observation, error) {
+	if len(observation.Supersedes) == 0 {
+		return nil, fmt.Errorf("observation does not supersede any observation")
+	}
+
+	for _, oldID := range observation.Supersedes {
+		old := e.FindObservation(oldID)
+		if old == nil {
+			return nil, fmt.Errorf("observation '%s' not found", oldID)
+		}
+		if old.SupersededBy != "" {
+			return nil, fmt.Errorf("observation '%s' is already superseded by '%s'", oldID, old.SupersededBy)
+		}
+	}
+
+	stored := e.AppendObservation(observation)
+	for _, oldID := range stored.Supersedes {
+		e.FindObservation(oldID).SupersededBy = stored.ID
+	}
+	return e.FindObservation(stored.ID), nil
+}
+
+// Lineage returns every observation connected to the given one through
+// supersession links, oldest first
+func (e *Entity) Lineage(observationID string) []Observation {
+	if e.FindObservation(observationID) == nil {
+		return nil
+	}
+
+	seen := map[string]bool{observationID: true}
+	queue := []string{observationID}
+	var lineage []Observation
+	for len(queue) > 0 {
+		obs := e.FindObservation(queue[0])
+		queue = queue[1:]
+		if obs == nil {
+			continue
+		}
+		lineage = append(lineage, *obs)
+
+		linked := append([]string{obs.SupersededBy}, obs.Supersedes...)
+		for _, id := range linked {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+
+	slices.SortStableFunc(lineage, func(a, b Observation) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return lineage
+}
+
+// PruneSupersessionLinks drops supersession references to observations that
+// no longer exist, so facts replaced by a removed observation resurface
+func (e *Entity) PruneSupersessionLinks() {
+	for i := range e.Observations {
+		obs := &e.Observations[i]
+		if obs.SupersededBy != "" && e.FindObservation(obs.SupersededBy) == nil {
+			obs.SupersededBy = ""
+		}
+		if len(obs.Supersedes) > 0 {
+			// Build a new slice; the old one may be shared with a filtered copy
+			var kept []string
+			for _, id := range obs.Supersedes {
+				if e.FindObservation(id) != nil {
+					kept = append(kept, id)
+				}
+			}
+			obs.Supersedes = kept
+		}
+	}
+}
+
 // RemoveObservation removes an observation by ID
 func (e *Entity) RemoveObservation(observationID string) bool {
 	for i, obs := range e.Observations {
 		if obs.ID == observationID {
 			e.Observations = append(e.Observations[:i], e.Observations[i+1:]...)
+			e.PruneSupersessionLinks()
 			e.LastModified = time.Now()
 			return true
 		}
@@ -191,7 +476,8 @@ func (e *Entity) GetObservationCount() int {
 	return len(e.Observations)
 }
 
-// SearchObservations returns observations containing the search text
+// SearchObservations returns observations containing the search text, or
+// words a typo or two from its words
 func (e *Entity) SearchObservations(searchText string) []Observation {
 	var results []Observation
 	for _, obs := range e.Observations {
@@ -202,6 +488,594 @@ func (e *Entity) SearchObservations(searchText string) []Observation {
 	return results
 }
 
+// Clone returns a deep copy of the entity, so a change can be prepared and
+// validated without touching the original, such as a cached entity
+func (e *Entity) Clone() *Entity {
+	clone := *e
+	clone.Aliases = slices.Clone(e.Aliases)
+	clone.Attributes = maps.Clone(e.Attributes)
+	if e.Observations != nil {
+		clone.Observations = make([]Observation, len(e.Observations))
+		for i := range e.Observations {
+			clone.Observations[i] = e.Observations[i].Clone()
+		}
+	}
+	return &clone
+}
+
+// Clone returns a deep copy of the observation
+func (o Observation) Clone() Observation {
+	o.Tags = slices.Clone(o.Tags)
+	o.Metadata = maps.Clone(o.Metadata)
+	o.Revisions = slices.Clone(o.Revisions)
+	o.Supersedes = slices.Clone(o.Supersedes)
+	if o.ExpiresAt != nil {
+		expiresAt := *o.ExpiresAt
+		o.ExpiresAt = &expiresAt
+	}
+	if o.UpdatedAt != nil {
+		updatedAt := *o.UpdatedAt
+		o.UpdatedAt = &updatedAt
+	}
+	if o.Provenance != nil {
+		provenance := *o.Provenance
+		o.Provenance = &provenance
+	}
+	return o
+}
+
+// FilterObservations returns a shallow copy of the entity containing only the
+// observations accepted by keep. The receiver is left untouched.
+func (e *Entity) FilterObservations(keep func(Observation) bool) *Entity {
+	filtered := *e
+	filtered.Observations = make([]Observation, 0, len(e.Observations))
+	for _, obs := range e.Observations {
+		if keep(obs) {
+			filtered.Observations = append(filtered.Observations, obs)
+		}
+	}
+	return &filtered
+}
+
+// Merge moves the other entity's observations into this one, skipping
+// observations whose text is already present, and adopts the other entity's
+// name and aliases as aliases. It returns the number of observations moved.
+func (e *Entity) Merge(other *Entity) int {
+	existing := make(map[string]bool, len(e.Observations))
+	for _, obs := range e.Observations {
+		existing[normalizeObservationText(obs.Text)] = true
+	}
+
+	moved := 0
+	for _, obs := range other.Observations {
+		key := normalizeObservationText(obs.Text)
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		e.Observations = append(e.Observations, obs)
+		moved++
+	}
+
+	// Links to observations dropped as duplicates no longer resolve
+	e.PruneSupersessionLinks()
+
+	for _, name := range other.Names() {
+		e.AddAlias(name)
+	}
+
+	// Keep this entity's attribute values where both define a key
+	for key, value := range other.Attributes {
+		if _, exists := e.Attributes[key]; !exists {
+			if e.Attributes == nil {
+				e.Attributes = make(map[string]any)
+			}
+			e.Attributes[key] = value
+		}
+	}
+
+	e.LastModified = time.Now()
+	return moved
+}
+
+// AddAlias records an alternative name for the entity. It returns false if
+// the alias is empty or already resolves to this entity.
+func (e *Entity) AddAlias(alias string) bool {
+	alias = strings.TrimSpace(alias)
+	if textutil.NormalizeName(alias) == "" || e.MatchesName(alias) {
+		return false
+	}
+
+	e.Aliases = append(e.Aliases, alias)
+	e.LastModified = time.Now()
+	return true
+}
+
+// RemoveAlias removes an alias, matching case- and separator-insensitively
+func (e *Entity) RemoveAlias(alias string) bool {
+	key := textutil.NormalizeName(alias)
+	for i, existing := range e.Aliases {
+		if textutil.NormalizeName(existing) == key {
+			e.Aliases = append(e.Aliases[:i:i], e.Aliases[i+1:]...)
+			e.LastModified = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesName reports whether name refers to this entity, either as its
+// name or one of its aliases, ignoring case and separators
+func (e *Entity) MatchesName(name string) bool {
+	key := textutil.NormalizeName(name)
+	if key == "" {
+		return false
+	}
+	for _, candidate := range e.Names() {
+		if textutil.NormalizeName(candidate) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the entity's name followed by its aliases
+func (e *Entity) Names() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// normalizedAliases returns the trimmed aliases without duplicates or
+// aliases equivalent to the entity name
+func (e *Entity) normalizedAliases() []string {
+	if len(e.Aliases) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{textutil.NormalizeName(e.Name): true}
+	aliases := make([]string, 0, len(e.Aliases))
+	for _, alias := range e.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := textutil.NormalizeName(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// Attribute Helper Methods
+
+// MaxAttributeLength limits string attribute values and lists
+const MaxAttributeLength = 500
+
+// SetAttributes merges attribute changes into the entity. A nil value
+// removes the attribute.
+func (e *Entity) SetAttributes(changes map[string]any) {
+	for key, value := range changes {
+		key = strings.TrimSpace(key)
+		if value == nil {
+			delete(e.Attributes, key)
+			continue
+		}
+		if e.Attributes == nil {
+			e.Attributes = make(map[string]any)
+		}
+		e.Attributes[key] = value
+	}
+	if len(e.Attributes) == 0 {
+		e.Attributes = nil
+	}
+	e.LastModified = time.Now()
+}
+
+// MatchesAttributes reports whether the entity has every attribute in
+// filter with the given value. Values are compared as text ignoring case;
+// list attributes match if any element does.
+func (e *Entity) MatchesAttributes(filter map[string]string) bool {
+	for key, want := range filter {
+		value, ok := e.Attributes[key]
+		if !ok {
+			return false
+		}
+		if list, isList := value.([]any); isList {
+			if !slices.ContainsFunc(list, func(item any) bool {
+				return strings.EqualFold(FormatAttributeValue(item), want)
+			}) {
+				return false
+			}
+		} else if !strings.EqualFold(FormatAttributeValue(value), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// AttributeSummary renders the attributes as "key=value" pairs sorted by key
+func (e *Entity) AttributeSummary() string {
+	keys := slices.Sorted(maps.Keys(e.Attributes))
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + FormatAttributeValue(e.Attributes[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// FormatAttributeValue renders an attribute value as text
+func FormatAttributeValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = FormatAttributeValue(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeAttributes converts attribute values to their JSON forms so
+// stored and in-memory entities compare alike, rejecting unsupported values
+func (e *Entity) normalizeAttributes() error {
+	if len(e.Attributes) == 0 {
+		e.Attributes = nil
+		return nil
+	}
+
+	for key, value := range e.Attributes {
+		normalized, err := normalizeAttributeValue(value, true)
+		if err != nil {
+			return fmt.Errorf("attribute '%s': %w", key, err)
+		}
+		e.Attributes[key] = normalized
+	}
+	return nil
+}
+
+func normalizeAttributeValue(value any, allowList bool) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if len(v) > MaxAttributeLength {
+			return nil, fmt.Errorf("value is longer than %d characters", MaxAttributeLength)
+		}
+		return v, nil
+	case bool, float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return normalizeAttributeValue(items, allowList)
+	case []any:
+		if !allowList {
+			return nil, fmt.Errorf("lists cannot be nested")
+		}
+		if len(v) > MaxAttributeLength {
+			return nil, fmt.Errorf("list has more than %d items", MaxAttributeLength)
+		}
+		items := make([]any, len(v))
+		for i, item := range v {
+			normalized, err := normalizeAttributeValue(item, false)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = normalized
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v (use a string, number, boolean or list)", value)
+	}
+}
+
+// Observation Helper Methods
+
+// HasTag reports whether the observation carries the given tag
+func (o *Observation) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range o.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllTags reports whether the observation carries every one of the given tags
+func (o *Observation) HasAllTags(tags []string) bool {
+	for _, tag := range tags {
+		if !o.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesMetadata reports whether the observation metadata contains every
+// key/value pair in the given map
+func (o *Observation) MatchesMetadata(metadata map[string]string) bool {
+	for key, value := range metadata {
+		if actual, ok := o.Metadata[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTTL makes the observation expire the given duration after its creation
+func (o *Observation) SetTTL(ttl time.Duration) {
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+	expiresAt := o.CreatedAt.Add(ttl)
+	o.ExpiresAt = &expiresAt
+}
+
+// IsExpired reports whether the observation has expired at the given time
+func (o *Observation) IsExpired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// ParseTTL parses a time-to-live such as "90m", "72h", "3d" or "2w".
+// Day and week suffixes are accepted in addition to Go duration syntax.
+func ParseTTL(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("ttl must not be empty")
+	}
+
+	var ttl time.Duration
+	var err error
+	switch unit := value[len(value)-1]; unit {
+	case 'd', 'w':
+		var n float64
+		n, err = strconv.ParseFloat(value[:len(value)-1], 64)
+		hours := 24.0
+		if unit == 'w' {
+			hours = 24 * 7
+		}
+		ttl = time.Duration(n * hours * float64(time.Hour))
+	default:
+		ttl, err = time.ParseDuration(value)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q: use a duration such as 90m, 72h, 3d or 2w", value)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("ttl %q must be positive", value)
+	}
+	return ttl, nil
+}
+
+// CurrentRevision describes the observation's current text as a revision
+func (o *Observation) CurrentRevision() ObservationRevision {
+	if o.UpdatedAt != nil {
+		return ObservationRevision{Text: o.Text, Author: o.UpdatedBy, Timestamp: *o.UpdatedAt}
+	}
+	return ObservationRevision{Text: o.Text, Author: o.Source, Timestamp: o.CreatedAt}
+}
+
+// History returns every version of the observation text, oldest first,
+// ending with the current text
+func (o *Observation) History() []ObservationRevision {
+	history := make([]ObservationRevision, 0, len(o.Revisions)+1)
+	history = append(history, o.Revisions...)
+	return append(history, o.CurrentRevision())
+}
+
+// IsSuperseded reports whether a newer observation has replaced this one
+func (o *Observation) IsSuperseded() bool {
+	return o.SupersededBy != ""
+}
+
+// EffectiveImportance returns the importance, falling back to DefaultImportance
+func (o *Observation) EffectiveImportance() int {
+	if o.Importance == 0 {
+		return DefaultImportance
+	}
+	return o.Importance
+}
+
+// EffectiveConfidence returns the confidence, falling back to DefaultConfidence
+func (o *Observation) EffectiveConfidence() float64 {
+	if o.Confidence == 0 {
+		return DefaultConfidence
+	}
+	return o.Confidence
+}
+
+// CompareObservations orders observations for display: pinned facts first,
+// then by descending importance and descending confidence
+func CompareObservations(a, b Observation) int {
+	if a.Pinned != b.Pinned {
+		if a.Pinned {
+			return -1
+		}
+		return 1
+	}
+	if c := cmp.Compare(b.EffectiveImportance(), a.EffectiveImportance()); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.EffectiveConfidence(), a.EffectiveConfidence())
+}
+
+// SortObservations sorts observations by rank, keeping insertion order for ties
+func SortObservations(observations []Observation) {
+	slices.SortStableFunc(observations, CompareObservations)
+}
+
+// MatchesFile reports whether the provenance refers to the given file, or to a
+// file inside the given directory when the path ends with a slash
+func (p *Provenance) MatchesFile(filePath string) bool {
+	if p == nil || p.FilePath == "" {
+		return false
+	}
+
+	isDir := strings.HasSuffix(filePath, "/")
+	filePath = NormalizeFilePath(filePath)
+	if isDir || filePath == "." {
+		return filePath == "." || strings.HasPrefix(p.FilePath, filePath+"/")
+	}
+	return p.FilePath == filePath
+}
+
+// String renders the provenance as repository:path:lines#symbol@commit
+func (p *Provenance) String() string {
+	if p == nil {
+		return ""
+	}
+
+	var location strings.Builder
+	if p.Repository != "" {
+		location.WriteString(p.Repository + ":")
+	}
+	location.WriteString(p.FilePath)
+	if p.StartLine > 0 {
+		location.WriteString(fmt.Sprintf(":%d", p.StartLine))
+		if p.EndLine > p.StartLine {
+			location.WriteString(fmt.Sprintf("-%d", p.EndLine))
+		}
+	}
+	if p.Symbol != "" {
+		location.WriteString("#" + p.Symbol)
+	}
+	if p.Commit != "" {
+		commit := p.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		location.WriteString("@" + commit)
+	}
+	return location.String()
+}
+
+// NormalizeFilePath converts a file path to a clean, slash-separated path
+// relative to the repository root
+func NormalizeFilePath(filePath string) string {
+	filePath = strings.TrimSpace(strings.ReplaceAll(filePath, "\\", "/"))
+	if filePath == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Clean(filePath), "./")
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and duplicate entries
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Relation Helper Methods
+
+// Reversed returns the relation read from target to source under the given
+// relation type, marked as inferred from the stored relation rather than
+// carrying its ID
+func (r Relation) Reversed(relationType string) Relation {
+	reversed := r
+	reversed.ID = ""
+	reversed.From, reversed.To = r.To, r.From
+	reversed.RelationType = relationType
+	reversed.Properties = maps.Clone(r.Properties)
+	reversed.Inferred = true
+	reversed.InferredFrom = r.ID
+	return reversed
+}
+
+// Equal reports whether two relations have the same ID and content
+func (r Relation) Equal(other Relation) bool {
+	return r.ID == other.ID && r.From == other.From && r.To == other.To &&
+		r.RelationType == other.RelationType && r.CreatedAt.Equal(other.CreatedAt) &&
+		maps.Equal(r.Properties, other.Properties) && r.Weight == other.Weight &&
+		equalTimes(r.ValidFrom, other.ValidFrom) && equalTimes(r.ValidTo, other.ValidTo) &&
+		r.Source == other.Source && r.Inferred == other.Inferred && r.InferredFrom == other.InferredFrom
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// IsValidAt reports whether the relation holds at the given time
+func (r *Relation) IsValidAt(t time.Time) bool {
+	if r.ValidFrom != nil && t.Before(*r.ValidFrom) {
+		return false
+	}
+	if r.ValidTo != nil && !t.Before(*r.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// MatchesProperties reports whether the relation has all the given property values
+func (r *Relation) MatchesProperties(properties map[string]string) bool {
+	for key, value := range properties {
+		if r.Properties[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Details renders the relation's weight, validity, source and properties as
+// a suffix for text output, or "" if it has none
+func (r *Relation) Details() string {
+	var details []string
+	if r.Weight != 0 {
+		details = append(details, "weight "+strconv.FormatFloat(r.Weight, 'f', -1, 64))
+	}
+	if r.ValidFrom != nil {
+		details = append(details, "from "+r.ValidFrom.Format("2006-01-02"))
+	}
+	if r.ValidTo != nil {
+		details = append(details, "until "+r.ValidTo.Format("2006-01-02"))
+	}
+	if r.Source != "" {
+		details = append(details, "source: "+r.Source)
+	}
+	for _, key := range slices.Sorted(maps.Keys(r.Properties)) {
+		details = append(details, key+"="+r.Properties[key])
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// Key returns the key that identifies the relation within a set
+func (r Relation) Key() RelationKey {
+	return RelationKey{From: r.From, To: r.To, RelationType: textutil.NormalizeName(r.RelationType)}
+}
+
 // RelationSet Helper Methods
 
 // AddRelation adds a new relation to the set
@@ -221,6 +1095,63 @@ func (rs *RelationSet) RemoveRelation(relationID string) bool {
 	return false
 }
 
+// FindRelation returns the relation with the given endpoints and type
+func (rs *RelationSet) FindRelation(from, to, relationType string) (*Relation, bool) {
+	key := Relation{From: from, To: to, RelationType: relationType}.Key()
+	for i := range rs.Relations {
+		if rs.Relations[i].Key() == key {
+			return &rs.Relations[i], true
+		}
+	}
+	return nil, false
+}
+
+// Dedupe removes relations that repeat the key of an earlier relation, such
+// as Relation.Key or a key that ignores the direction of symmetric types.
+// The properties, weight, source and validity period of a removed relation
+// are kept where the remaining relation does not set them. It returns the
+// removed relations.
+func (rs *RelationSet) Dedupe(keyOf func(Relation) RelationKey) []Relation {
+	index := make(map[RelationKey]int, len(rs.Relations))
+	kept := make([]Relation, 0, len(rs.Relations))
+	var removed []Relation
+
+	for _, rel := range rs.Relations {
+		i, duplicate := index[keyOf(rel)]
+		if !duplicate {
+			index[keyOf(rel)] = len(kept)
+			kept = append(kept, rel)
+			continue
+		}
+
+		first := &kept[i]
+		for key, value := range rel.Properties {
+			if _, ok := first.Properties[key]; !ok {
+				if first.Properties == nil {
+					first.Properties = make(map[string]string)
+				}
+				first.Properties[key] = value
+			}
+		}
+		if first.Weight == 0 {
+			first.Weight = rel.Weight
+		}
+		if first.Source == "" {
+			first.Source = rel.Source
+		}
+		if first.ValidFrom == nil {
+			first.ValidFrom = rel.ValidFrom
+		}
+		if first.ValidTo == nil {
+			first.ValidTo = rel.ValidTo
+		}
+		removed = append(removed, rel)
+	}
+
+	rs.Relations = kept
+	return removed
+}
+
 // GetRelationsByEntity returns all relations involving the specified entity
 func (rs *RelationSet) GetRelationsByEntity(entityName string) []Relation {
 	var results []Relation
@@ -232,6 +1163,47 @@ func (rs *RelationSet) GetRelationsByEntity(entityName string) []Relation {
 	return results
 }
 
+// ReplaceEntity rewrites relations that reference oldName to reference
+// newName instead. Relations that become duplicates of an existing relation
+// under keyOf, or that would make an entity relate to itself, are dropped.
+// It returns the number of relations rewritten and dropped.
+func (rs *RelationSet) ReplaceEntity(oldName, newName string, keyOf func(Relation) RelationKey) (rewritten, dropped int) {
+	seen := make(map[RelationKey]bool, len(rs.Relations))
+	for _, rel := range rs.Relations {
+		if rel.From != oldName && rel.To != oldName {
+			seen[keyOf(rel)] = true
+		}
+	}
+
+	kept := make([]Relation, 0, len(rs.Relations))
+	for _, rel := range rs.Relations {
+		if rel.From != oldName && rel.To != oldName {
+			kept = append(kept, rel)
+			continue
+		}
+
+		wasSelfLoop := rel.From == rel.To
+		if rel.From == oldName {
+			rel.From = newName
+		}
+		if rel.To == oldName {
+			rel.To = newName
+		}
+
+		key := keyOf(rel)
+		if seen[key] || (rel.From == rel.To && !wasSelfLoop) {
+			dropped++
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, rel)
+		rewritten++
+	}
+
+	rs.Relations = kept
+	return rewritten, dropped
+}
+
 // GetRelationsByType returns all relations of the specified type
 func (rs *RelationSet) GetRelationsByType(relationType string) []Relation {
 	var results []Relation
@@ -243,11 +1215,27 @@ func (rs *RelationSet) GetRelationsByType(relationType string) []Relation {
 	return results
 }
 
-// Helper function for case-insensitive string search
+// normalizeObservationText folds case and whitespace so duplicate facts
+// compare equal
+func normalizeObservationText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// normalizeTag converts a tag to its canonical lowercase form
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// validateTag checks that a tag is a lowercase slug
+func validateTag(fl validator.FieldLevel) bool {
+	return tagPattern.MatchString(fl.Field().String())
+}
+
+// contains reports whether text contains searchText ignoring case, or each
+// of its words up to a typo or two
 func contains(text, searchText string) bool {
-	// Simple case-insensitive contains check
-	// Convert to lowercase for case-insensitive search
-	textLower := strings.ToLower(text)
-	searchLower := strings.ToLower(searchText)
-	return strings.Contains(textLower, searchLower)
+	if strings.Contains(strings.ToLower(text), strings.ToLower(searchText)) {
+		return true
+	}
+	return textutil.FuzzyContains(text, searchText, textutil.DefaultFuzziness)
 }
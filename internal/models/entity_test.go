@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 )
 
 func TestNewEntity(t *testing.T) {
@@ -86,6 +87,13 @@ func TestEntitySearchObservations(t *testing.T) {
 	if results[0].Text != "use conventional commits" {
 		t.Errorf("Expected search result 'use conventional commits', got '%s'", results[0].Text)
 	}
+
+	if results := entity.SearchObservations("conventinal comits"); len(results) != 1 {
+		t.Errorf("Expected typos to be tolerated, got %d results", len(results))
+	}
+	if results := entity.SearchObservations("ape"); len(results) != 0 {
+		t.Errorf("Expected short words to match exactly, got %d results", len(results))
+	}
 }
 
 func TestEntityJSONMarshaling(t *testing.T) {
@@ -250,3 +258,490 @@ func TestRelationValidation(t *testing.T) {
 		t.Error("Expected invalid relation (empty from) to fail validation")
 	}
 }
+
+func TestObservationTagsAndMetadata(t *testing.T) {
+	obs := NewObservation("rotate API keys every 90 days")
+	obs.Tags = []string{" Security ", "security", "OPS"}
+	obs.Metadata = map[string]string{"team": "platform"}
+
+	if err := obs.Validate(); err != nil {
+		t.Fatalf("Expected observation with tags to pass validation, got error: %v", err)
+	}
+
+	if len(obs.Tags) != 2 || obs.Tags[0] != "security" || obs.Tags[1] != "ops" {
+		t.Errorf("Expected normalized tags [security ops], got %v", obs.Tags)
+	}
+
+	if !obs.HasAllTags([]string{"SECURITY", "ops"}) {
+		t.Error("Expected observation to match tags case-insensitively")
+	}
+
+	if obs.HasTag("frontend") {
+		t.Error("Expected observation not to carry tag 'frontend'")
+	}
+
+	if !obs.MatchesMetadata(map[string]string{"team": "platform"}) {
+		t.Error("Expected observation to match metadata")
+	}
+
+	if obs.MatchesMetadata(map[string]string{"team": "payments"}) {
+		t.Error("Expected observation not to match different metadata value")
+	}
+
+	// Test observation with invalid tag
+	invalidObs := NewObservation("use conventional commits")
+	invalidObs.Tags = []string{"has space"}
+	if err := invalidObs.Validate(); err == nil {
+		t.Error("Expected observation with invalid tag to fail validation")
+	}
+}
+
+func TestEntityFilterObservations(t *testing.T) {
+	entity := NewEntity("auth_service", "service")
+	entity.AddObservation("tokens expire after 1 hour")
+	secure := NewObservation("passwords are hashed with bcrypt")
+	secure.Tags = []string{"security"}
+	entity.AppendObservation(secure)
+
+	filtered := entity.FilterObservations(func(obs Observation) bool {
+		return obs.HasTag("security")
+	})
+
+	if filtered.GetObservationCount() != 1 {
+		t.Errorf("Expected 1 filtered observation, got %d", filtered.GetObservationCount())
+	}
+
+	if entity.GetObservationCount() != 2 {
+		t.Errorf("Expected original entity to keep 2 observations, got %d", entity.GetObservationCount())
+	}
+}
+
+func TestEntityClone(t *testing.T) {
+	entity := NewEntity("api", "service")
+	entity.AddAlias("gateway")
+	entity.SetAttributes(map[string]any{"owner": "team-a"})
+	entity.AddObservation("Uses JWT tokens")
+	obs := &entity.Observations[0]
+	obs.Tags = []string{"security"}
+	obs.Metadata = map[string]string{"ticket": "SEC-1"}
+	obs.SetTTL(time.Hour)
+	obs.Provenance = &Provenance{FilePath: "auth.go"}
+
+	clone := entity.Clone()
+	clone.AddObservation("Rotates keys daily")
+	clone.AddAlias("edge")
+	clone.SetAttributes(map[string]any{"owner": "team-b"})
+	cloned := &clone.Observations[0]
+	cloned.Text = "changed"
+	cloned.Tags[0] = "changed"
+	cloned.Metadata["ticket"] = "changed"
+	*cloned.ExpiresAt = time.Time{}
+	cloned.Provenance.FilePath = "changed"
+
+	if len(entity.Observations) != 1 || len(entity.Aliases) != 1 || entity.Attributes["owner"] != "team-a" {
+		t.Errorf("Expected the original entity untouched, got %+v", entity)
+	}
+	if obs.Text != "Uses JWT tokens" || obs.Tags[0] != "security" || obs.Metadata["ticket"] != "SEC-1" ||
+		obs.ExpiresAt.IsZero() || obs.Provenance.FilePath != "auth.go" {
+		t.Errorf("Expected the original observation untouched, got %+v", obs)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "90m", want: 90 * time.Minute},
+		{input: "72h", want: 72 * time.Hour},
+		{input: "3d", want: 72 * time.Hour},
+		{input: "2w", want: 14 * 24 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTTL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTTL(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTTL(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObservationExpiry(t *testing.T) {
+	obs := NewObservation("the staging DB is down until Friday")
+	obs.SetTTL(time.Hour)
+
+	if err := obs.Validate(); err != nil {
+		t.Fatalf("Expected observation with TTL to pass validation, got error: %v", err)
+	}
+
+	if obs.IsExpired(time.Now()) {
+		t.Error("Expected observation not to be expired yet")
+	}
+
+	if !obs.IsExpired(time.Now().Add(2 * time.Hour)) {
+		t.Error("Expected observation to be expired after its TTL")
+	}
+
+	// Test expiry before creation
+	past := obs.CreatedAt.Add(-time.Minute)
+	obs.ExpiresAt = &past
+	if err := obs.Validate(); err == nil {
+		t.Error("Expected observation expiring before creation to fail validation")
+	}
+}
+
+func TestSortObservations(t *testing.T) {
+	trivial := NewObservation("prefers tabs in Makefiles")
+	trivial.Importance = 1
+	critical := NewObservation("never commit secrets")
+	critical.Importance = 5
+	unsure := NewObservation("never commit generated files")
+	unsure.Importance = 5
+	unsure.Confidence = 0.4
+	pinned := NewObservation("run make lint before pushing")
+	pinned.Pinned = true
+	normal := NewObservation("use conventional commits")
+
+	observations := []Observation{trivial, normal, unsure, pinned, critical}
+	SortObservations(observations)
+
+	want := []string{pinned.Text, critical.Text, unsure.Text, normal.Text, trivial.Text}
+	for i, obs := range observations {
+		if obs.Text != want[i] {
+			t.Errorf("Position %d: expected '%s', got '%s'", i, want[i], obs.Text)
+		}
+	}
+
+	// Test ranking field validation
+	invalidObs := NewObservation("use conventional commits")
+	invalidObs.Importance = 9
+	if err := invalidObs.Validate(); err == nil {
+		t.Error("Expected observation with importance above 5 to fail validation")
+	}
+}
+
+func TestEntityUpdateObservation(t *testing.T) {
+	entity := NewEntity("api_patterns", "pattern")
+	entity.AddObservation("use REST endpoints")
+	observationID := entity.Observations[0].ID
+
+	obs, err := entity.UpdateObservation(observationID, "use gRPC endpoints", "copilot")
+	if err != nil {
+		t.Fatalf("Failed to update observation: %v", err)
+	}
+
+	if obs.ID != observationID {
+		t.Errorf("Expected observation ID to be preserved, got '%s'", obs.ID)
+	}
+
+	if obs.Text != "use gRPC endpoints" {
+		t.Errorf("Expected updated text 'use gRPC endpoints', got '%s'", obs.Text)
+	}
+
+	history := obs.History()
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+
+	if history[0].Text != "use REST endpoints" || history[0].Author != "user_input" {
+		t.Errorf("Expected original revision by 'user_input', got %+v", history[0])
+	}
+
+	if history[1].Text != "use gRPC endpoints" || history[1].Author != "copilot" {
+		t.Errorf("Expected current revision by 'copilot', got %+v", history[1])
+	}
+
+	// Test invalid update leaves the observation untouched
+	if _, err := entity.UpdateObservation(observationID, "", "copilot"); err == nil {
+		t.Error("Expected update with empty text to fail")
+	}
+
+	if entity.Observations[0].Text != "use gRPC endpoints" || len(entity.Observations[0].Revisions) != 1 {
+		t.Error("Expected failed update to leave observation unchanged")
+	}
+
+	// Test unknown observation
+	if _, err := entity.UpdateObservation("missing", "text", ""); err == nil {
+		t.Error("Expected update of unknown observation to fail")
+	}
+}
+
+func TestEntitySupersede(t *testing.T) {
+	entity := NewEntity("architecture", "decision")
+	entity.AddObservation("services talk over REST")
+	oldID := entity.Observations[0].ID
+
+	replacement := NewObservation("services talk over gRPC")
+	replacement.Supersedes = []string{oldID}
+	stored, err := entity.Supersede(replacement)
+	if err != nil {
+		t.Fatalf("Failed to supersede observation: %v", err)
+	}
+
+	old := entity.FindObservation(oldID)
+	if !old.IsSuperseded() || old.SupersededBy != stored.ID {
+		t.Errorf("Expected old observation to be superseded by '%s', got '%s'", stored.ID, old.SupersededBy)
+	}
+
+	lineage := entity.Lineage(stored.ID)
+	if len(lineage) != 2 || lineage[0].ID != oldID {
+		t.Errorf("Expected lineage [old, new], got %+v", lineage)
+	}
+
+	// Test superseding an already superseded observation
+	again := NewObservation("services talk over GraphQL")
+	again.Supersedes = []string{oldID}
+	if _, err := entity.Supersede(again); err == nil {
+		t.Error("Expected superseding an already superseded observation to fail")
+	}
+
+	// Removing the replacement resurfaces the old observation
+	entity.RemoveObservation(stored.ID)
+	if entity.FindObservation(oldID).IsSuperseded() {
+		t.Error("Expected old observation to resurface after its replacement was removed")
+	}
+}
+
+func TestObservationProvenance(t *testing.T) {
+	obs := NewObservation("handleMemoryRemember creates missing entities")
+	obs.Provenance = &Provenance{
+		FilePath:  "./internal\\api/memory.go",
+		StartLine: 131,
+		EndLine:   200,
+		Symbol:    "Router.handleMemoryRemember",
+		Commit:    "c619213",
+	}
+	if err := obs.Validate(); err != nil {
+		t.Fatalf("Expected valid provenance, got %v", err)
+	}
+
+	if obs.Provenance.FilePath != "internal/api/memory.go" {
+		t.Errorf("Expected normalized file path, got '%s'", obs.Provenance.FilePath)
+	}
+
+	for path, want := range map[string]bool{
+		"internal/api/memory.go":   true,
+		"./internal/api/memory.go": true,
+		"internal/api/":            true,
+		"internal/ap/":             false,
+		"internal/api":             false,
+		"internal/api/mcp.go":      false,
+	} {
+		if got := obs.Provenance.MatchesFile(path); got != want {
+			t.Errorf("MatchesFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if got := obs.Provenance.String(); got != "internal/api/memory.go:131-200#Router.handleMemoryRemember@c619213" {
+		t.Errorf("Unexpected provenance string '%s'", got)
+	}
+
+	// Test an inverted line range
+	obs.Provenance.EndLine = 100
+	if err := obs.Validate(); err == nil {
+		t.Error("Expected validation error for end line before start line")
+	}
+
+	// Test an invalid commit SHA
+	obs.Provenance.EndLine = 0
+	obs.Provenance.Commit = "not-a-sha"
+	if err := obs.Validate(); err == nil {
+		t.Error("Expected validation error for invalid commit")
+	}
+}
+
+func TestEntityAliases(t *testing.T) {
+	entity := NewEntity("auth_service", "component")
+
+	if !entity.AddAlias("authentication-service") {
+		t.Error("Expected alias to be added")
+	}
+	if entity.AddAlias("AuthService") {
+		t.Error("Expected alias equivalent to the name to be rejected")
+	}
+	if entity.AddAlias("Authentication Service") {
+		t.Error("Expected alias equivalent to an existing alias to be rejected")
+	}
+
+	if !entity.MatchesName("AUTH-SERVICE") || !entity.MatchesName("authenticationService") {
+		t.Error("Expected name and alias to match ignoring case and separators")
+	}
+	if entity.MatchesName("billing") {
+		t.Error("Expected unrelated name not to match")
+	}
+
+	if !entity.RemoveAlias("AuthenticationService") || len(entity.Aliases) != 0 {
+		t.Errorf("Expected alias to be removed, got %v", entity.Aliases)
+	}
+
+	// Validation drops duplicate aliases loaded from disk
+	entity.Aliases = []string{"auth", "AUTH", "auth_service"}
+	if err := entity.Validate(); err != nil {
+		t.Fatalf("Failed to validate entity: %v", err)
+	}
+	if len(entity.Aliases) != 1 || entity.Aliases[0] != "auth" {
+		t.Errorf("Expected aliases [auth], got %v", entity.Aliases)
+	}
+}
+
+func TestEntityMerge(t *testing.T) {
+	target := NewEntity("auth_service", "component")
+	target.AddObservation("issues JWTs")
+	source := NewEntity("AuthSvc", "component")
+	source.AddAlias("login")
+	source.AddObservation("Issues  JWTs")
+	source.AddObservation("rate limits logins")
+
+	if moved := target.Merge(source); moved != 1 {
+		t.Errorf("Expected 1 observation moved, got %d", moved)
+	}
+	if target.GetObservationCount() != 2 {
+		t.Errorf("Expected 2 observations after merge, got %d", target.GetObservationCount())
+	}
+	if !target.MatchesName("authsvc") || !target.MatchesName("login") {
+		t.Errorf("Expected source names to become aliases, got %v", target.Aliases)
+	}
+}
+
+func TestEntityMergeAttributes(t *testing.T) {
+	target := NewEntity("auth_service", "component")
+	target.SetAttributes(map[string]any{"owner": "team-identity"})
+	source := NewEntity("AuthSvc", "component")
+	source.SetAttributes(map[string]any{"owner": "team-legacy", "language": "go"})
+
+	target.Merge(source)
+	if target.Attributes["owner"] != "team-identity" || target.Attributes["language"] != "go" {
+		t.Errorf("Expected target attributes to win and missing ones to be adopted, got %v", target.Attributes)
+	}
+}
+
+func TestEntityAttributes(t *testing.T) {
+	entity := NewEntity("payments", "service")
+	entity.SetAttributes(map[string]any{"owner": "team-payments", "replicas": 3, "languages": []string{"go", "sql"}})
+
+	if err := entity.Validate(); err != nil {
+		t.Fatalf("Expected valid attributes, got %v", err)
+	}
+	if entity.Attributes["replicas"] != float64(3) {
+		t.Errorf("Expected numbers to be normalized to float64, got %T", entity.Attributes["replicas"])
+	}
+
+	if !entity.MatchesAttributes(map[string]string{"owner": "Team-Payments", "replicas": "3", "languages": "sql"}) {
+		t.Error("Expected attributes to match")
+	}
+	if entity.MatchesAttributes(map[string]string{"owner": "team-search"}) || entity.MatchesAttributes(map[string]string{"status": "active"}) {
+		t.Error("Expected mismatched or missing attributes not to match")
+	}
+
+	if got := entity.AttributeSummary(); got != "languages=go, sql, owner=team-payments, replicas=3" {
+		t.Errorf("Unexpected attribute summary %q", got)
+	}
+
+	entity.SetAttributes(map[string]any{"owner": nil})
+	if _, ok := entity.Attributes["owner"]; ok {
+		t.Error("Expected nil value to remove the attribute")
+	}
+
+	entity.SetAttributes(map[string]any{"nested": map[string]any{"a": 1}})
+	if err := entity.Validate(); err == nil {
+		t.Error("Expected object attribute value to be rejected")
+	}
+}
+
+func TestRelationSetReplaceEntity(t *testing.T) {
+	rs := &RelationSet{}
+	rs.AddRelation("api", "auth_svc", "depends_on")
+	rs.AddRelation("api", "auth_service", "depends_on")
+	rs.AddRelation("auth_svc", "auth_service", "related_to")
+	rs.AddRelation("auth_svc", "db", "uses")
+
+	rewritten, dropped := rs.ReplaceEntity("auth_svc", "auth_service", Relation.Key)
+	if rewritten != 1 || dropped != 2 {
+		t.Errorf("Expected 1 rewritten and 2 dropped, got %d and %d", rewritten, dropped)
+	}
+	if len(rs.Relations) != 2 {
+		t.Fatalf("Expected 2 relations, got %+v", rs.Relations)
+	}
+	if len(rs.GetRelationsByEntity("auth_svc")) != 0 {
+		t.Error("Expected no relations to reference the old name")
+	}
+}
+
+func TestRelationValidityAndDetails(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	relation := NewRelation("service_a", "service_b", "depends_on")
+	relation.Weight = 0.8
+	relation.ValidFrom = &from
+	relation.ValidTo = &to
+	relation.Source = "architecture review"
+	relation.Properties = map[string]string{"since": "v2"}
+
+	if err := relation.Validate(); err != nil {
+		t.Fatalf("Expected relation to be valid, got %v", err)
+	}
+
+	if !relation.IsValidAt(from) || !relation.IsValidAt(from.AddDate(0, 6, 0)) {
+		t.Error("Expected relation to hold within its validity period")
+	}
+	if relation.IsValidAt(from.Add(-time.Second)) || relation.IsValidAt(to) {
+		t.Error("Expected relation not to hold outside its validity period")
+	}
+
+	if !relation.MatchesProperties(map[string]string{"since": "v2"}) || relation.MatchesProperties(map[string]string{"since": "v1"}) {
+		t.Error("Expected properties to match exactly")
+	}
+
+	want := " (weight 0.8, from 2024-01-01, until 2025-01-01, source: architecture review, since=v2)"
+	if got := relation.Details(); got != want {
+		t.Errorf("Expected details %q, got %q", want, got)
+	}
+	if got := NewRelation("a", "b", "uses"); got.Details() != "" {
+		t.Errorf("Expected no details for a plain relation, got %q", got.Details())
+	}
+
+	relation.ValidTo = &from
+	if err := relation.Validate(); err == nil {
+		t.Error("Expected validTo before validFrom to be rejected")
+	}
+
+	relation.ValidTo = nil
+	relation.Weight = -1
+	if err := relation.Validate(); err == nil {
+		t.Error("Expected negative weight to be rejected")
+	}
+}
+
+func TestRelationSetDedupe(t *testing.T) {
+	rs := &RelationSet{}
+	rs.AddRelation("api", "auth", "depends_on")
+	rs.AddRelation("api", "db", "uses")
+	rs.AddRelation("api", "auth", "Depends-On")
+	rs.Relations[2].Source = "code review"
+	rs.Relations[2].Properties = map[string]string{"since": "v2"}
+
+	if _, ok := rs.FindRelation("api", "auth", "DEPENDS_ON"); !ok {
+		t.Error("Expected relation types to match ignoring case and separators")
+	}
+
+	removed := rs.Dedupe(Relation.Key)
+	if len(removed) != 1 || len(rs.Relations) != 2 {
+		t.Fatalf("Expected 1 duplicate removed, got %d removed and %d kept", len(removed), len(rs.Relations))
+	}
+	if kept := rs.Relations[0]; kept.Source != "code review" || kept.Properties["since"] != "v2" {
+		t.Errorf("Expected duplicate's source and properties to be kept, got %+v", kept)
+	}
+	if removed := rs.Dedupe(Relation.Key); len(removed) != 0 {
+		t.Errorf("Expected no duplicates left, got %+v", removed)
+	}
+}
@@ -0,0 +1,192 @@
+package provenance
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// StaleFact is an observation whose referenced code no longer exists
+type StaleFact struct {
+	storage.SearchResult
+	Reason string `json:"reason"`
+}
+
+// Checker verifies observation provenance against a working tree
+type Checker struct {
+	root  string
+	files map[string]*fileInfo
+}
+
+type fileInfo struct {
+	missing bool
+	lines   []string
+}
+
+// ResolveRoot resolves a working tree root given by a client against the
+// workspace. Relative roots are taken from the workspace, an empty root is
+// the workspace itself, and roots outside it are rejected.
+func ResolveRoot(workspace, root string) (string, error) {
+	absWorkspace, err := resolvePath(workspace)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+	if root == "" {
+		return absWorkspace, nil
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(absWorkspace, root)
+	}
+
+	absRoot, err := resolvePath(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve working tree root: %w", err)
+	}
+	if !within(absWorkspace, absRoot) {
+		return "", fmt.Errorf("working tree root '%s' is outside the workspace", root)
+	}
+	return absRoot, nil
+}
+
+// NewChecker creates a checker for the working tree rooted at root
+func NewChecker(root string) (*Checker, error) {
+	absRoot, err := resolvePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve working tree root: %w", err)
+	}
+
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access working tree root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("working tree root '%s' is not a directory", root)
+	}
+
+	return &Checker{root: absRoot, files: make(map[string]*fileInfo)}, nil
+}
+
+// FindStale returns the results whose provenance refers to a file, line range
+// or symbol that is no longer present in the working tree. Results without a
+// file path in their provenance are skipped.
+func (c *Checker) FindStale(results []storage.SearchResult) []StaleFact {
+	var stale []StaleFact
+	for _, result := range results {
+		if reason := c.Check(result); reason != "" {
+			stale = append(stale, StaleFact{SearchResult: result, Reason: reason})
+		}
+	}
+	return stale
+}
+
+// Check returns why the result's provenance is stale, or an empty string if
+// it still matches the working tree
+func (c *Checker) Check(result storage.SearchResult) string {
+	prov := result.Observation.Provenance
+	if prov == nil || prov.FilePath == "" {
+		return ""
+	}
+
+	file, err := c.load(prov.FilePath)
+	if err != nil {
+		return err.Error()
+	}
+	if file.missing {
+		return fmt.Sprintf("file '%s' no longer exists", prov.FilePath)
+	}
+
+	if prov.EndLine > 0 && prov.EndLine < prov.StartLine {
+		return fmt.Sprintf("line range %d-%d in '%s' is reversed", prov.StartLine, prov.EndLine, prov.FilePath)
+	}
+	if last := max(prov.StartLine, prov.EndLine); last > len(file.lines) {
+		return fmt.Sprintf("line %d is beyond the end of '%s' (%d lines)", last, prov.FilePath, len(file.lines))
+	}
+
+	if prov.Symbol != "" && !containsSymbol(file.lines, prov.Symbol) {
+		return fmt.Sprintf("symbol '%s' no longer appears in '%s'", prov.Symbol, prov.FilePath)
+	}
+
+	return ""
+}
+
+// load reads a file relative to the root, caching the result
+func (c *Checker) load(filePath string) (*fileInfo, error) {
+	if file, ok := c.files[filePath]; ok {
+		return file, nil
+	}
+
+	if !filepath.IsLocal(filepath.FromSlash(filePath)) {
+		return nil, fmt.Errorf("file path '%s' is outside the working tree", filePath)
+	}
+
+	file := &fileInfo{}
+	f, err := c.open(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read '%s': %w", filePath, err)
+		}
+		file.missing = true
+	} else {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			file.lines = append(file.lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", filePath, err)
+		}
+	}
+
+	c.files[filePath] = file
+	return file, nil
+}
+
+// open opens a file relative to the root, refusing symlinks that lead
+// outside it
+func (c *Checker) open(filePath string) (*os.File, error) {
+	path, err := filepath.EvalSymlinks(filepath.Join(c.root, filepath.FromSlash(filePath)))
+	if err != nil {
+		return nil, err
+	}
+	if !within(c.root, path) {
+		return nil, fmt.Errorf("file path '%s' is outside the working tree", filePath)
+	}
+	return os.Open(path)
+}
+
+// resolvePath returns the absolute path with symlinks resolved
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// within reports whether path is dir or below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// containsSymbol reports whether the symbol appears as a whole identifier.
+// Qualified symbols such as Router.handleRemember match on their last segment.
+func containsSymbol(lines []string, symbol string) bool {
+	if i := strings.LastIndexAny(symbol, ".:"); i >= 0 && i < len(symbol)-1 {
+		symbol = symbol[i+1:]
+	}
+
+	pattern := regexp.MustCompile(`(^|[^\w])` + regexp.QuoteMeta(symbol) + `($|[^\w])`)
+	for _, line := range lines {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
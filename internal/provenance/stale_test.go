@@ -0,0 +1,110 @@
+package provenance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+func resultWith(prov *models.Provenance) storage.SearchResult {
+	obs := models.NewObservation("fact")
+	obs.Provenance = prov
+	return storage.SearchResult{EntityName: "code", EntityType: "component", Observation: obs}
+}
+
+func TestFindStale(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	source := "package api\n\nfunc (r *Router) handleHealth() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "api", "health.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	checker, err := NewChecker(root)
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	results := []storage.SearchResult{
+		resultWith(nil),
+		resultWith(&models.Provenance{FilePath: "api/health.go", StartLine: 3, Symbol: "Router.handleHealth"}),
+		resultWith(&models.Provenance{FilePath: "api/missing.go"}),
+		resultWith(&models.Provenance{FilePath: "api/health.go", StartLine: 40}),
+		resultWith(&models.Provenance{FilePath: "api/health.go", Symbol: "handle"}),
+		resultWith(&models.Provenance{FilePath: "../outside.go"}),
+		resultWith(&models.Provenance{FilePath: "api/health.go", StartLine: 1, EndLine: 3}),
+		resultWith(&models.Provenance{FilePath: "api/health.go", StartLine: 3, EndLine: 1}),
+		resultWith(&models.Provenance{FilePath: "api/health.go", StartLine: 1, EndLine: 40}),
+	}
+
+	stale := checker.FindStale(results)
+	if len(stale) != 6 {
+		t.Fatalf("Expected 6 stale facts, got %d: %+v", len(stale), stale)
+	}
+	for _, fact := range stale {
+		if fact.Reason == "" {
+			t.Errorf("Expected a reason for stale fact %+v", fact.Observation.Provenance)
+		}
+	}
+}
+
+func TestNewCheckerRequiresDirectory(t *testing.T) {
+	if _, err := NewChecker(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing working tree root")
+	}
+}
+
+func TestCheckerStaysInRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.go")
+	if err := os.WriteFile(outside, []byte("package secret\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link.go")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	checker, err := NewChecker(root)
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	reason := checker.Check(resultWith(&models.Provenance{FilePath: "link.go", Symbol: "secret"}))
+	if !strings.Contains(reason, "outside the working tree") {
+		t.Errorf("Expected a symlink out of the root to be refused, got %q", reason)
+	}
+}
+
+func TestResolveRoot(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, "checkout"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(workspace)
+
+	tests := []struct {
+		root string
+		want string
+	}{
+		{"", want},
+		{"checkout", filepath.Join(want, "checkout")},
+		{filepath.Join(workspace, "checkout"), filepath.Join(want, "checkout")},
+	}
+	for _, tt := range tests {
+		got, err := ResolveRoot(workspace, tt.root)
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveRoot(%q) = %q, %v; want %q", tt.root, got, err, tt.want)
+		}
+	}
+
+	for _, root := range []string{"..", t.TempDir(), "/"} {
+		if _, err := ResolveRoot(workspace, root); err == nil {
+			t.Errorf("Expected root %q outside the workspace to be rejected", root)
+		}
+	}
+}
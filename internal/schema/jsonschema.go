@@ -0,0 +1,231 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema used to describe structured entity
+// attributes: type, enum, properties, required, additionalProperties, items,
+// string length and pattern, and numeric bounds
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes are the JSON Schema type names a Schema may declare
+var schemaTypes = []string{"", "object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Compile checks the schema for unsupported types and invalid patterns
+func (s *Schema) Compile() error {
+	return s.compile("")
+}
+
+func (s *Schema) compile(path string) error {
+	if !slices.Contains(schemaTypes, s.Type) {
+		return fmt.Errorf("%s: unsupported type '%s'", displayPath(path), s.Type)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", displayPath(path), err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s: property '%s' has no schema", displayPath(path), name)
+		}
+		if err := property.compile(joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON value against the schema and returns an
+// error naming the first offending path
+func (s *Schema) Validate(value any) error {
+	return s.validate("", normalizeValue(value))
+}
+
+func (s *Schema) validate(path string, value any) error {
+	if s.Type != "" && !matchesType(s.Type, value) {
+		return fmt.Errorf("%s: expected %s, got %s", displayPath(path), s.Type, typeName(value))
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool {
+		return reflect.DeepEqual(normalizeValue(allowed), value)
+	}) {
+		return fmt.Errorf("%s: value %v is not one of %v", displayPath(path), value, s.Enum)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: must be at least %d characters", displayPath(path), *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: must be at most %d characters", displayPath(path), *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: must match pattern '%s'", displayPath(path), s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: must be at least %v", displayPath(path), *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Errorf("%s: must be at most %v", displayPath(path), *s.Maximum)
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property", displayPath(joinPath(path, name)))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, known := s.Properties[name]
+			if !known {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: property is not allowed", displayPath(joinPath(path, name)))
+				}
+				continue
+			}
+			if err := property.validate(joinPath(path, name), v[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeValue converts Go values into the shapes encoding/json decodes
+// into, so schemas can validate values built in code as well as parsed JSON
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case nil, string, bool, float64:
+		return v
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			object[key] = normalizeValue(item)
+		}
+		return object
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+func matchesType(schemaType string, value any) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "value"
+	}
+	return strings.TrimPrefix(path, ".")
+}
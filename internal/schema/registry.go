@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// TypeDefinition describes an entity type and the rules its entities follow
+type TypeDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Aliases are other spellings that resolve to this type, e.g. "guidelines"
+	Aliases []string `json:"aliases,omitempty"`
+
+	// RelationTypes lists the relation types entities of this type may take
+	// part in; empty allows any
+	RelationTypes []string `json:"relationTypes,omitempty"`
+
+	// RequiredTags must be carried by every observation of entities of this type
+	RequiredTags []string `json:"requiredTags,omitempty"`
+
+	// AttributeSchema optionally describes the entity's structured attributes
+	AttributeSchema *Schema `json:"attributeSchema,omitempty"`
+}
+
+// Registry holds the configured entity types. An empty registry accepts any
+// entity type.
+type Registry struct {
+	// AllowUnknownTypes accepts entity types that are not registered instead
+	// of rejecting them
+	AllowUnknownTypes bool             `json:"allowUnknownTypes,omitempty"`
+	Types             []TypeDefinition `json:"types"`
+
+	index map[string]int // normalized name or alias -> position in Types
+}
+
+// NewRegistry builds a registry from type definitions
+func NewRegistry(types []TypeDefinition) (*Registry, error) {
+	registry := &Registry{Types: types}
+	if err := registry.build(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// LoadRegistry reads a registry from a JSON file. A missing file yields an
+// empty registry.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Registry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read type registry: %w", err)
+	}
+
+	var registry Registry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse type registry %s: %w", path, err)
+	}
+	if err := registry.build(); err != nil {
+		return nil, fmt.Errorf("invalid type registry %s: %w", path, err)
+	}
+	return &registry, nil
+}
+
+// build validates the definitions and indexes them by name and alias
+func (r *Registry) build() error {
+	r.index = make(map[string]int, len(r.Types))
+	for i := range r.Types {
+		def := &r.Types[i]
+		def.Name = strings.TrimSpace(def.Name)
+		if def.Name == "" {
+			return fmt.Errorf("type %d has no name", i+1)
+		}
+		def.RequiredTags = models.NormalizeTags(def.RequiredTags)
+
+		for _, name := range append([]string{def.Name}, def.Aliases...) {
+			key := textutil.NormalizeName(name)
+			if existing, ok := r.index[key]; ok && existing != i {
+				return fmt.Errorf("type name '%s' is used by both '%s' and '%s'", name, r.Types[existing].Name, def.Name)
+			}
+			r.index[key] = i
+		}
+
+		if def.AttributeSchema != nil {
+			if err := def.AttributeSchema.Compile(); err != nil {
+				return fmt.Errorf("type '%s' attribute schema: %w", def.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any types are registered
+func (r *Registry) Enabled() bool {
+	return r != nil && len(r.Types) > 0
+}
+
+// Lookup returns the definition a type name or alias refers to, ignoring
+// case and separators
+func (r *Registry) Lookup(name string) (*TypeDefinition, bool) {
+	if !r.Enabled() {
+		return nil, false
+	}
+	i, ok := r.index[textutil.NormalizeName(name)]
+	if !ok {
+		return nil, false
+	}
+	return &r.Types[i], true
+}
+
+// Names returns the registered type names in definition order
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, len(r.Types))
+	for i, def := range r.Types {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// CanonicalType returns the registered spelling of a type name, or the name
+// unchanged if it is not registered
+func (r *Registry) CanonicalType(name string) string {
+	if def, ok := r.Lookup(name); ok {
+		return def.Name
+	}
+	return name
+}
+
+// ValidateEntity checks that an entity's type is registered, that its
+// observations carry the type's required tags and that its attributes match
+// the type's attribute schema. The entity type is rewritten to the
+// registered spelling.
+func (r *Registry) ValidateEntity(entity *models.Entity) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	def, ok := r.Lookup(entity.EntityType)
+	if !ok {
+		if r.AllowUnknownTypes {
+			return nil
+		}
+		return fmt.Errorf("unknown entity type '%s' (registered types: %s)",
+			entity.EntityType, strings.Join(r.Names(), ", "))
+	}
+	entity.EntityType = def.Name
+
+	for _, obs := range entity.Observations {
+		if !obs.HasAllTags(def.RequiredTags) {
+			return fmt.Errorf("observations of %s entities must be tagged %s",
+				def.Name, strings.Join(def.RequiredTags, ", "))
+		}
+	}
+	return r.ValidateAttributes(def.Name, entity.Attributes)
+}
+
+// ValidateAttributes checks structured attributes against the type's
+// attribute schema, if it has one
+func (r *Registry) ValidateAttributes(entityType string, attributes map[string]any) error {
+	def, ok := r.Lookup(entityType)
+	if !ok || def.AttributeSchema == nil {
+		return nil
+	}
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if err := def.AttributeSchema.Validate(attributes); err != nil {
+		return fmt.Errorf("attributes do not match the %s schema: %w", def.Name, err)
+	}
+	return nil
+}
+
+// ValidateRelation checks that both entity types allow the relation type
+func (r *Registry) ValidateRelation(fromType, toType, relationType string) error {
+	for _, entityType := range []string{fromType, toType} {
+		def, ok := r.Lookup(entityType)
+		if !ok || len(def.RelationTypes) == 0 {
+			continue
+		}
+		if !slices.Contains(def.RelationTypes, relationType) {
+			return fmt.Errorf("%s entities cannot have '%s' relations (allowed: %s)",
+				def.Name, relationType, strings.Join(def.RelationTypes, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+const testRegistry = `{
+  "types": [
+    {
+      "name": "guideline",
+      "description": "Coding rules the team follows",
+      "aliases": ["guidelines"],
+      "relationTypes": ["applies_to"],
+      "requiredTags": ["Rule"]
+    },
+    {
+      "name": "service",
+      "attributeSchema": {
+        "type": "object",
+        "required": ["owner"],
+        "additionalProperties": false,
+        "properties": {
+          "owner": {"type": "string", "pattern": "^team-"},
+          "language": {"enum": ["go", "typescript"]},
+          "replicas": {"type": "integer", "minimum": 1}
+        }
+      }
+    }
+  ]
+}`
+
+func loadTestRegistry(t *testing.T) *Registry {
+	path := filepath.Join(t.TempDir(), "types.json")
+	if err := os.WriteFile(path, []byte(testRegistry), 0644); err != nil {
+		t.Fatalf("Failed to write registry: %v", err)
+	}
+	registry, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("Failed to load registry: %v", err)
+	}
+	return registry
+}
+
+func TestLoadRegistry(t *testing.T) {
+	registry, err := LoadRegistry(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || registry.Enabled() {
+		t.Fatalf("Expected missing file to yield an empty registry, got %+v, %v", registry, err)
+	}
+
+	registry = loadTestRegistry(t)
+	for _, name := range []string{"guideline", "Guideline", "guidelines"} {
+		if got := registry.CanonicalType(name); got != "guideline" {
+			t.Errorf("CanonicalType(%q) = %q, want guideline", name, got)
+		}
+	}
+	if got := registry.CanonicalType("pattern"); got != "pattern" {
+		t.Errorf("Expected unregistered type to be unchanged, got %q", got)
+	}
+
+	if _, err := NewRegistry([]TypeDefinition{{Name: "a", Aliases: []string{"b"}}, {Name: "B"}}); err == nil {
+		t.Error("Expected conflicting type names to be rejected")
+	}
+	if _, err := NewRegistry([]TypeDefinition{{Name: "a", AttributeSchema: &Schema{Type: "text"}}}); err == nil {
+		t.Error("Expected unsupported schema type to be rejected")
+	}
+}
+
+func TestRegistryValidateEntity(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	entity := models.NewEntity("style", "Guidelines")
+	entity.Observations = append(entity.Observations, models.Observation{Text: "Use gofmt", Tags: []string{"rule"}})
+	if err := registry.ValidateEntity(entity); err != nil {
+		t.Fatalf("Expected valid entity, got %v", err)
+	}
+	if entity.EntityType != "guideline" {
+		t.Errorf("Expected entity type to be canonicalized, got %q", entity.EntityType)
+	}
+
+	entity.Observations = append(entity.Observations, models.Observation{Text: "Untagged"})
+	if err := registry.ValidateEntity(entity); err == nil || !strings.Contains(err.Error(), "tagged rule") {
+		t.Errorf("Expected missing required tag error, got %v", err)
+	}
+
+	if err := registry.ValidateEntity(models.NewEntity("x", "pattern")); err == nil {
+		t.Error("Expected unknown type to be rejected")
+	}
+	registry.AllowUnknownTypes = true
+	if err := registry.ValidateEntity(models.NewEntity("x", "pattern")); err != nil {
+		t.Errorf("Expected unknown type to be allowed, got %v", err)
+	}
+
+	var empty *Registry
+	if err := empty.ValidateEntity(models.NewEntity("x", "anything")); err != nil {
+		t.Errorf("Expected nil registry to accept any type, got %v", err)
+	}
+}
+
+func TestRegistryValidateRelation(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	if err := registry.ValidateRelation("guideline", "service", "applies_to"); err != nil {
+		t.Errorf("Expected allowed relation, got %v", err)
+	}
+	if err := registry.ValidateRelation("service", "guideline", "depends_on"); err == nil {
+		t.Error("Expected relation type not allowed for guideline to be rejected")
+	}
+	if err := registry.ValidateRelation("service", "pattern", "depends_on"); err != nil {
+		t.Errorf("Expected unrestricted types to allow any relation, got %v", err)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	registry := loadTestRegistry(t)
+
+	tests := []struct {
+		attributes string
+		wantErr    string
+	}{
+		{`{"owner": "team-payments", "language": "go", "replicas": 3}`, ""},
+		{`{"language": "go"}`, "owner: missing required property"},
+		{`{"owner": "payments"}`, "owner: must match pattern"},
+		{`{"owner": "team-a", "language": "rust"}`, "language: value rust is not one of"},
+		{`{"owner": "team-a", "replicas": 1.5}`, "replicas: expected integer"},
+		{`{"owner": "team-a", "replicas": 0}`, "replicas: must be at least 1"},
+		{`{"owner": "team-a", "color": "red"}`, "color: property is not allowed"},
+	}
+
+	for _, tt := range tests {
+		var attributes map[string]any
+		if err := json.Unmarshal([]byte(tt.attributes), &attributes); err != nil {
+			t.Fatalf("Invalid test attributes %s: %v", tt.attributes, err)
+		}
+
+		err := registry.ValidateAttributes("service", attributes)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateAttributes(%s) returned %v", tt.attributes, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateAttributes(%s) = %v, want error containing %q", tt.attributes, err, tt.wantErr)
+		}
+	}
+
+	// Values built in code are validated like decoded JSON
+	if err := registry.ValidateAttributes("service", map[string]any{"owner": "team-a", "replicas": 2}); err != nil {
+		t.Errorf("Expected Go integer to satisfy integer schema, got %v", err)
+	}
+}
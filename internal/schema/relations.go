@@ -0,0 +1,264 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+	"github.com/tr4d3r/ghcp-memory-context/pkg/types"
+)
+
+// RelationTypeDefinition describes a relation type, how it reads in the
+// other direction and which entity types it may connect
+type RelationTypeDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Inverse names the relation read from target to source, e.g. "used_by"
+	// for "depends_on"
+	Inverse string `json:"inverse,omitempty"`
+
+	// Symmetric relations read the same in both directions, e.g. "related_to"
+	Symmetric bool `json:"symmetric,omitempty"`
+
+	// SourceTypes and TargetTypes restrict the entity types the relation may
+	// start and end at; empty allows any
+	SourceTypes []string `json:"sourceTypes,omitempty"`
+	TargetTypes []string `json:"targetTypes,omitempty"`
+}
+
+// RelationRegistry holds the configured relation types. Relation types that
+// are not registered are accepted unless RejectUnknownTypes is set.
+type RelationRegistry struct {
+	RejectUnknownTypes bool                     `json:"rejectUnknownTypes,omitempty"`
+	Types              []RelationTypeDefinition `json:"types"`
+
+	index map[string]relationIndexEntry // normalized name or inverse -> definition
+}
+
+type relationIndexEntry struct {
+	position int
+	inverse  bool
+}
+
+// DefaultRelationTypes returns the built-in relation types, one for each
+// types.RelationshipType
+func DefaultRelationTypes() []RelationTypeDefinition {
+	return []RelationTypeDefinition{
+		{Name: string(types.RelationshipParentChild), Description: "Source contains the target", Inverse: "child_of"},
+		{Name: string(types.RelationshipDependsOn), Description: "Source needs the target to work", Inverse: "used_by"},
+		{Name: string(types.RelationshipRelatedTo), Description: "Entities are associated", Symmetric: true},
+		{Name: string(types.RelationshipBlocks), Description: "Source prevents progress on the target", Inverse: "blocked_by"},
+		{Name: string(types.RelationshipReferences), Description: "Source mentions or links to the target", Inverse: "referenced_by"},
+	}
+}
+
+// NewRelationRegistry builds a relation registry from type definitions
+func NewRelationRegistry(definitions []RelationTypeDefinition) (*RelationRegistry, error) {
+	registry := &RelationRegistry{Types: definitions}
+	if err := registry.build(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// DefaultRelationRegistry returns a registry of the built-in relation types
+func DefaultRelationRegistry() *RelationRegistry {
+	registry, err := NewRelationRegistry(DefaultRelationTypes())
+	if err != nil {
+		panic(fmt.Sprintf("invalid default relation types: %v", err))
+	}
+	return registry
+}
+
+// LoadRelationRegistry reads a relation registry from a JSON file. A missing
+// file yields the default relation types.
+func LoadRelationRegistry(path string) (*RelationRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultRelationRegistry(), nil
+		}
+		return nil, fmt.Errorf("failed to read relation type registry: %w", err)
+	}
+
+	var registry RelationRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse relation type registry %s: %w", path, err)
+	}
+	if err := registry.build(); err != nil {
+		return nil, fmt.Errorf("invalid relation type registry %s: %w", path, err)
+	}
+	return &registry, nil
+}
+
+// build validates the definitions and indexes them by name and inverse
+func (r *RelationRegistry) build() error {
+	r.index = make(map[string]relationIndexEntry, 2*len(r.Types))
+	add := func(name string, entry relationIndexEntry) error {
+		key := textutil.NormalizeName(name)
+		if existing, ok := r.index[key]; ok {
+			return fmt.Errorf("relation type name '%s' is used by both '%s' and '%s'",
+				name, r.Types[existing.position].Name, r.Types[entry.position].Name)
+		}
+		r.index[key] = entry
+		return nil
+	}
+
+	for i := range r.Types {
+		def := &r.Types[i]
+		def.Name = strings.TrimSpace(def.Name)
+		def.Inverse = strings.TrimSpace(def.Inverse)
+		if def.Name == "" {
+			return fmt.Errorf("relation type %d has no name", i+1)
+		}
+		if def.Symmetric && def.Inverse != "" {
+			return fmt.Errorf("relation type '%s' cannot be symmetric and have an inverse", def.Name)
+		}
+
+		if err := add(def.Name, relationIndexEntry{position: i}); err != nil {
+			return err
+		}
+		if def.Inverse != "" {
+			if err := add(def.Inverse, relationIndexEntry{position: i, inverse: true}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition a relation type or its inverse refers to,
+// ignoring case and separators. inverse reports whether name is the
+// definition's inverse.
+func (r *RelationRegistry) Lookup(name string) (def *RelationTypeDefinition, inverse bool, ok bool) {
+	if r == nil {
+		return nil, false, false
+	}
+	entry, ok := r.index[textutil.NormalizeName(name)]
+	if !ok {
+		return nil, false, false
+	}
+	return &r.Types[entry.position], entry.inverse, true
+}
+
+// Names returns the registered relation type names in definition order
+func (r *RelationRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, len(r.Types))
+	for i, def := range r.Types {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// InverseOf returns the name a relation type reads as in the other direction.
+// ok is false for unregistered types and types without an inverse.
+func (r *RelationRegistry) InverseOf(relationType string) (string, bool) {
+	def, inverse, ok := r.Lookup(relationType)
+	switch {
+	case !ok:
+		return "", false
+	case def.Symmetric:
+		return def.Name, true
+	case inverse:
+		return def.Name, true
+	case def.Inverse != "":
+		return def.Inverse, true
+	}
+	return "", false
+}
+
+// Canonicalize rewrites a relation stored under an inverse name into the
+// registered direction, e.g. "B used_by A" becomes "A depends_on B", and
+// applies the registered spelling. It reports whether the relation changed.
+func (r *RelationRegistry) Canonicalize(rel *models.Relation) bool {
+	def, inverse, ok := r.Lookup(rel.RelationType)
+	if !ok {
+		return false
+	}
+	changed := rel.RelationType != def.Name || inverse
+	if inverse {
+		rel.From, rel.To = rel.To, rel.From
+	}
+	rel.RelationType = def.Name
+	return changed
+}
+
+// Key returns the key that makes a relation unique. Relations of symmetric
+// types read the same in either direction, so their key ignores it and
+// "A related_to B" and "B related_to A" are one relation.
+func (r *RelationRegistry) Key(rel models.Relation) models.RelationKey {
+	key := rel.Key()
+	if def, _, ok := r.Lookup(rel.RelationType); ok && def.Symmetric {
+		return key.Undirected()
+	}
+	return key
+}
+
+// ValidateRelation checks that a relation type is known and that it may
+// connect entities of the given types. Empty entity types are not checked.
+func (r *RelationRegistry) ValidateRelation(relationType, fromType, toType string) error {
+	def, inverse, ok := r.Lookup(relationType)
+	if !ok {
+		if r != nil && r.RejectUnknownTypes {
+			return fmt.Errorf("unknown relation type '%s' (registered types: %s)",
+				relationType, strings.Join(r.Names(), ", "))
+		}
+		return nil
+	}
+	if inverse {
+		fromType, toType = toType, fromType
+	}
+
+	if !allowsType(def.SourceTypes, fromType) {
+		return fmt.Errorf("'%s' relations cannot start at %s entities (allowed: %s)",
+			def.Name, fromType, strings.Join(def.SourceTypes, ", "))
+	}
+	if !allowsType(def.TargetTypes, toType) {
+		return fmt.Errorf("'%s' relations cannot end at %s entities (allowed: %s)",
+			def.Name, toType, strings.Join(def.TargetTypes, ", "))
+	}
+	return nil
+}
+
+// Expand returns the relations together with the inferred relations that
+// read them in the other direction, e.g. "B used_by A" for every stored
+// "A depends_on B". Inferred relations already stored are not repeated.
+func (r *RelationRegistry) Expand(relations []models.Relation) []models.Relation {
+	type relationKey struct{ from, to, relationType string }
+	seen := make(map[relationKey]bool, len(relations))
+	for _, rel := range relations {
+		seen[relationKey{rel.From, rel.To, rel.RelationType}] = true
+	}
+
+	expanded := slices.Clone(relations)
+	for _, rel := range relations {
+		inverseType, ok := r.InverseOf(rel.RelationType)
+		if !ok {
+			continue
+		}
+		key := relationKey{rel.To, rel.From, inverseType}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		expanded = append(expanded, rel.Reversed(inverseType))
+	}
+	return expanded
+}
+
+func allowsType(allowed []string, entityType string) bool {
+	if len(allowed) == 0 || entityType == "" {
+		return true
+	}
+	return slices.ContainsFunc(allowed, func(name string) bool {
+		return textutil.NormalizeName(name) == textutil.NormalizeName(entityType)
+	})
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+func TestLoadRelationRegistry(t *testing.T) {
+	dir := t.TempDir()
+
+	registry, err := LoadRelationRegistry(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("Expected missing file to yield defaults, got %v", err)
+	}
+	if inverse, ok := registry.InverseOf("depends_on"); !ok || inverse != "used_by" {
+		t.Errorf("Expected default inverse 'used_by', got %q", inverse)
+	}
+
+	path := filepath.Join(dir, "relation_types.json")
+	if err := os.WriteFile(path, []byte(`{"types": [{"name": "owns", "inverse": "owned_by", "symmetric": true}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRelationRegistry(path); err == nil {
+		t.Error("Expected symmetric type with an inverse to be rejected")
+	}
+
+	if _, err := NewRelationRegistry([]RelationTypeDefinition{
+		{Name: "owns", Inverse: "owned_by"},
+		{Name: "owned_by"},
+	}); err == nil {
+		t.Error("Expected clashing inverse name to be rejected")
+	}
+}
+
+func TestRelationRegistryCanonicalize(t *testing.T) {
+	registry := DefaultRelationRegistry()
+
+	rel := models.NewRelation("auth", "api", "Used-By")
+	if !registry.Canonicalize(&rel) {
+		t.Fatal("Expected inverse relation to be rewritten")
+	}
+	if rel.From != "api" || rel.To != "auth" || rel.RelationType != "depends_on" {
+		t.Errorf("Expected 'api depends_on auth', got %+v", rel)
+	}
+
+	rel = models.NewRelation("a", "b", "related_to")
+	if registry.Canonicalize(&rel) || rel.From != "a" {
+		t.Errorf("Expected canonical relation to be unchanged, got %+v", rel)
+	}
+}
+
+func TestRelationRegistryKey(t *testing.T) {
+	registry := DefaultRelationRegistry()
+
+	if registry.Key(models.NewRelation("api", "docs", "related_to")) != registry.Key(models.NewRelation("docs", "api", "Related-To")) {
+		t.Error("Expected a symmetric relation to have the same key in both directions")
+	}
+	if registry.Key(models.NewRelation("api", "auth", "depends_on")) == registry.Key(models.NewRelation("auth", "api", "depends_on")) {
+		t.Error("Expected a directed relation to have a key per direction")
+	}
+	if registry.Key(models.NewRelation("b", "a", "uses")) != models.NewRelation("b", "a", "uses").Key() {
+		t.Error("Expected unregistered types to keep their direction")
+	}
+}
+
+func TestRelationRegistryValidate(t *testing.T) {
+	registry, err := NewRelationRegistry([]RelationTypeDefinition{
+		{Name: "implements", Inverse: "implemented_by", SourceTypes: []string{"component"}, TargetTypes: []string{"pattern"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+
+	if err := registry.ValidateRelation("implements", "component", "pattern"); err != nil {
+		t.Errorf("Expected relation to be allowed, got %v", err)
+	}
+	if err := registry.ValidateRelation("implemented_by", "pattern", "component"); err != nil {
+		t.Errorf("Expected inverse relation to be allowed, got %v", err)
+	}
+	if err := registry.ValidateRelation("implements", "pattern", "component"); err == nil {
+		t.Error("Expected disallowed source type to be rejected")
+	}
+	if err := registry.ValidateRelation("uses", "component", "pattern"); err != nil {
+		t.Errorf("Expected unknown relation type to be allowed, got %v", err)
+	}
+
+	registry.RejectUnknownTypes = true
+	if err := registry.ValidateRelation("uses", "component", "pattern"); err == nil {
+		t.Error("Expected unknown relation type to be rejected")
+	}
+}
+
+func TestRelationRegistryExpand(t *testing.T) {
+	registry := DefaultRelationRegistry()
+
+	relations := []models.Relation{
+		models.NewRelation("api", "auth", "depends_on"),
+		models.NewRelation("api", "docs", "related_to"),
+		models.NewRelation("docs", "api", "related_to"),
+		models.NewRelation("api", "db", "uses"),
+	}
+
+	expanded := registry.Expand(relations)
+	if len(expanded) != 5 {
+		t.Fatalf("Expected 5 relations, got %+v", expanded)
+	}
+	inferred := expanded[4]
+	if !inferred.Inferred || inferred.From != "auth" || inferred.To != "api" || inferred.RelationType != "used_by" {
+		t.Errorf("Expected inferred 'auth used_by api', got %+v", inferred)
+	}
+	if inferred.ID != "" || inferred.InferredFrom != relations[0].ID {
+		t.Errorf("Expected inferred relation to point at the stored relation without its ID, got %+v", inferred)
+	}
+}
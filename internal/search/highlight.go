@@ -0,0 +1,113 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// Mark surrounds the matched words of a snippet
+const Mark = "**"
+
+// DefaultSnippetLength is the most characters a snippet keeps of a text
+const DefaultSnippetLength = 160
+
+// Ellipsis marks text left out of a snippet
+const Ellipsis = "…"
+
+// span is the byte range of a matched word
+type span struct{ start, end int }
+
+// Highlight returns a snippet of text with the words match accepts wrapped
+// in Mark, and whether any matched. Words are runs of letters and digits,
+// passed to match folded and split like identifiers, without stop words.
+// Texts longer than maxLength characters are cut to a window around the
+// first match, at word boundaries where possible.
+func Highlight(text string, match func(word string) bool, maxLength int) (string, bool) {
+	var matches []span
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		for _, word := range textutil.Words(text[start:end]) {
+			if match(word) {
+				matches = append(matches, span{start, end})
+				break
+			}
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	from, to := 0, len(text)
+	if utf8.RuneCountInString(text) > maxLength {
+		if len(matches) > 0 {
+			from = backRunes(text, matches[0].start, maxLength/3)
+		}
+		to = forwardRunes(text, from, maxLength)
+		if from > 0 {
+			// Start at a word, but never after the first match
+			limit := to
+			if len(matches) > 0 {
+				limit = matches[0].start
+			}
+			if space := strings.IndexByte(text[from:limit], ' '); space >= 0 {
+				from += space + 1
+			}
+		}
+		if to < len(text) {
+			if space := strings.LastIndexByte(text[from:to], ' '); space > 0 {
+				to = from + space
+			}
+		}
+	}
+
+	var snippet strings.Builder
+	if from > 0 {
+		snippet.WriteString(Ellipsis)
+	}
+	last := from
+	for _, m := range matches {
+		if m.start < from || m.end > to {
+			continue
+		}
+		snippet.WriteString(text[last:m.start])
+		snippet.WriteString(Mark + text[m.start:m.end] + Mark)
+		last = m.end
+	}
+	snippet.WriteString(text[last:to])
+	if to < len(text) {
+		snippet.WriteString(Ellipsis)
+	}
+	return snippet.String(), len(matches) > 0
+}
+
+// backRunes moves back up to n runes from a byte offset
+func backRunes(text string, offset, n int) int {
+	for ; n > 0 && offset > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:offset])
+		offset -= size
+	}
+	return offset
+}
+
+// forwardRunes moves forward up to n runes from a byte offset
+func forwardRunes(text string, offset, n int) int {
+	for ; n > 0 && offset < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
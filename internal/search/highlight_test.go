@@ -0,0 +1,54 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHighlight(t *testing.T) {
+	isAuth := func(word string) bool { return word == "auth" }
+
+	tests := []struct {
+		text    string
+		want    string
+		matched bool
+	}{
+		{"auth_service (service)", "**auth**_service (service)", true},
+		{"AuthService", "**AuthService**", true},
+		{"gateway -[depends_on]-> auth", "gateway -[depends_on]-> **auth**", true},
+		{"The AUTH token, then auth again", "The **AUTH** token, then **auth** again", true},
+		{"authentication", "authentication", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, matched := Highlight(tt.text, isAuth, DefaultSnippetLength)
+		if got != tt.want || matched != tt.matched {
+			t.Errorf("Highlight(%q) = %q, %v, want %q, %v", tt.text, got, matched, tt.want, tt.matched)
+		}
+	}
+}
+
+func TestHighlightWindow(t *testing.T) {
+	text := strings.Repeat("filler words before ", 20) + "the auth check " + strings.Repeat("and filler words after ", 20)
+	got, matched := Highlight(text, func(word string) bool { return word == "auth" }, 60)
+	if !matched || !strings.Contains(got, "**auth**") {
+		t.Fatalf("Expected the window to keep the match, got %q", got)
+	}
+	if !strings.HasPrefix(got, Ellipsis+"filler") && !strings.HasPrefix(got, Ellipsis+"words") && !strings.HasPrefix(got, Ellipsis+"before") {
+		t.Errorf("Expected the snippet to start at a word after an ellipsis, got %q", got)
+	}
+	if !strings.HasSuffix(got, Ellipsis) || strings.HasSuffix(got, " "+Ellipsis) {
+		t.Errorf("Expected the snippet to end at a word before an ellipsis, got %q", got)
+	}
+	if length := utf8.RuneCountInString(strings.ReplaceAll(got, Mark, "")); length > 60+2 {
+		t.Errorf("Expected at most 60 characters and the ellipses, got %d in %q", length, got)
+	}
+
+	// Without a match the snippet is the start of the text
+	got, matched = Highlight(text, func(string) bool { return false }, 60)
+	if matched || !strings.HasPrefix(got, "filler words") || !strings.HasSuffix(got, Ellipsis) {
+		t.Errorf("Expected the start of the text, got %q", got)
+	}
+}
@@ -0,0 +1,559 @@
+// Package search parses the query language of memory searches into an
+// expression tree the storage layer evaluates against observations.
+package search
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// Expr is a node of a parsed search query
+type Expr interface {
+	String() string
+}
+
+// Term matches observations containing a word, or, for a phrase, the words
+// in order
+type Term struct {
+	Text   string
+	Phrase bool
+}
+
+// Field names
+const (
+	FieldType    = "type"
+	FieldEntity  = "entity"
+	FieldSource  = "source"
+	FieldTag     = "tag"
+	FieldCreated = "created"
+)
+
+// Fields lists the fields a query can restrict
+var Fields = []string{FieldType, FieldEntity, FieldSource, FieldTag, FieldCreated}
+
+// Field restricts an observation or its entity. Type, entity, source and tag
+// compare text without regard to case or accents, and their values may
+// contain * wildcards. Created compares the creation time with Op.
+type Field struct {
+	Name  string
+	Value string
+
+	// Op is the comparison for created: =, <, <=, > or >=
+	Op string
+
+	// From and To bound the time a created value names: the whole day for a
+	// date, or a single instant for a timestamp
+	From, To time.Time
+}
+
+// And matches observations both sides match
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches observations either side matches
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches observations the expression does not match
+type Not struct {
+	Expr Expr
+}
+
+func (t *Term) String() string {
+	if t.Phrase {
+		return fmt.Sprintf("%q", t.Text)
+	}
+	return t.Text
+}
+
+func (f *Field) String() string {
+	if f.Name == FieldCreated {
+		return f.Name + ":" + f.Op + f.Value
+	}
+	return f.Name + ":" + f.Value
+}
+
+func (e *And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+
+func (e *Or) String() string { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+
+func (e *Not) String() string { return "NOT " + e.Expr.String() }
+
+// SyntaxError reports a search query that cannot be parsed
+type SyntaxError struct {
+	Position int // byte offset in the query, from 0
+	Message  string
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("search syntax error at position %d: %s", e.Position+1, e.Message)
+}
+
+// Parse parses a search query. Words must all match unless joined by OR;
+// "quoted phrases" match words in order; NOT or a leading - excludes
+// matches and parentheses group. Fields restrict matches: type:guideline,
+// entity:auth*, source:copilot, tag:security and created:>2026-01-01 (also
+// >=, <, <= and =, with a date or an RFC 3339 time). AND, OR and NOT are
+// only operators in upper case.
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf("empty query")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		if t.kind == tokenClose {
+			return nil, p.errorf("unmatched ')'")
+		}
+		return nil, p.errorf("unexpected %s", t.describe())
+	}
+	return expr, nil
+}
+
+// Split separates the words and phrases a query looks for from the
+// conditions it imposes, for searches matching words by meaning rather than
+// exactly. text joins the words and phrases outside NOT; conditions is the
+// query without them, nil if nothing remains. Alternatives containing words
+// are dropped from the conditions whole, since any text may match them.
+func Split(expr Expr) (text string, conditions Expr) {
+	var words []string
+	conditions = split(expr, &words)
+	return strings.Join(words, " "), conditions
+}
+
+func split(expr Expr, words *[]string) Expr {
+	switch e := expr.(type) {
+	case *Term:
+		*words = append(*words, e.Text)
+		return nil
+	case *And:
+		left, right := split(e.Left, words), split(e.Right, words)
+		if left == nil {
+			return right
+		}
+		if right == nil {
+			return left
+		}
+		return &And{Left: left, Right: right}
+	case *Or:
+		left, right := split(e.Left, words), split(e.Right, words)
+		if left == nil || right == nil {
+			return nil
+		}
+		return &Or{Left: left, Right: right}
+	}
+	return expr
+}
+
+// Rewrite returns a query with the text of its words, phrases and field
+// values replaced by fix, keeping operators, grouping and spacing as they
+// were. fix receives the field name, or "" for a word or phrase, and the
+// text; returning the text unchanged keeps it. Replacements are quoted when
+// they would not parse as they are.
+func Rewrite(input string, fix func(field, text string) string) (string, error) {
+	if _, err := Parse(input); err != nil {
+		return "", err
+	}
+	tokens, _ := lex(input)
+
+	var rewritten strings.Builder
+	last := 0
+	for _, t := range tokens {
+		var replacement string
+		switch t.kind {
+		case tokenWord:
+			if t.text == "AND" || t.text == "OR" || t.text == "NOT" {
+				continue
+			}
+			text := fix("", t.text)
+			if text == t.text {
+				continue
+			}
+			replacement = quoteIfNeeded(text)
+		case tokenPhrase:
+			text := fix("", t.text)
+			if text == t.text {
+				continue
+			}
+			replacement = quote(text)
+		case tokenField:
+			text := fix(t.field, t.text)
+			if text == t.text {
+				continue
+			}
+			replacement = input[t.pos:t.pos+len(t.field)+1] + quoteIfNeeded(text)
+		default:
+			continue
+		}
+		rewritten.WriteString(input[last:t.pos])
+		rewritten.WriteString(replacement)
+		last = t.end
+	}
+	rewritten.WriteString(input[last:])
+	return rewritten.String(), nil
+}
+
+// quoteIfNeeded quotes text that would not lex as a single plain word
+func quoteIfNeeded(text string) string {
+	if text == "" || strings.ContainsRune(text, ':') || strings.HasPrefix(text, "-") ||
+		strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return quote(text)
+	}
+	return text
+}
+
+func quote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenPhrase
+	tokenField
+	tokenOpen
+	tokenClose
+	tokenMinus
+)
+
+type token struct {
+	kind     tokenKind
+	text     string // word, phrase or field value
+	field    string // field name
+	pos, end int    // byte offsets of the token in the query
+}
+
+// describe renders a token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenPhrase:
+		return fmt.Sprintf("%q", t.text)
+	case tokenField:
+		return "'" + t.field + ":" + t.text + "'"
+	case tokenOpen:
+		return "'('"
+	case tokenClose:
+		return "')'"
+	case tokenMinus:
+		return "'-'"
+	}
+	return "'" + t.text + "'"
+}
+
+// isWordRune reports whether a rune can be part of an unquoted word
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && r != '(' && r != ')' && r != '"'
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, pos: i, end: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, pos: i, end: i + 1})
+			i++
+		case r == '"':
+			text, end, err := lexPhrase(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, text: text, pos: i, end: end})
+			i = end
+		case r == '-' && i+1 < len(input) && (input[i+1] == '(' || input[i+1] == '"' || isWordRune(rune(input[i+1]))):
+			tokens = append(tokens, token{kind: tokenMinus, pos: i, end: i + 1})
+			i++
+		default:
+			end := i
+			for end < len(input) {
+				r, size := utf8.DecodeRuneInString(input[end:])
+				if !isWordRune(r) {
+					break
+				}
+				end += size
+			}
+			word := input[i:end]
+			t := token{kind: tokenWord, text: word, pos: i}
+
+			// name:value, where the value may be quoted
+			if colon := strings.IndexByte(word, ':'); colon > 0 {
+				t.kind, t.field, t.text = tokenField, strings.ToLower(word[:colon]), word[colon+1:]
+				if t.text == "" && end < len(input) && input[end] == '"' {
+					text, phraseEnd, err := lexPhrase(input, end)
+					if err != nil {
+						return nil, err
+					}
+					t.text, end = text, phraseEnd
+				}
+			}
+			t.end = end
+			tokens = append(tokens, t)
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input), end: len(input)}), nil
+}
+
+// lexPhrase reads a double-quoted phrase starting at input[start], returning
+// its unescaped text and the offset after the closing quote
+func lexPhrase(input string, start int) (string, int, error) {
+	var text strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '"':
+			return text.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(input) {
+				i++
+			}
+		}
+		text.WriteByte(input[i])
+	}
+	return "", 0, &SyntaxError{Position: start, Message: "unterminated quote"}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// acceptKeyword consumes the next token if it is the given operator
+func (p *parser) acceptKeyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenWord && t.text == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf reports an error at the next token
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Position: p.peek().pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// startsOperand reports whether the next token can begin an operand, which
+// joins it to the previous one with an implicit AND
+func (p *parser) startsOperand() bool {
+	switch t := p.peek(); t.kind {
+	case tokenEOF, tokenClose:
+		return false
+	case tokenWord:
+		return t.text != "OR" && t.text != "AND"
+	}
+	return true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if !p.acceptKeyword("AND") && !p.startsOperand() {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if t := p.peek(); t.kind == tokenMinus || t.kind == tokenWord && t.text == "NOT" {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenOpen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			return nil, p.errorf("expected ')', found %s", p.peek().describe())
+		}
+		p.next()
+		return expr, nil
+	case tokenPhrase:
+		p.next()
+		if len(textutil.Tokenize(t.text)) == 0 {
+			return nil, &SyntaxError{Position: t.pos, Message: "empty phrase"}
+		}
+		return &Term{Text: t.text, Phrase: true}, nil
+	case tokenField:
+		p.next()
+		return parseField(t)
+	case tokenWord:
+		if t.text == "AND" || t.text == "OR" {
+			return nil, p.errorf("expected a search term before %s", t.text)
+		}
+		p.next()
+		return &Term{Text: t.text}, nil
+	case tokenEOF:
+		return nil, p.errorf("expected a search term, found end of query")
+	}
+	return nil, p.errorf("unexpected %s", t.describe())
+}
+
+// timeOps are the comparisons a created value may start with, longest first
+var timeOps = []string{">=", "<=", ">", "<", "="}
+
+// parseField checks a field name and value
+func parseField(t token) (Expr, error) {
+	if !slices.Contains(Fields, t.field) {
+		return nil, &SyntaxError{
+			Position: t.pos,
+			Message: fmt.Sprintf("unknown field '%s' (use %s, or quote the text to search for it)",
+				t.field, strings.Join(Fields, ", ")),
+		}
+	}
+	valuePos := t.pos + len(t.field) + 1
+	if strings.TrimSpace(t.text) == "" {
+		return nil, &SyntaxError{Position: valuePos, Message: fmt.Sprintf("missing value for %s:", t.field)}
+	}
+	if t.field != FieldCreated {
+		return &Field{Name: t.field, Value: t.text}, nil
+	}
+
+	field := &Field{Name: t.field, Op: "="}
+	value := t.text
+	for _, op := range timeOps {
+		if strings.HasPrefix(value, op) {
+			field.Op, value = op, value[len(op):]
+			break
+		}
+	}
+	field.Value = value
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		field.From, field.To = day, day.AddDate(0, 0, 1)
+	} else if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		field.From, field.To = instant, instant.Add(time.Nanosecond)
+	} else {
+		return nil, &SyntaxError{
+			Position: valuePos,
+			Message:  fmt.Sprintf("invalid date '%s' for created: (use 2026-01-01 or 2026-01-01T15:04:05Z, after >, >=, <, <= or =)", value),
+		}
+	}
+	return field, nil
+}
+
+// MatchesTime reports whether a time satisfies a created field
+func (f *Field) MatchesTime(t time.Time) bool {
+	switch f.Op {
+	case ">":
+		return !t.Before(f.To)
+	case ">=":
+		return !t.Before(f.From)
+	case "<":
+		return t.Before(f.From)
+	case "<=":
+		return t.Before(f.To)
+	}
+	return !t.Before(f.From) && t.Before(f.To)
+}
+
+// MatchesText reports whether a value satisfies a text field, ignoring case
+// and accents and expanding * wildcards
+func (f *Field) MatchesText(value string) bool {
+	return wildcardMatch(textutil.Fold(f.Value), textutil.Fold(value))
+}
+
+// wildcardMatch matches text against a pattern in which * stands for any
+// run of characters
+func wildcardMatch(pattern, text string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == text
+	}
+	if !strings.HasPrefix(text, parts[0]) {
+		return false
+	}
+	text = text[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(text, part)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(part):]
+	}
+	return strings.HasSuffix(text, last)
+}
+
+// ContainsPhrase reports whether text contains the words of a phrase in
+// order, ignoring case, accents and punctuation
+func ContainsPhrase(text, phrase string) bool {
+	words := textutil.Tokenize(textutil.Fold(text))
+	want := textutil.Tokenize(textutil.Fold(phrase))
+	for i := 0; i+len(want) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
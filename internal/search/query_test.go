@@ -0,0 +1,137 @@
+package search
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		"auth tokens":                         "(auth AND tokens)",
+		"auth AND tokens OR jwt":              "((auth AND tokens) OR jwt)",
+		"auth (tokens OR jwt)":                "(auth AND (tokens OR jwt))",
+		`"rate limit" -redis`:                 `("rate limit" AND NOT redis)`,
+		"NOT type:guideline":                  "NOT type:guideline",
+		`entity:auth* tag:"on call"`:          "(entity:auth* AND tag:on call)",
+		"Type:Guideline created:>=2026-01-01": "(type:Guideline AND created:>=2026-01-01)",
+		"or and":                              "(or AND and)",
+		"well-known":                          "well-known",
+	}
+
+	for input, want := range tests {
+		expr, err := Parse(input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", input, err)
+			continue
+		}
+		if got := expr.String(); got != want {
+			t.Errorf("Parse(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+	}{
+		{"", 0},
+		{"auth)", 4},
+		{"(auth OR", 8},
+		{"(auth jwt", 9},
+		{"OR auth", 0},
+		{`auth "rate limit`, 5},
+		{`""`, 0},
+		{"auth color:red", 5},
+		{"tag:", 4},
+		{"created:yesterday", 8},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) = %v, want a syntax error", tt.input, err)
+			continue
+		}
+		if syntaxErr.Position != tt.position {
+			t.Errorf("Parse(%q) error at %d, want %d: %v", tt.input, syntaxErr.Position, tt.position, err)
+		}
+	}
+}
+
+func TestFieldMatches(t *testing.T) {
+	expr, err := Parse("created:2026-03-01")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	day := expr.(*Field)
+	if !day.MatchesTime(time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)) || day.MatchesTime(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Expected a date to match the whole day")
+	}
+
+	expr, _ = Parse("created:>2026-03-01")
+	if after := expr.(*Field); after.MatchesTime(time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)) {
+		t.Error("Expected > to exclude the day itself")
+	}
+
+	entity := &Field{Name: FieldEntity, Value: "auth*svc"}
+	if !entity.MatchesText("AuthService-svc") || entity.MatchesText("payments-svc") {
+		t.Error("Expected wildcards to match any run of characters")
+	}
+
+	if !ContainsPhrase("Limits: the rate-limit is 100/s", "rate limit") || ContainsPhrase("rate and limit", "rate limit") {
+		t.Error("Expected phrases to match consecutive words")
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	fixes := map[string]string{"postgress": "postgres", "rate limt": "rate limit", "auth svc": "auth-service", "x": "a b"}
+	fix := func(field, text string) string {
+		if fixed, ok := fixes[text]; ok {
+			return fixed
+		}
+		return text
+	}
+
+	tests := map[string]string{
+		`postgress AND ("rate limt" OR -redis)`: `postgres AND ("rate limit" OR -redis)`,
+		`entity:"auth svc" created:>2026-01-01`: `entity:auth-service created:>2026-01-01`,
+		"tag:x  x":                              `tag:"a b"  "a b"`,
+	}
+	for input, want := range tests {
+		got, err := Rewrite(input, fix)
+		if err != nil {
+			t.Errorf("Rewrite(%q) failed: %v", input, err)
+		} else if got != want {
+			t.Errorf("Rewrite(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input, text, conditions string
+	}{
+		{"db pool", "db pool", "<nil>"},
+		{`"connection pool" type:guideline -redis`, "connection pool", "(type:guideline AND NOT redis)"},
+		{"(pool OR tag:db) created:>2026-01-01", "pool", "created:>2026-01-01"},
+		{"tag:db OR tag:cache", "", "(tag:db OR tag:cache)"},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.input, err)
+		}
+		text, conditions := Split(expr)
+		got := "<nil>"
+		if conditions != nil {
+			got = conditions.String()
+		}
+		if text != tt.text || got != tt.conditions {
+			t.Errorf("Split(%q) = %q, %s; want %q, %s", tt.input, text, got, tt.text, tt.conditions)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"slices"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// DefaultDuplicateThreshold is the name similarity above which two entities
+// are proposed as likely duplicates
+const DefaultDuplicateThreshold = 0.8
+
+// DuplicateCandidate is a pair of entities whose names suggest they describe
+// the same thing
+type DuplicateCandidate struct {
+	Entity        string  `json:"entity"`
+	EntityType    string  `json:"entityType"`
+	Duplicate     string  `json:"duplicate"`
+	DuplicateType string  `json:"duplicateType"`
+	Score         float64 `json:"score"`
+	MatchedName   string  `json:"matchedName"`
+	MatchedWith   string  `json:"matchedWith"`
+}
+
+// ProposeDuplicates compares every pair of entities by name and alias and
+// returns the pairs scoring at least threshold, most similar first
+func ProposeDuplicates(entities []*models.Entity, threshold float64) []DuplicateCandidate {
+	var candidates []DuplicateCandidate
+	for i, a := range entities {
+		for _, b := range entities[i+1:] {
+			best := DuplicateCandidate{Entity: a.Name, EntityType: a.EntityType, Duplicate: b.Name, DuplicateType: b.EntityType}
+			for _, nameA := range a.Names() {
+				for _, nameB := range b.Names() {
+					if score := textutil.NameSimilarity(nameA, nameB); score > best.Score {
+						best.Score, best.MatchedName, best.MatchedWith = score, nameA, nameB
+					}
+				}
+			}
+			if best.Score >= threshold {
+				candidates = append(candidates, best)
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(x, y DuplicateCandidate) int {
+		switch {
+		case x.Score > y.Score:
+			return -1
+		case x.Score < y.Score:
+			return 1
+		}
+		return 0
+	})
+	return candidates
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+func TestProposeDuplicates(t *testing.T) {
+	entities := []*models.Entity{
+		models.NewEntity("auth_service", "component"),
+		models.NewEntity("authentication-service", "component"),
+		models.NewEntity("billing", "component"),
+	}
+
+	candidates := ProposeDuplicates(entities, DefaultDuplicateThreshold)
+	if len(candidates) != 1 {
+		t.Fatalf("Expected 1 duplicate candidate, got %+v", candidates)
+	}
+	if candidates[0].Entity != "auth_service" || candidates[0].Duplicate != "authentication-service" {
+		t.Errorf("Unexpected candidate %+v", candidates[0])
+	}
+}
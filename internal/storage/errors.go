@@ -24,6 +24,13 @@ var (
 
 	// ErrUnsupportedOperation is returned when an operation is not supported
 	ErrUnsupportedOperation = errors.New("unsupported operation")
+
+	// ErrReferenced is returned when deleting an entity that relations still reference
+	ErrReferenced = errors.New("entity is referenced by relations")
+
+	// ErrDuplicateRelation is returned when saving a relation with the same
+	// endpoints and type as another
+	ErrDuplicateRelation = errors.New("relation already exists")
 )
 
 // StorageError wraps storage-specific errors with additional context
@@ -76,3 +83,13 @@ func IsAlreadyExists(err error) bool {
 func IsInvalidInput(err error) bool {
 	return errors.Is(err, ErrInvalidInput)
 }
+
+// IsReferenced checks if an error is a referenced entity error
+func IsReferenced(err error) bool {
+	return errors.Is(err, ErrReferenced)
+}
+
+// IsDuplicateRelation checks if an error is a duplicate relation error
+func IsDuplicateRelation(err error) bool {
+	return errors.Is(err, ErrDuplicateRelation)
+}
@@ -9,25 +9,60 @@ import (
 	"sync"
 	"time"
 
+	"github.com/tr4d3r/ghcp-memory-context/internal/embed"
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
 	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
 	"github.com/tr4d3r/ghcp-memory-context/pkg/types"
 )
 
 // FileStore implements file-based storage for entities and relations
 type FileStore struct {
-	baseDir       string
-	entitiesDir   string
-	relationsFile string
+	baseDir           string
+	entitiesDir       string
+	relationsFile     string
+	relationsLogFile  string
+	trashDir          string
+	trashRetention    time.Duration
+	typesFile         string
+	relationTypesFile string
+
+	// Registered entity types; empty accepts any type
+	types *schema.Registry
+
+	// Registered relation types; defaults to the built-in types
+	relationTypes *schema.RelationRegistry
 
 	// In-memory cache for performance
-	entityCache   map[string]*models.Entity
-	relationCache *models.RelationSet
-	cacheMutex    sync.RWMutex
+	entityCache map[string]*models.Entity
+	nameIndex   map[string]string // normalized name or alias -> entity name; nil when stale
+	cacheMutex  sync.RWMutex
+
+	// Indexed relations; nil until loaded from the snapshot and log
+	relations      *relationIndex
+	relationLogOps int // changes in the log since the last snapshot
+	relationMutex  sync.RWMutex
+
+	// Inverted index of observation text; nil until the first search
+	search      *searchIndex
+	searchMutex sync.Mutex
+	fuzziness   int // most edits a search word may be from a stored word
+
+	// Observation embeddings for semantic search; nil until the first one
+	embedder      embed.Embedder
+	minSimilarity float64
+	vectors       *vectorIndex
+	vectorsFile   string
+	vectorMutex   sync.Mutex
 
 	// File locking for concurrent access
 	fileLocks map[string]*sync.RWMutex
 	lockMutex sync.Mutex
+
+	// Serializes operations that touch several entities and the relations file
+	structureMutex sync.Mutex
 }
 
 // NewFileStore creates a new file-based storage instance
@@ -36,12 +71,22 @@ func NewFileStore(baseDir string) *FileStore {
 	relationsFile := filepath.Join(baseDir, "relations", "relations.json")
 
 	return &FileStore{
-		baseDir:       baseDir,
-		entitiesDir:   entitiesDir,
-		relationsFile: relationsFile,
-		entityCache:   make(map[string]*models.Entity),
-		relationCache: &models.RelationSet{Relations: make([]models.Relation, 0)},
-		fileLocks:     make(map[string]*sync.RWMutex),
+		baseDir:           baseDir,
+		entitiesDir:       entitiesDir,
+		relationsFile:     relationsFile,
+		relationsLogFile:  filepath.Join(filepath.Dir(relationsFile), "relations.log"),
+		trashDir:          filepath.Join(baseDir, "trash"),
+		trashRetention:    DefaultTrashRetention,
+		fuzziness:         textutil.DefaultFuzziness,
+		embedder:          embed.NewHashEmbedder(0),
+		minSimilarity:     DefaultMinSimilarity,
+		vectorsFile:       filepath.Join(baseDir, "vectors.json"),
+		typesFile:         filepath.Join(baseDir, "types.json"),
+		relationTypesFile: filepath.Join(baseDir, "relation_types.json"),
+		types:             &schema.Registry{},
+		relationTypes:     schema.DefaultRelationRegistry(),
+		entityCache:       make(map[string]*models.Entity),
+		fileLocks:         make(map[string]*sync.RWMutex),
 	}
 }
 
@@ -55,6 +100,11 @@ func (fs *FileStore) Initialize() error {
 		return fmt.Errorf("failed to create entities directory: %w", err)
 	}
 
+	// Create trash directory
+	if err := os.MkdirAll(fs.trashDir, 0750); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
 	// Create relations directory
 	relationsDir := filepath.Dir(fs.relationsFile)
 	if err := os.MkdirAll(relationsDir, 0750); err != nil {
@@ -69,6 +119,23 @@ func (fs *FileStore) Initialize() error {
 		}
 	}
 
+	// Load the entity type registry, if one is configured
+	types, err := schema.LoadRegistry(fs.typesFile)
+	if err != nil {
+		return err
+	}
+	fs.types = types
+	if types.Enabled() {
+		fmt.Fprintf(os.Stderr, "[FileStore] Loaded %d entity types from %s\n", len(types.Types), fs.typesFile)
+	}
+
+	// Load the relation type registry, falling back to the built-in types
+	relationTypes, err := schema.LoadRelationRegistry(fs.relationTypesFile)
+	if err != nil {
+		return err
+	}
+	fs.relationTypes = relationTypes
+
 	fmt.Fprintf(os.Stderr, "[FileStore] Initialization complete\n")
 	return nil
 }
@@ -78,12 +145,18 @@ func (fs *FileStore) Initialize() error {
 // CreateEntity creates a new entity and saves it to file
 func (fs *FileStore) CreateEntity(ctx context.Context, entity *models.Entity) error {
 	if err := entity.Validate(); err != nil {
-		return fmt.Errorf("entity validation failed: %w", err)
+		return storage.NewStorageError("create", "entity", entity.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if err := fs.types.ValidateEntity(entity); err != nil {
+		return storage.NewStorageError("create", "entity", entity.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
 	}
 
-	// Check if entity already exists
+	// Check if entity already exists, under this or an equivalent name
 	if fs.EntityExists(entity.Name) {
-		return fmt.Errorf("entity '%s' already exists", entity.Name)
+		return storage.NewStorageError("create", "entity", entity.Name, storage.ErrAlreadyExists)
+	}
+	if err := fs.checkAliasConflicts(ctx, entity); err != nil {
+		return err
 	}
 
 	// Save to file
@@ -94,13 +167,29 @@ func (fs *FileStore) CreateEntity(ctx context.Context, entity *models.Entity) er
 	// Update cache
 	fs.cacheMutex.Lock()
 	fs.entityCache[entity.Name] = entity
+	fs.nameIndex = nil
 	fs.cacheMutex.Unlock()
+	fs.indexEntity(entity)
 
 	return nil
 }
 
-// GetEntity retrieves an entity by name
+// GetEntity retrieves an entity by name or alias
 func (fs *FileStore) GetEntity(ctx context.Context, name string) (*models.Entity, error) {
+	entity, err := fs.getEntityExact(name)
+	if err == nil || fs.entityFileExists(name) {
+		return entity, err
+	}
+
+	canonical, resolveErr := fs.ResolveEntityName(ctx, name)
+	if resolveErr != nil || canonical == name {
+		return nil, err
+	}
+	return fs.getEntityExact(canonical)
+}
+
+// getEntityExact retrieves an entity by its exact stored name
+func (fs *FileStore) getEntityExact(name string) (*models.Entity, error) {
 	// Check cache first
 	fs.cacheMutex.RLock()
 	if cached, exists := fs.entityCache[name]; exists {
@@ -125,14 +214,29 @@ func (fs *FileStore) GetEntity(ctx context.Context, name string) (*models.Entity
 
 // UpdateEntity updates an existing entity
 func (fs *FileStore) UpdateEntity(ctx context.Context, entity *models.Entity) error {
+	// Serialized with the expiry sweeper so a purge never overwrites a
+	// concurrent update
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+	return fs.updateEntity(ctx, entity)
+}
+
+// updateEntity updates an existing entity; callers hold structureMutex
+func (fs *FileStore) updateEntity(ctx context.Context, entity *models.Entity) error {
 	if err := entity.Validate(); err != nil {
-		return fmt.Errorf("entity validation failed: %w", err)
+		return storage.NewStorageError("update", "entity", entity.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if err := fs.types.ValidateEntity(entity); err != nil {
+		return storage.NewStorageError("update", "entity", entity.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
 	}
 
-	// Check if entity exists
-	if !fs.EntityExists(entity.Name) {
+	// Check if entity exists under its exact name
+	if !fs.entityFileExists(entity.Name) {
 		return fmt.Errorf("entity '%s' does not exist", entity.Name)
 	}
+	if err := fs.checkAliasConflicts(ctx, entity); err != nil {
+		return err
+	}
 
 	// Save to file
 	if err := fs.saveEntityFile(entity); err != nil {
@@ -142,25 +246,17 @@ func (fs *FileStore) UpdateEntity(ctx context.Context, entity *models.Entity) er
 	// Update cache
 	fs.cacheMutex.Lock()
 	fs.entityCache[entity.Name] = entity
+	fs.nameIndex = nil
 	fs.cacheMutex.Unlock()
+	fs.indexEntity(entity)
 
 	return nil
 }
 
-// DeleteEntity removes an entity
+// DeleteEntity moves an entity to the trash under the default reject
+// policy, refusing while relations still reference it
 func (fs *FileStore) DeleteEntity(ctx context.Context, name string) error {
-	// Remove file
-	filePath := fs.getEntityFilePath(name)
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete entity file: %w", err)
-	}
-
-	// Remove from cache
-	fs.cacheMutex.Lock()
-	delete(fs.entityCache, name)
-	fs.cacheMutex.Unlock()
-
-	return nil
+	return fs.DeleteEntityWithPolicy(ctx, name, storage.DeleteReject)
 }
 
 // ListEntities returns all entities, optionally filtered by type
@@ -169,12 +265,15 @@ func (fs *FileStore) ListEntities(ctx context.Context, entityType string) ([]*mo
 	if err != nil {
 		return nil, fmt.Errorf("failed to read entities directory: %w", err)
 	}
+	if entityType != "" {
+		entityType = fs.types.CanonicalType(entityType)
+	}
 
 	var entities []*models.Entity
 	for _, file := range files {
 		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
 			name := strings.TrimSuffix(file.Name(), ".json")
-			entity, err := fs.GetEntity(ctx, name)
+			entity, err := fs.getEntityExact(name)
 			if err != nil {
 				continue // Skip invalid entities
 			}
@@ -189,73 +288,91 @@ func (fs *FileStore) ListEntities(ctx context.Context, entityType string) ([]*mo
 	return entities, nil
 }
 
-// EntityExists checks if an entity exists
-func (fs *FileStore) EntityExists(name string) bool {
-	filePath := fs.getEntityFilePath(name)
-	_, err := os.Stat(filePath)
-	return !os.IsNotExist(err)
-}
+// ListEntitiesFiltered returns the entities matching the filter
+func (fs *FileStore) ListEntitiesFiltered(ctx context.Context, filter storage.EntityFilter) ([]*models.Entity, error) {
+	entityType := ""
+	if filter.EntityType != nil {
+		entityType = fs.types.CanonicalType(*filter.EntityType)
+		filter.EntityType = &entityType
+	}
 
-// SearchObservations searches for observations across all entities
-func (fs *FileStore) SearchObservations(ctx context.Context, query string, entityType string) ([]storage.SearchResult, error) {
 	entities, err := fs.ListEntities(ctx, entityType)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []storage.SearchResult
+	matching := make([]*models.Entity, 0, len(entities))
 	for _, entity := range entities {
-		observations := entity.SearchObservations(query)
-		for _, obs := range observations {
-			results = append(results, storage.SearchResult{
-				EntityName:  entity.Name,
-				EntityType:  entity.EntityType,
-				Observation: obs,
-			})
+		if filter.Matches(entity) {
+			matching = append(matching, entity)
 		}
 	}
+	return filter.Apply(matching), nil
+}
 
-	return results, nil
+// EntityExists checks if an entity exists under the given name or alias
+func (fs *FileStore) EntityExists(name string) bool {
+	if fs.entityFileExists(name) {
+		return true
+	}
+	_, err := fs.ResolveEntityName(context.Background(), name)
+	return err == nil
 }
 
-// Relation Operations
+// SearchObservations searches for observations across all entities
+func (fs *FileStore) SearchObservations(ctx context.Context, query string, entityType string) ([]storage.SearchResult, error) {
+	return fs.SearchObservationsFiltered(ctx, query, storage.ObservationFilter{EntityType: entityType})
+}
 
-// GetRelations returns all relations
-func (fs *FileStore) GetRelations(ctx context.Context) (*models.RelationSet, error) {
-	// Check cache first
-	fs.cacheMutex.RLock()
-	if fs.relationCache != nil && len(fs.relationCache.Relations) > 0 {
-		fs.cacheMutex.RUnlock()
-		return fs.relationCache, nil
+// SearchObservationsFiltered searches for observations matching the query and
+// filter. The query is parsed with search.Parse. In the default lexical mode
+// words match stemmed and folded, and results are ranked by BM25 score; the
+// semantic and hybrid modes match by meaning too (see searchSemantic). An
+// empty query matches every observation. Syntax errors are
+// *search.SyntaxError wrapping storage.ErrInvalidInput.
+func (fs *FileStore) SearchObservationsFiltered(ctx context.Context, query string, filter storage.ObservationFilter) ([]storage.SearchResult, error) {
+	mode, err := storage.ParseSearchMode(filter.Mode)
+	if err != nil {
+		return nil, storage.NewStorageError("search", "observation", "", fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if strings.TrimSpace(query) == "" {
+		return fs.searchQuery(ctx, nil, nil, filter)
+	}
+	expr, err := search.Parse(query)
+	if err != nil {
+		return nil, storage.NewStorageError("search", "observation", "", fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
 	}
-	fs.cacheMutex.RUnlock()
 
-	// Load from file
-	relations, err := fs.loadRelationsFile()
+	switch mode {
+	case storage.SearchSemantic:
+		return fs.searchSemantic(ctx, expr, filter)
+	case storage.SearchHybrid:
+		return fs.searchHybrid(ctx, expr, filter)
+	}
+	return fs.searchQuery(ctx, expr, nil, filter)
+}
+
+// Relation Operations
+
+// GetRelations returns all relations in the order they were created. The
+// set is a copy; changes take effect through SaveRelations.
+func (fs *FileStore) GetRelations(ctx context.Context) (*models.RelationSet, error) {
+	ix, err := fs.readRelations()
 	if err != nil {
 		return nil, err
 	}
+	defer fs.relationMutex.RUnlock()
 
-	// Update cache
-	fs.cacheMutex.Lock()
-	fs.relationCache = relations
-	fs.cacheMutex.Unlock()
-
-	return relations, nil
+	return &models.RelationSet{Relations: ix.all()}, nil
 }
 
-// SaveRelations saves the relation set
+// SaveRelations replaces the stored relations with the set, persisting only
+// the relations that were added, changed or removed
 func (fs *FileStore) SaveRelations(ctx context.Context, relations *models.RelationSet) error {
-	if err := fs.saveRelationsFile(relations); err != nil {
+	if err := fs.checkRelations(relations); err != nil {
 		return err
 	}
-
-	// Update cache
-	fs.cacheMutex.Lock()
-	fs.relationCache = relations
-	fs.cacheMutex.Unlock()
-
-	return nil
+	return fs.replaceRelations(relations)
 }
 
 // File Operations
@@ -332,7 +449,12 @@ func (fs *FileStore) saveRelationsFile(relations *models.RelationSet) error {
 		return fmt.Errorf("failed to marshal relations: %w", err)
 	}
 
-	return os.WriteFile(fs.relationsFile, data, 0644)
+	// Write to a temporary file first so a crash never leaves a torn snapshot
+	tmpFile := fs.relationsFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, fs.relationsFile)
 }
 
 func (fs *FileStore) loadRelationsFile() (*models.RelationSet, error) {
@@ -360,11 +482,20 @@ func (fs *FileStore) loadRelationsFile() (*models.RelationSet, error) {
 
 // ClearCache clears the in-memory cache
 func (fs *FileStore) ClearCache() {
+	// Searches hold the search lock while loading entities, so it is taken first
+	fs.searchMutex.Lock()
+	fs.search = nil
+	fs.searchMutex.Unlock()
+
 	fs.cacheMutex.Lock()
 	defer fs.cacheMutex.Unlock()
 
 	fs.entityCache = make(map[string]*models.Entity)
-	fs.relationCache = &models.RelationSet{Relations: make([]models.Relation, 0)}
+	fs.nameIndex = nil
+
+	fs.relationMutex.Lock()
+	fs.relations = nil
+	fs.relationMutex.Unlock()
 }
 
 // Storage interface implementation
@@ -471,14 +602,70 @@ func (tx *NoOpTransaction) ListEntities(ctx context.Context, entityType string)
 	return tx.store.ListEntities(ctx, entityType)
 }
 
+func (tx *NoOpTransaction) ListEntitiesFiltered(ctx context.Context, filter storage.EntityFilter) ([]*models.Entity, error) {
+	return tx.store.ListEntitiesFiltered(ctx, filter)
+}
+
 func (tx *NoOpTransaction) EntityExists(name string) bool {
 	return tx.store.EntityExists(name)
 }
 
+func (tx *NoOpTransaction) DeleteEntityWithPolicy(ctx context.Context, name string, policy storage.DeletePolicy) error {
+	return tx.store.DeleteEntityWithPolicy(ctx, name, policy)
+}
+
+func (tx *NoOpTransaction) ListTrash(ctx context.Context) ([]storage.TrashEntry, error) {
+	return tx.store.ListTrash(ctx)
+}
+
+func (tx *NoOpTransaction) RestoreEntity(ctx context.Context, name string) (*models.Entity, error) {
+	return tx.store.RestoreEntity(ctx, name)
+}
+
+func (tx *NoOpTransaction) PurgeTrash(ctx context.Context) (int, error) {
+	return tx.store.PurgeTrash(ctx)
+}
+
+func (tx *NoOpTransaction) ResolveEntityName(ctx context.Context, name string) (string, error) {
+	return tx.store.ResolveEntityName(ctx, name)
+}
+
+func (tx *NoOpTransaction) RenameEntity(ctx context.Context, oldName, newName string) (*models.Entity, error) {
+	return tx.store.RenameEntity(ctx, oldName, newName)
+}
+
+func (tx *NoOpTransaction) MergeEntities(ctx context.Context, sourceName, targetName string) (*models.Entity, error) {
+	return tx.store.MergeEntities(ctx, sourceName, targetName)
+}
+
+func (tx *NoOpTransaction) TypeRegistry() *schema.Registry {
+	return tx.store.TypeRegistry()
+}
+
+func (tx *NoOpTransaction) RelationTypeRegistry() *schema.RelationRegistry {
+	return tx.store.RelationTypeRegistry()
+}
+
 func (tx *NoOpTransaction) SearchObservations(ctx context.Context, query string, entityType string) ([]storage.SearchResult, error) {
 	return tx.store.SearchObservations(ctx, query, entityType)
 }
 
+func (tx *NoOpTransaction) SearchObservationsFiltered(ctx context.Context, query string, filter storage.ObservationFilter) ([]storage.SearchResult, error) {
+	return tx.store.SearchObservationsFiltered(ctx, query, filter)
+}
+
+func (tx *NoOpTransaction) SearchAll(ctx context.Context, query string, kinds []string, filter storage.ObservationFilter) ([]storage.SearchHit, error) {
+	return tx.store.SearchAll(ctx, query, kinds, filter)
+}
+
+func (tx *NoOpTransaction) SuggestSearch(ctx context.Context, query string, filter storage.ObservationFilter) ([]string, error) {
+	return tx.store.SuggestSearch(ctx, query, filter)
+}
+
+func (tx *NoOpTransaction) PurgeExpiredObservations(ctx context.Context, grace time.Duration) (int, error) {
+	return tx.store.PurgeExpiredObservations(ctx, grace)
+}
+
 func (tx *NoOpTransaction) GetRelations(ctx context.Context) (*models.RelationSet, error) {
 	return tx.store.GetRelations(ctx)
 }
@@ -487,6 +674,34 @@ func (tx *NoOpTransaction) SaveRelations(ctx context.Context, relations *models.
 	return tx.store.SaveRelations(ctx, relations)
 }
 
+func (tx *NoOpTransaction) GetRelation(ctx context.Context, id string) (*models.Relation, error) {
+	return tx.store.GetRelation(ctx, id)
+}
+
+func (tx *NoOpTransaction) GetEntityRelations(ctx context.Context, name string) ([]models.Relation, error) {
+	return tx.store.GetEntityRelations(ctx, name)
+}
+
+func (tx *NoOpTransaction) QueryRelations(ctx context.Context, filter storage.RelationFilter) ([]models.Relation, error) {
+	return tx.store.QueryRelations(ctx, filter)
+}
+
+func (tx *NoOpTransaction) CreateRelation(ctx context.Context, relation *models.Relation) (*models.Relation, bool, error) {
+	return tx.store.CreateRelation(ctx, relation)
+}
+
+func (tx *NoOpTransaction) UpdateRelation(ctx context.Context, relation *models.Relation) error {
+	return tx.store.UpdateRelation(ctx, relation)
+}
+
+func (tx *NoOpTransaction) DeleteRelation(ctx context.Context, id string) error {
+	return tx.store.DeleteRelation(ctx, id)
+}
+
+func (tx *NoOpTransaction) DedupeRelations(ctx context.Context) ([]models.Relation, error) {
+	return tx.store.DedupeRelations(ctx)
+}
+
 // Context operations (not implemented)
 func (tx *NoOpTransaction) CreateContext(ctx context.Context, obj types.ContextObject) error {
 	return tx.store.CreateContext(ctx, obj)
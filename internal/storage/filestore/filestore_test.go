@@ -2,11 +2,15 @@ package filestore
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
 )
 
 func setupTestFileStore(t *testing.T) (*FileStore, string) {
@@ -323,3 +327,892 @@ func TestCreateDuplicateEntity(t *testing.T) {
 		t.Error("Expected error when creating duplicate entity")
 	}
 }
+
+func TestSearchObservationsFiltered(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("auth_service", "service")
+	entity.AddObservation("use JWT for session tokens")
+	secure := models.NewObservation("never log session tokens")
+	secure.Tags = []string{"security"}
+	secure.Metadata = map[string]string{"team": "platform"}
+	entity.AppendObservation(secure)
+
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Filter by tag
+	results, err := fs.SearchObservationsFiltered(ctx, "tokens", storage.ObservationFilter{Tags: []string{"security"}})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result tagged 'security', got %d", len(results))
+	}
+
+	// Filter by metadata with an empty query
+	results, err = fs.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{Metadata: map[string]string{"team": "platform"}})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result with team=platform, got %d", len(results))
+	}
+}
+
+func TestExpiredObservations(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("staging", "environment")
+	entity.AddObservation("staging DB runs Postgres 16")
+	expired := models.NewObservation("staging DB is down for maintenance")
+	expired.CreatedAt = time.Now().Add(-48 * time.Hour)
+	expired.SetTTL(time.Hour)
+	entity.AppendObservation(expired)
+
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Expired observations are hidden from search
+	results, err := fs.SearchObservations(ctx, "staging DB", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("Expected 1 unexpired result, got %d", len(results))
+	}
+
+	// A grace period longer than the expiry keeps the observation on disk
+	purged, err := fs.PurgeExpiredObservations(ctx, 72*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to purge expired observations: %v", err)
+	}
+
+	if purged != 0 {
+		t.Errorf("Expected 0 observations purged within grace period, got %d", purged)
+	}
+
+	purged, err = fs.PurgeExpiredObservations(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to purge expired observations: %v", err)
+	}
+
+	if purged != 1 {
+		t.Errorf("Expected 1 observation purged, got %d", purged)
+	}
+
+	fs.ClearCache()
+	reloaded, err := fs.GetEntity(ctx, "staging")
+	if err != nil {
+		t.Fatalf("Failed to get entity: %v", err)
+	}
+
+	if reloaded.GetObservationCount() != 1 {
+		t.Errorf("Expected 1 observation on disk after purge, got %d", reloaded.GetObservationCount())
+	}
+}
+
+func TestPurgeExpiredDuringUpdates(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	// Entities listed before the target keep the sweep busy, so updates to
+	// the target land between the sweep listing it and purging it
+	var names []string
+	for i := range 100 {
+		names = append(names, fmt.Sprintf("env_%03d", i))
+	}
+	names = append(names, "zz_staging")
+	for _, name := range names {
+		if err := fs.CreateEntity(ctx, models.NewEntity(name, "environment")); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	// Each round gives every entity an expired observation to purge, and
+	// the timing of the purge against the updates differs between rounds
+	added := 0
+	for range 5 {
+		for _, name := range names {
+			entity, err := fs.GetEntity(ctx, name)
+			if err != nil {
+				t.Fatalf("Failed to get entity: %v", err)
+			}
+			entity = entity.Clone()
+			expired := models.NewObservation("temporary notice")
+			expired.CreatedAt = time.Now().Add(-48 * time.Hour)
+			expired.SetTTL(time.Hour)
+			entity.AppendObservation(expired)
+			if err := fs.UpdateEntity(ctx, entity); err != nil {
+				t.Fatalf("Failed to update entity: %v", err)
+			}
+		}
+
+		swept := make(chan error, 1)
+		go func() {
+			_, err := fs.PurgeExpiredObservations(ctx, 0)
+			swept <- err
+		}()
+
+		for sweeping := true; sweeping; {
+			select {
+			case err := <-swept:
+				if err != nil {
+					t.Fatalf("Failed to purge expired observations: %v", err)
+				}
+				sweeping = false
+			default:
+				entity, err := fs.GetEntity(ctx, "zz_staging")
+				if err != nil {
+					t.Fatalf("Failed to get entity: %v", err)
+				}
+				entity = entity.Clone()
+				entity.AddObservation(fmt.Sprintf("fact %d", added))
+				if err := fs.UpdateEntity(ctx, entity); err != nil {
+					t.Fatalf("Failed to update entity: %v", err)
+				}
+				added++
+
+				// A purge landing between updates must keep every fact; an
+				// update that read the target before the purge may write the
+				// expired observation back for the next sweep to remove
+				if facts := countFacts(t, fs, "zz_staging"); facts != added {
+					t.Fatalf("Expected the %d facts added during the sweep to be kept, got %d", added, facts)
+				}
+			}
+		}
+	}
+
+	if _, err := fs.PurgeExpiredObservations(ctx, 0); err != nil {
+		t.Fatalf("Failed to purge expired observations: %v", err)
+	}
+	fs.ClearCache()
+	entity, err := fs.GetEntity(ctx, "zz_staging")
+	if err != nil {
+		t.Fatalf("Failed to get entity: %v", err)
+	}
+	if entity.GetObservationCount() != added {
+		t.Errorf("Expected only the %d facts to remain, got %d observations", added, entity.GetObservationCount())
+	}
+}
+
+// countFacts returns how many unexpired observations an entity holds on disk
+func countFacts(t *testing.T, fs *FileStore, name string) int {
+	t.Helper()
+	entity, err := fs.loadEntityFile(name)
+	if err != nil {
+		t.Fatalf("Failed to load entity: %v", err)
+	}
+	facts := 0
+	for _, obs := range entity.Observations {
+		if !obs.IsExpired(time.Now()) {
+			facts++
+		}
+	}
+	return facts
+}
+
+func TestSearchObservationsRanking(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("standards", "guideline")
+	entity.AddObservation("commit messages are lowercase")
+	critical := models.NewObservation("commit signing is mandatory")
+	critical.Importance = 5
+	entity.AppendObservation(critical)
+
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	results, err := fs.SearchObservations(ctx, "commit", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Observation.ID != critical.ID {
+		t.Errorf("Expected critical observation to be ranked first, got %+v", results)
+	}
+
+	// Filter by minimum importance
+	results, err = fs.SearchObservationsFiltered(ctx, "commit", storage.ObservationFilter{MinImportance: 4})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result with importance >= 4, got %d", len(results))
+	}
+}
+
+func TestSearchHidesSupersededObservations(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("architecture", "decision")
+	entity.AddObservation("services talk over REST")
+	replacement := models.NewObservation("services talk over gRPC")
+	replacement.Supersedes = []string{entity.Observations[0].ID}
+	if _, err := entity.Supersede(replacement); err != nil {
+		t.Fatalf("Failed to supersede observation: %v", err)
+	}
+
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	results, err := fs.SearchObservations(ctx, "services", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Observation.ID != replacement.ID {
+		t.Errorf("Expected only the replacement observation, got %+v", results)
+	}
+
+	results, err = fs.SearchObservationsFiltered(ctx, "services", storage.ObservationFilter{IncludeSuperseded: true})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected 2 results including superseded, got %d", len(results))
+	}
+}
+
+func TestSearchObservationsByProvenance(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("api", "component")
+	remember := models.NewObservation("remember creates missing entities")
+	remember.Provenance = &models.Provenance{FilePath: "internal/api/memory.go", Symbol: "handleMemoryRemember"}
+	router := models.NewObservation("all routes are registered in one place")
+	router.Provenance = &models.Provenance{FilePath: "internal/api/router.go"}
+	entity.AppendObservation(remember)
+	entity.AppendObservation(router)
+	entity.AddObservation("the API speaks JSON")
+
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	results, err := fs.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{FilePath: "internal/api/memory.go"})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 || results[0].Observation.ID != remember.ID {
+		t.Errorf("Expected only the memory.go observation, got %+v", results)
+	}
+
+	results, err = fs.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{FilePath: "internal/api/"})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 observations under internal/api/, got %d", len(results))
+	}
+
+	results, err = fs.SearchObservationsFiltered(ctx, "", storage.ObservationFilter{Symbol: "handleMemoryRemember"})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 observation for symbol, got %d", len(results))
+	}
+}
+
+func TestResolveEntityName(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	entity := models.NewEntity("auth_service", "component")
+	entity.AddAlias("login")
+	if err := fs.CreateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	for _, name := range []string{"auth_service", "AuthService", "auth-service", "LOGIN"} {
+		resolved, err := fs.ResolveEntityName(ctx, name)
+		if err != nil || resolved != "auth_service" {
+			t.Errorf("ResolveEntityName(%q) = %q, %v; want auth_service", name, resolved, err)
+		}
+
+		retrieved, err := fs.GetEntity(ctx, name)
+		if err != nil || retrieved.Name != "auth_service" {
+			t.Errorf("GetEntity(%q) failed: %v", name, err)
+		}
+
+		if !fs.EntityExists(name) {
+			t.Errorf("Expected EntityExists(%q) to be true", name)
+		}
+	}
+
+	if _, err := fs.GetEntity(ctx, "billing"); err == nil || err.Error() != "entity 'billing' not found" {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	// Equivalent names cannot create duplicates
+	if err := fs.CreateEntity(ctx, models.NewEntity("AuthService", "component")); err == nil {
+		t.Error("Expected creating an equivalent entity to fail")
+	}
+
+	// Aliases cannot point at another entity's name
+	other := models.NewEntity("billing", "component")
+	other.AddAlias("auth service")
+	if err := fs.CreateEntity(ctx, other); err == nil {
+		t.Error("Expected conflicting alias to be rejected")
+	}
+
+	// New aliases are picked up after an update
+	entity.AddAlias("identity")
+	if err := fs.UpdateEntity(ctx, entity); err != nil {
+		t.Fatalf("Failed to update entity: %v", err)
+	}
+	if resolved, err := fs.ResolveEntityName(ctx, "Identity"); err != nil || resolved != "auth_service" {
+		t.Errorf("Expected new alias to resolve, got %q, %v", resolved, err)
+	}
+
+	// Deleting by alias removes the entity
+	if err := fs.DeleteEntity(ctx, "login"); err != nil {
+		t.Fatalf("Failed to delete entity: %v", err)
+	}
+	if fs.EntityExists("auth_service") {
+		t.Error("Expected entity to be deleted via its alias")
+	}
+}
+
+func TestRenameEntity(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"auth_svc", "api"} {
+		if err := fs.CreateEntity(ctx, models.NewEntity(name, "component")); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+	relations := &models.RelationSet{}
+	relations.AddRelation("api", "auth_svc", "depends_on")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+
+	renamed, err := fs.RenameEntity(ctx, "auth_svc", "auth_service")
+	if err != nil {
+		t.Fatalf("Failed to rename entity: %v", err)
+	}
+	if renamed.Name != "auth_service" || !renamed.MatchesName("auth_svc") {
+		t.Errorf("Expected renamed entity with old name as alias, got %+v", renamed)
+	}
+
+	if resolved, err := fs.ResolveEntityName(ctx, "auth_svc"); err != nil || resolved != "auth_service" {
+		t.Errorf("Expected old name to resolve to new name, got %q, %v", resolved, err)
+	}
+
+	fs.ClearCache()
+	saved, err := fs.GetRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+	if len(saved.Relations) != 1 || saved.Relations[0].To != "auth_service" {
+		t.Errorf("Expected relation to be rewritten, got %+v", saved.Relations)
+	}
+
+	if _, err := fs.RenameEntity(ctx, "auth_service", "api"); !storage.IsAlreadyExists(err) {
+		t.Errorf("Expected renaming onto an existing entity to fail, got %v", err)
+	}
+	if _, err := fs.RenameEntity(ctx, "billing", "payments"); !storage.IsNotFound(err) {
+		t.Errorf("Expected renaming an unknown entity to fail, got %v", err)
+	}
+}
+
+func TestMergeEntities(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	source := models.NewEntity("AuthSvc", "component")
+	source.AddObservation("issues JWTs")
+	source.AddObservation("rate limits logins")
+	target := models.NewEntity("auth_service", "component")
+	target.AddObservation("issues JWTs")
+	for _, entity := range []*models.Entity{source, target, models.NewEntity("api", "component")} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+	relations := &models.RelationSet{}
+	relations.AddRelation("api", "AuthSvc", "depends_on")
+	relations.AddRelation("api", "auth_service", "depends_on")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+
+	merged, err := fs.MergeEntities(ctx, "AuthSvc", "auth_service")
+	if err != nil {
+		t.Fatalf("Failed to merge entities: %v", err)
+	}
+	if merged.GetObservationCount() != 2 {
+		t.Errorf("Expected 2 deduplicated observations, got %d", merged.GetObservationCount())
+	}
+
+	// The source is gone but its name resolves to the target
+	if _, err := fs.getEntityExact("AuthSvc"); err == nil {
+		t.Error("Expected source entity file to be removed")
+	}
+	if retrieved, err := fs.GetEntity(ctx, "AuthSvc"); err != nil || retrieved.Name != "auth_service" {
+		t.Errorf("Expected source name to resolve to target, got %v", err)
+	}
+
+	saved, err := fs.GetRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get relations: %v", err)
+	}
+	if len(saved.Relations) != 1 {
+		t.Errorf("Expected duplicate relation to be dropped, got %+v", saved.Relations)
+	}
+
+	if _, err := fs.MergeEntities(ctx, "auth_service", "AuthSvc"); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected merging an entity into itself to fail, got %v", err)
+	}
+}
+
+func TestDeleteEntityPolicies(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	for _, name := range []string{"api", "auth", "db"} {
+		if err := fs.CreateEntity(ctx, models.NewEntity(name, "component")); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+	relations := &models.RelationSet{}
+	relations.AddRelation("api", "auth", "depends_on")
+	relations.AddRelation("auth", "db", "uses")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+
+	// Missing entities report not found
+	if err := fs.DeleteEntity(ctx, "missing"); !storage.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	// Referenced entities are kept by the reject policy
+	if err := fs.DeleteEntityWithPolicy(ctx, "auth", storage.DeleteReject); !storage.IsReferenced(err) {
+		t.Errorf("Expected referenced error, got %v", err)
+	}
+	if !fs.EntityExists("auth") {
+		t.Error("Expected rejected delete to keep the entity")
+	}
+	// DeleteEntity uses the same default as the API
+	if err := fs.DeleteEntity(ctx, "auth"); !storage.IsReferenced(err) {
+		t.Errorf("Expected DeleteEntity to reject a referenced entity, got %v", err)
+	}
+
+	// Cascade removes the relations along with the entity
+	if err := fs.DeleteEntityWithPolicy(ctx, "auth", storage.DeleteCascade); err != nil {
+		t.Fatalf("Failed to cascade delete: %v", err)
+	}
+	saved, _ := fs.GetRelations(ctx)
+	if len(saved.GetRelationsByEntity("auth")) != 0 {
+		t.Errorf("Expected relations to be deleted, got %+v", saved.Relations)
+	}
+
+	trash, err := fs.ListTrash(ctx)
+	if err != nil || len(trash) != 1 || len(trash[0].Relations) != 2 {
+		t.Fatalf("Expected one trash entry with 2 relations, got %+v, %v", trash, err)
+	}
+
+	// Restoring brings back the entity and its relations
+	restored, err := fs.RestoreEntity(ctx, "Auth")
+	if err != nil {
+		t.Fatalf("Failed to restore entity: %v", err)
+	}
+	if restored.Name != "auth" || !fs.EntityExists("auth") {
+		t.Errorf("Expected auth to be restored, got %+v", restored)
+	}
+	saved, _ = fs.GetRelations(ctx)
+	if len(saved.Relations) != 2 {
+		t.Errorf("Expected 2 relations after restore, got %+v", saved.Relations)
+	}
+	if trash, _ := fs.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("Expected empty trash after restore, got %d entries", len(trash))
+	}
+
+	// Detach leaves relations in place
+	if err := fs.DeleteEntityWithPolicy(ctx, "db", storage.DeleteDetach); err != nil {
+		t.Fatalf("Failed to detach delete: %v", err)
+	}
+	saved, _ = fs.GetRelations(ctx)
+	if len(saved.GetRelationsByEntity("db")) != 1 {
+		t.Errorf("Expected detached relation to remain, got %+v", saved.Relations)
+	}
+
+	// A name taken since the delete blocks the restore
+	database := models.NewEntity("database", "component")
+	database.AddAlias("db")
+	if err := fs.CreateEntity(ctx, database); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if _, err := fs.RestoreEntity(ctx, "db"); !storage.IsAlreadyExists(err) {
+		t.Errorf("Expected restore onto a taken name to conflict, got %v", err)
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	if err := fs.CreateEntity(ctx, models.NewEntity("scratch", "memory")); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	fs.SetTrashRetention(-time.Minute)
+	if err := fs.DeleteEntity(ctx, "scratch"); err != nil {
+		t.Fatalf("Failed to delete entity: %v", err)
+	}
+
+	if _, err := fs.RestoreEntity(ctx, "scratch"); !storage.IsNotFound(err) {
+		t.Errorf("Expected expired trash entry not to be restorable, got %v", err)
+	}
+
+	purged, err := fs.PurgeTrash(ctx)
+	if err != nil || purged != 1 {
+		t.Errorf("Expected 1 purged trash entry, got %d, %v", purged, err)
+	}
+}
+
+func TestMergeEntitiesValidatesType(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	registry, err := schema.NewRegistry([]schema.TypeDefinition{
+		{Name: "service", RequiredTags: []string{"owner"}},
+		{Name: "component"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+	fs.SetTypeRegistry(registry)
+
+	source := models.NewEntity("AuthSvc", "component")
+	source.AddObservation("rate limits logins")
+	target := models.NewEntity("auth_service", "service")
+	owned := models.NewObservation("owned by the identity team")
+	owned.Tags = []string{"owner"}
+	target.AppendObservation(owned)
+	for _, entity := range []*models.Entity{source, target} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	// The untagged observation would break the target's required tags
+	if _, err := fs.MergeEntities(ctx, "AuthSvc", "auth_service"); !storage.IsInvalidInput(err) {
+		t.Fatalf("Expected merge breaking the target type to be rejected, got %v", err)
+	}
+
+	fs.ClearCache()
+	if _, err := fs.GetEntity(ctx, "AuthSvc"); err != nil {
+		t.Errorf("Expected the source to be kept, got %v", err)
+	}
+	if retrieved, err := fs.GetEntity(ctx, "auth_service"); err != nil || retrieved.GetObservationCount() != 1 {
+		t.Errorf("Expected the target to be unchanged, got %+v, %v", retrieved, err)
+	}
+}
+
+func TestEntityTypeRegistry(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	registry, err := schema.NewRegistry([]schema.TypeDefinition{
+		{Name: "guideline", Aliases: []string{"guidelines"}, RelationTypes: []string{"applies_to"}},
+		{Name: "service"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+	fs.SetTypeRegistry(registry)
+
+	if err := fs.CreateEntity(ctx, models.NewEntity("scratch", "note")); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected unknown type to be rejected, got %v", err)
+	}
+
+	style := models.NewEntity("style", "Guidelines")
+	if err := fs.CreateEntity(ctx, style); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	if style.EntityType != "guideline" {
+		t.Errorf("Expected canonical type, got %q", style.EntityType)
+	}
+	if err := fs.CreateEntity(ctx, models.NewEntity("api", "service")); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	if entities, _ := fs.ListEntities(ctx, "GUIDELINES"); len(entities) != 1 {
+		t.Errorf("Expected type filter to match aliases, got %d entities", len(entities))
+	}
+
+	relations, _ := fs.GetRelations(ctx)
+	relations.AddRelation("style", "api", "depends_on")
+	if err := fs.SaveRelations(ctx, relations); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected disallowed relation type to be rejected, got %v", err)
+	}
+	if relations, _ := fs.GetRelations(ctx); len(relations.Relations) != 0 {
+		t.Errorf("Expected rejected relation not to be cached, got %+v", relations.Relations)
+	}
+
+	relations, _ = fs.GetRelations(ctx)
+	relations.AddRelation("style", "api", "applies_to")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Errorf("Expected allowed relation to be saved, got %v", err)
+	}
+}
+
+func TestRelationTypeRegistry(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	for _, entity := range []*models.Entity{
+		models.NewEntity("api", "component"),
+		models.NewEntity("auth", "component"),
+		models.NewEntity("retry", "pattern"),
+	} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	relations, _ := fs.GetRelations(ctx)
+	relations.AddRelation("auth", "api", "used_by")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Fatalf("Failed to save relations: %v", err)
+	}
+	relations, _ = fs.GetRelations(ctx)
+	if rel := relations.Relations[0]; rel.From != "api" || rel.To != "auth" || rel.RelationType != "depends_on" {
+		t.Errorf("Expected relation stored as 'api depends_on auth', got %+v", rel)
+	}
+
+	registry, err := schema.NewRelationRegistry([]schema.RelationTypeDefinition{
+		{Name: "implements", SourceTypes: []string{"component"}, TargetTypes: []string{"pattern"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+	registry.RejectUnknownTypes = true
+	fs.SetRelationTypeRegistry(registry)
+
+	relations, _ = fs.GetRelations(ctx)
+	relations.AddRelation("retry", "api", "implements")
+	if err := fs.SaveRelations(ctx, relations); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected disallowed source type to be rejected, got %v", err)
+	}
+
+	relations, _ = fs.GetRelations(ctx)
+	relations.AddRelation("api", "auth", "calls")
+	if err := fs.SaveRelations(ctx, relations); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected unknown relation type to be rejected, got %v", err)
+	}
+
+	relations, _ = fs.GetRelations(ctx)
+	relations.AddRelation("api", "retry", "implements")
+	if err := fs.SaveRelations(ctx, relations); err != nil {
+		t.Errorf("Expected allowed relation to be saved, got %v", err)
+	}
+}
+
+func TestRelationUniqueness(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	first := models.NewRelation("api", "auth", "depends_on")
+	stored, created, err := fs.CreateRelation(ctx, &first)
+	if err != nil || !created {
+		t.Fatalf("Expected relation to be created, got %v", err)
+	}
+
+	again := models.NewRelation("auth", "api", "Used-By")
+	existing, created, err := fs.CreateRelation(ctx, &again)
+	if err != nil {
+		t.Fatalf("Failed to repeat create: %v", err)
+	}
+	if created || existing.ID != stored.ID {
+		t.Errorf("Expected repeated create to return relation %s, got %+v", stored.ID, existing)
+	}
+
+	relations, _ := fs.GetRelations(ctx)
+	relations.AddRelation("api", "auth", "depends-on")
+	if err := fs.SaveRelations(ctx, relations); !storage.IsDuplicateRelation(err) {
+		t.Errorf("Expected duplicate relation to be rejected, got %v", err)
+	}
+
+	// Duplicates written before uniqueness was enforced are removed by a dedupe pass
+	duplicate := models.NewRelation("api", "auth", "depends_on")
+	duplicate.Weight = 0.8
+	inverse := models.NewRelation("auth", "api", "used_by")
+	if err := fs.saveRelationsFile(&models.RelationSet{Relations: []models.Relation{*stored, duplicate, inverse}}); err != nil {
+		t.Fatal(err)
+	}
+	fs.ClearCache()
+
+	removed, err := fs.DedupeRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dedupe relations: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("Expected 2 duplicates removed, got %+v", removed)
+	}
+	relations, _ = fs.GetRelations(ctx)
+	if len(relations.Relations) != 1 || relations.Relations[0].ID != stored.ID || relations.Relations[0].Weight != 0.8 {
+		t.Errorf("Expected the first relation to remain with the duplicate's weight, got %+v", relations.Relations)
+	}
+}
+
+func TestSymmetricRelationUniqueness(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	// related_to is symmetric, so either direction is the same relation
+	first := models.NewRelation("api", "docs", "related_to")
+	stored, created, err := fs.CreateRelation(ctx, &first)
+	if err != nil || !created {
+		t.Fatalf("Expected relation to be created, got %v", err)
+	}
+	reversed := models.NewRelation("docs", "api", "related_to")
+	existing, created, err := fs.CreateRelation(ctx, &reversed)
+	if err != nil {
+		t.Fatalf("Failed to repeat create: %v", err)
+	}
+	if created || existing.ID != stored.ID {
+		t.Errorf("Expected the reversed create to return relation %s, got %+v", stored.ID, existing)
+	}
+
+	relations, _ := fs.GetRelations(ctx)
+	relations.AddRelation("docs", "api", "related-to")
+	if err := fs.SaveRelations(ctx, relations); !storage.IsDuplicateRelation(err) {
+		t.Errorf("Expected the reversed relation to be rejected as a duplicate, got %v", err)
+	}
+
+	// Directed types still tell the directions apart
+	forward := models.NewRelation("api", "docs", "depends_on")
+	backward := models.NewRelation("docs", "api", "depends_on")
+	for _, rel := range []*models.Relation{&forward, &backward} {
+		if _, created, err := fs.CreateRelation(ctx, rel); err != nil || !created {
+			t.Errorf("Expected %s %s %s to be created, got %v", rel.From, rel.RelationType, rel.To, err)
+		}
+	}
+
+	// Pairs stored before uniqueness covered symmetric types are deduped
+	if err := fs.saveRelationsFile(&models.RelationSet{Relations: []models.Relation{*stored, reversed}}); err != nil {
+		t.Fatal(err)
+	}
+	fs.ClearCache()
+	removed, err := fs.DedupeRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to dedupe relations: %v", err)
+	}
+	if len(removed) != 1 || removed[0].ID != reversed.ID {
+		t.Errorf("Expected the reversed relation to be removed, got %+v", removed)
+	}
+}
+
+func TestEntityAttributeFilters(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	payments := models.NewEntity("payments", "service")
+	payments.SetAttributes(map[string]any{"owner": "team-payments", "language": "go"})
+	payments.AddObservation("retries card charges")
+	search := models.NewEntity("search", "service")
+	search.SetAttributes(map[string]any{"owner": "team-search", "language": "go"})
+	search.AddObservation("retries index writes")
+	for _, entity := range []*models.Entity{payments, search} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	owner := "team-payments"
+	entities, err := fs.ListEntitiesFiltered(ctx, storage.EntityFilter{Owner: &owner})
+	if err != nil || len(entities) != 1 || entities[0].Name != "payments" {
+		t.Errorf("Expected owner filter to find payments, got %v, %v", entities, err)
+	}
+
+	entities, _ = fs.ListEntitiesFiltered(ctx, storage.EntityFilter{
+		Attributes: map[string]string{"language": "go"},
+		SortBy:     "name",
+		SortOrder:  "desc",
+		Limit:      1,
+	})
+	if len(entities) != 1 || entities[0].Name != "search" {
+		t.Errorf("Expected sorted, limited results, got %v", entities)
+	}
+
+	results, _ := fs.SearchObservationsFiltered(ctx, "retries", storage.ObservationFilter{
+		Attributes: map[string]string{"owner": "team-search"},
+	})
+	if len(results) != 1 || results[0].EntityName != "search" {
+		t.Errorf("Expected attribute filter to restrict search, got %+v", results)
+	}
+
+	// Attributes are validated against the type's schema
+	registry, err := schema.NewRegistry([]schema.TypeDefinition{{
+		Name: "service",
+		AttributeSchema: &schema.Schema{
+			Type:       "object",
+			Properties: map[string]*schema.Schema{"owner": {Type: "string", Pattern: "^team-"}},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build registry: %v", err)
+	}
+	fs.SetTypeRegistry(registry)
+
+	updated := *payments
+	updated.Attributes = map[string]any{"owner": "payments"}
+	if err := fs.UpdateEntity(ctx, &updated); !storage.IsInvalidInput(err) {
+		t.Errorf("Expected schema violation to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,483 @@
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// Relations are kept in memory in a relationIndex. On disk they are stored as
+// a snapshot (relations.json, the format older versions wrote) followed by
+// an append-only log of changes (relations.log), so a change writes only the
+// relations it touches. The log is folded into the snapshot once it grows
+// past a quarter of the relation count.
+
+// relationOp is one change recorded in the relation log
+type relationOp struct {
+	Op       string           `json:"op"`
+	ID       string           `json:"id,omitempty"`
+	Relation *models.Relation `json:"relation,omitempty"`
+}
+
+// Relation log operations
+const (
+	relationPut    = "put"
+	relationDelete = "delete"
+)
+
+// minLogCompaction is the number of logged changes below which the relation
+// log is never folded into the snapshot
+const minLogCompaction = 1000
+
+// GetRelation returns the relation with the given ID
+func (fs *FileStore) GetRelation(ctx context.Context, id string) (*models.Relation, error) {
+	ix, err := fs.readRelations()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.relationMutex.RUnlock()
+
+	rel, ok := ix.get(id)
+	if !ok {
+		return nil, storage.NewStorageError("get", "relation", id, storage.ErrNotFound)
+	}
+	return &rel, nil
+}
+
+// GetEntityRelations returns the relations starting or ending at an entity,
+// in time proportional to the entity's degree
+func (fs *FileStore) GetEntityRelations(ctx context.Context, name string) ([]models.Relation, error) {
+	ix, err := fs.readRelations()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.relationMutex.RUnlock()
+
+	return ix.byEntity(name), nil
+}
+
+// QueryRelations returns the stored relations matching a filter, using the
+// adjacency indexes for the from, to and relation type fields
+func (fs *FileStore) QueryRelations(ctx context.Context, filter storage.RelationFilter) ([]models.Relation, error) {
+	ix, err := fs.readRelations()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.relationMutex.RUnlock()
+
+	return ix.query(filter), nil
+}
+
+// CreateRelation stores a relation in its registered direction unless a
+// relation with the same endpoints and type exists, in which case the
+// existing relation is returned and created is false
+func (fs *FileStore) CreateRelation(ctx context.Context, relation *models.Relation) (*models.Relation, bool, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	if err := relation.Validate(); err != nil {
+		return nil, false, storage.NewStorageError("create", "relation", relation.ID, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if err := fs.checkRelation(relation); err != nil {
+		return nil, false, err
+	}
+
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return nil, false, err
+	}
+	defer fs.relationMutex.Unlock()
+
+	if existing, ok := ix.find(fs.relationTypes.Key(*relation), fs.relationTypes.Key); ok {
+		return &existing, false, nil
+	}
+	if err := fs.appendRelationOps(ix, []relationOp{{Op: relationPut, Relation: relation}}); err != nil {
+		return nil, false, err
+	}
+	return relation, true, nil
+}
+
+// UpdateRelation replaces the stored relation with the same ID
+func (fs *FileStore) UpdateRelation(ctx context.Context, relation *models.Relation) error {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	fs.relationTypes.Canonicalize(relation)
+	if err := relation.Validate(); err != nil {
+		return storage.NewStorageError("update", "relation", relation.ID, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+
+	stored, err := fs.GetRelation(ctx, relation.ID)
+	if err != nil {
+		return err
+	}
+	// Like SaveRelations, only check relations whose endpoints or type changed
+	if stored.Key() != relation.Key() {
+		if err := fs.checkRelation(relation); err != nil {
+			return err
+		}
+	}
+
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	if existing, ok := ix.find(fs.relationTypes.Key(*relation), fs.relationTypes.Key); ok && existing.ID != relation.ID {
+		return storage.NewStorageError("update", "relation", relation.ID,
+			fmt.Errorf("%w: %s %s %s", storage.ErrDuplicateRelation, relation.From, relation.RelationType, relation.To))
+	}
+	return fs.appendRelationOps(ix, []relationOp{{Op: relationPut, Relation: relation}})
+}
+
+// DeleteRelation removes the relation with the given ID
+func (fs *FileStore) DeleteRelation(ctx context.Context, id string) error {
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	if _, ok := ix.get(id); !ok {
+		return storage.NewStorageError("delete", "relation", id, storage.ErrNotFound)
+	}
+	return fs.appendRelationOps(ix, []relationOp{{Op: relationDelete, ID: id}})
+}
+
+// DedupeRelations removes relations that repeat the endpoints and type of an
+// earlier relation and returns the removed relations
+func (fs *FileStore) DedupeRelations(ctx context.Context) ([]models.Relation, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	relations, err := fs.GetRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relations stored under an inverse name duplicate their registered direction
+	for i := range relations.Relations {
+		fs.relationTypes.Canonicalize(&relations.Relations[i])
+	}
+
+	removed := relations.Dedupe(fs.relationTypes.Key)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	if err := fs.replaceRelations(relations); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// checkRelations rewrites new or changed relations stored under an inverse
+// name into their registered direction, and rejects them if they duplicate
+// another relation or fail checkRelation. Relations already stored are left
+// alone so registering types never blocks existing data.
+func (fs *FileStore) checkRelations(relations *models.RelationSet) error {
+	ix, err := fs.readRelations()
+	if err != nil {
+		return err
+	}
+	var changed []int
+	for i, rel := range relations.Relations {
+		if stored, ok := ix.get(rel.ID); !ok || stored.Key() != rel.Key() {
+			changed = append(changed, i)
+		}
+	}
+	fs.relationMutex.RUnlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, i := range changed {
+		if err := fs.checkRelation(&relations.Relations[i]); err != nil {
+			return err
+		}
+	}
+
+	counts := make(map[models.RelationKey]int, len(relations.Relations))
+	for _, rel := range relations.Relations {
+		counts[fs.relationTypes.Key(rel)]++
+	}
+	for _, i := range changed {
+		rel := relations.Relations[i]
+		if counts[fs.relationTypes.Key(rel)] > 1 {
+			return storage.NewStorageError("save", "relation", rel.ID,
+				fmt.Errorf("%w: %s %s %s", storage.ErrDuplicateRelation, rel.From, rel.RelationType, rel.To))
+		}
+	}
+	return nil
+}
+
+// checkRelation rewrites a relation stored under an inverse name into its
+// registered direction and rejects it if the relation type is unknown to a
+// strict relation registry or not allowed for the types of the entities it
+// connects
+func (fs *FileStore) checkRelation(rel *models.Relation) error {
+	fs.relationTypes.Canonicalize(rel)
+	rel.Inferred, rel.InferredFrom = false, ""
+
+	// Entity types are only checked when both entities exist
+	var fromType, toType string
+	from, fromErr := fs.getEntityExact(rel.From)
+	to, toErr := fs.getEntityExact(rel.To)
+	if fromErr == nil && toErr == nil {
+		fromType, toType = from.EntityType, to.EntityType
+	}
+
+	err := fs.relationTypes.ValidateRelation(rel.RelationType, fromType, toType)
+	if err == nil && fromType != "" && fs.types.Enabled() {
+		err = fs.types.ValidateRelation(fromType, toType, rel.RelationType)
+	}
+	if err != nil {
+		return storage.NewStorageError("save", "relation", rel.ID, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	return nil
+}
+
+// replaceRelations makes the stored relations match the set, logging only
+// the relations that were added, changed or removed
+func (fs *FileStore) replaceRelations(relations *models.RelationSet) error {
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	return fs.appendRelationOps(ix, diffRelations(ix, ix.all(), relations.Relations))
+}
+
+// rewriteRelations replaces the stored relations listed in previous with
+// relations, logging only the relations that were added, changed or removed
+func (fs *FileStore) rewriteRelations(previous, relations []models.Relation) error {
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	return fs.appendRelationOps(ix, diffRelations(ix, previous, relations))
+}
+
+// diffRelations returns the changes that turn the previous relations into
+// relations
+func diffRelations(ix *relationIndex, previous, relations []models.Relation) []relationOp {
+	var ops []relationOp
+	kept := make(map[string]bool, len(relations))
+	for _, rel := range relations {
+		kept[rel.ID] = true
+		if stored, ok := ix.get(rel.ID); ok && stored.Equal(rel) {
+			continue
+		}
+		ops = append(ops, relationOp{Op: relationPut, Relation: &rel})
+	}
+	for _, rel := range previous {
+		if !kept[rel.ID] {
+			ops = append(ops, relationOp{Op: relationDelete, ID: rel.ID})
+		}
+	}
+	return ops
+}
+
+// addRelations stores relations, skipping any that duplicate a stored relation
+func (fs *FileStore) addRelations(relations []models.Relation) error {
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	var ops []relationOp
+	added := make(map[models.RelationKey]bool, len(relations))
+	for _, rel := range relations {
+		key := fs.relationTypes.Key(rel)
+		if _, exists := ix.find(key, fs.relationTypes.Key); exists || added[key] {
+			continue
+		}
+		added[key] = true
+		ops = append(ops, relationOp{Op: relationPut, Relation: &rel})
+	}
+	return fs.appendRelationOps(ix, ops)
+}
+
+// removeRelations deletes the relations with the given IDs
+func (fs *FileStore) removeRelations(relations []models.Relation) error {
+	ix, err := fs.lockRelations()
+	if err != nil {
+		return err
+	}
+	defer fs.relationMutex.Unlock()
+
+	var ops []relationOp
+	for _, rel := range relations {
+		if _, ok := ix.get(rel.ID); ok {
+			ops = append(ops, relationOp{Op: relationDelete, ID: rel.ID})
+		}
+	}
+	return fs.appendRelationOps(ix, ops)
+}
+
+// appendRelationOps logs changes and applies them to the index. The caller
+// must hold the relation write lock.
+func (fs *FileStore) appendRelationOps(ix *relationIndex, ops []relationOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, op := range ops {
+		if op.Relation != nil {
+			rel := *op.Relation
+			rel.Inferred, rel.InferredFrom = false, ""
+			op.Relation = &rel
+		}
+		if err := encoder.Encode(op); err != nil {
+			return fmt.Errorf("failed to marshal relation change: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(fs.relationsLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open relation log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open relation log: %w", err)
+	}
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		// Cut off a partial write so later changes do not follow a torn line
+		_ = file.Truncate(info.Size())
+		file.Close()
+		return fmt.Errorf("failed to write relation log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write relation log: %w", err)
+	}
+
+	for _, op := range ops {
+		applyRelationOp(ix, op)
+	}
+	fs.relationLogOps += len(ops)
+
+	if fs.relationLogOps > max(minLogCompaction, ix.len()/4) {
+		// The changes are already durable in the log, so a failed compaction
+		// is retried on the next change
+		if err := fs.compactRelationLog(ix); err != nil {
+			fmt.Fprintf(os.Stderr, "[FileStore] Failed to compact relation log: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// compactRelationLog writes the index to the snapshot and empties the log.
+// The caller must hold the relation write lock.
+func (fs *FileStore) compactRelationLog(ix *relationIndex) error {
+	if err := fs.saveRelationsFile(&models.RelationSet{Relations: ix.all()}); err != nil {
+		return err
+	}
+	// Replaying a log the snapshot already contains is harmless, so a
+	// failure here only delays the cleanup
+	if err := os.Remove(fs.relationsLogFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove relation log: %w", err)
+	}
+	fs.relationLogOps = 0
+	return nil
+}
+
+// loadRelationIndex reads the snapshot and replays the log over it. It
+// returns the index and the number of logged changes.
+func (fs *FileStore) loadRelationIndex() (*relationIndex, int, error) {
+	snapshot, err := fs.loadRelationsFile()
+	if err != nil {
+		return nil, 0, err
+	}
+	ix := newRelationIndex(snapshot.Relations)
+
+	data, err := os.ReadFile(fs.relationsLogFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ix, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read relation log: %w", err)
+	}
+
+	ops := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		var op relationOp
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			// A torn final line is a change that was never acknowledged.
+			// It is cut off so the next change starts on a line of its own.
+			if !scanner.Scan() && !bytes.HasSuffix(data, []byte("\n")) {
+				if err := os.Truncate(fs.relationsLogFile, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+					return nil, 0, fmt.Errorf("failed to repair relation log: %w", err)
+				}
+				break
+			}
+			return nil, 0, fmt.Errorf("failed to parse relation log line %d: %w", line, err)
+		}
+		applyRelationOp(ix, op)
+		ops++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read relation log: %w", err)
+	}
+	return ix, ops, nil
+}
+
+func applyRelationOp(ix *relationIndex, op relationOp) {
+	switch op.Op {
+	case relationPut:
+		if op.Relation != nil {
+			ix.put(*op.Relation)
+		}
+	case relationDelete:
+		ix.remove(op.ID)
+	}
+}
+
+// readRelations returns the relation index, loading it if needed, with the
+// relation read lock held. The caller must release it.
+func (fs *FileStore) readRelations() (*relationIndex, error) {
+	for {
+		fs.relationMutex.RLock()
+		if fs.relations != nil {
+			return fs.relations, nil
+		}
+		fs.relationMutex.RUnlock()
+
+		if _, err := fs.lockRelations(); err != nil {
+			return nil, err
+		}
+		fs.relationMutex.Unlock()
+	}
+}
+
+// lockRelations returns the relation index, loading it if needed, with the
+// relation write lock held. The caller must release it.
+func (fs *FileStore) lockRelations() (*relationIndex, error) {
+	fs.relationMutex.Lock()
+	if fs.relations == nil {
+		ix, ops, err := fs.loadRelationIndex()
+		if err != nil {
+			fs.relationMutex.Unlock()
+			return nil, err
+		}
+		fs.relations, fs.relationLogOps = ix, ops
+	}
+	return fs.relations, nil
+}
@@ -0,0 +1,221 @@
+package filestore
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// relationIndex holds relations in insertion order with adjacency indexes by
+// source, target and type, so the relations of one entity are found in time
+// proportional to its degree. It is not safe for concurrent use.
+//
+// Relations are stored and returned by value; their Properties maps are
+// shared and must not be modified in place.
+type relationIndex struct {
+	entries  []*models.Relation // insertion order; nil after removal
+	position map[string]int     // relation ID -> position in entries
+	removed  int                // nil entries awaiting compaction
+
+	byFrom map[string]map[string]struct{} // entity name -> relation IDs
+	byTo   map[string]map[string]struct{} // entity name -> relation IDs
+	byType map[string]map[string]struct{} // normalized relation type -> relation IDs
+}
+
+// minIndexCompaction is the number of removed entries below which the entry
+// list is never compacted
+const minIndexCompaction = 1024
+
+func newRelationIndex(relations []models.Relation) *relationIndex {
+	ix := &relationIndex{
+		entries:  make([]*models.Relation, 0, len(relations)),
+		position: make(map[string]int, len(relations)),
+		byFrom:   make(map[string]map[string]struct{}),
+		byTo:     make(map[string]map[string]struct{}),
+		byType:   make(map[string]map[string]struct{}),
+	}
+	for _, rel := range relations {
+		ix.put(rel)
+	}
+	return ix
+}
+
+// len returns the number of relations
+func (ix *relationIndex) len() int {
+	return len(ix.position)
+}
+
+// get returns the relation with the given ID
+func (ix *relationIndex) get(id string) (models.Relation, bool) {
+	i, ok := ix.position[id]
+	if !ok {
+		return models.Relation{}, false
+	}
+	return *ix.entries[i], true
+}
+
+// put adds a relation, or replaces the relation with the same ID in place
+func (ix *relationIndex) put(rel models.Relation) {
+	rel.Properties = maps.Clone(rel.Properties)
+	rel.Inferred, rel.InferredFrom = false, ""
+
+	if i, ok := ix.position[rel.ID]; ok {
+		ix.unlink(ix.entries[i])
+		ix.entries[i] = &rel
+	} else {
+		ix.position[rel.ID] = len(ix.entries)
+		ix.entries = append(ix.entries, &rel)
+	}
+	ix.link(&rel)
+}
+
+// remove deletes the relation with the given ID
+func (ix *relationIndex) remove(id string) bool {
+	i, ok := ix.position[id]
+	if !ok {
+		return false
+	}
+	ix.unlink(ix.entries[i])
+	ix.entries[i] = nil
+	delete(ix.position, id)
+	ix.removed++
+
+	if ix.removed > minIndexCompaction && ix.removed > len(ix.entries)/2 {
+		ix.compact()
+	}
+	return true
+}
+
+// all returns every relation in insertion order
+func (ix *relationIndex) all() []models.Relation {
+	relations := make([]models.Relation, 0, ix.len())
+	for _, rel := range ix.entries {
+		if rel != nil {
+			relations = append(relations, *rel)
+		}
+	}
+	return relations
+}
+
+// byEntity returns the relations starting or ending at an entity
+func (ix *relationIndex) byEntity(name string) []models.Relation {
+	ids := maps.Clone(ix.byFrom[name])
+	if ids == nil {
+		ids = make(map[string]struct{})
+	}
+	maps.Copy(ids, ix.byTo[name])
+	return ix.collect(ids)
+}
+
+// find returns the relation whose key under keyOf is key, preferring the
+// oldest if duplicates were stored before uniqueness was enforced
+func (ix *relationIndex) find(key models.RelationKey, keyOf func(models.Relation) models.RelationKey) (models.Relation, bool) {
+	best := -1
+	// An undirected key may match a relation stored from either endpoint
+	for _, from := range []string{key.From, key.To} {
+		for id := range ix.byFrom[from] {
+			i := ix.position[id]
+			if keyOf(*ix.entries[i]) == key && (best < 0 || i < best) {
+				best = i
+			}
+		}
+		if key.From == key.To {
+			break
+		}
+	}
+	if best < 0 {
+		return models.Relation{}, false
+	}
+	return *ix.entries[best], true
+}
+
+// query returns the relations matching a filter, starting from the smallest
+// of the adjacency sets the filter selects
+func (ix *relationIndex) query(filter storage.RelationFilter) []models.Relation {
+	var candidates map[string]struct{}
+	narrowed := false
+	narrow := func(ids map[string]struct{}) {
+		if !narrowed || len(ids) < len(candidates) {
+			candidates, narrowed = ids, true
+		}
+	}
+	if filter.From != "" {
+		narrow(ix.byFrom[filter.From])
+	}
+	if filter.To != "" {
+		narrow(ix.byTo[filter.To])
+	}
+	if filter.RelationType != "" {
+		narrow(ix.byType[textutil.NormalizeName(filter.RelationType)])
+	}
+
+	var relations []models.Relation
+	if !narrowed {
+		relations = ix.all()
+	} else {
+		relations = ix.collect(candidates)
+	}
+	return slices.DeleteFunc(relations, func(rel models.Relation) bool {
+		return !filter.Matches(rel)
+	})
+}
+
+// collect returns the relations with the given IDs in insertion order
+func (ix *relationIndex) collect(ids map[string]struct{}) []models.Relation {
+	positions := make([]int, 0, len(ids))
+	for id := range ids {
+		positions = append(positions, ix.position[id])
+	}
+	slices.Sort(positions)
+
+	relations := make([]models.Relation, len(positions))
+	for i, position := range positions {
+		relations[i] = *ix.entries[position]
+	}
+	return relations
+}
+
+// compact drops removed entries and renumbers positions
+func (ix *relationIndex) compact() {
+	entries := make([]*models.Relation, 0, ix.len())
+	for _, rel := range ix.entries {
+		if rel != nil {
+			ix.position[rel.ID] = len(entries)
+			entries = append(entries, rel)
+		}
+	}
+	ix.entries = entries
+	ix.removed = 0
+}
+
+func (ix *relationIndex) link(rel *models.Relation) {
+	addToSet(ix.byFrom, rel.From, rel.ID)
+	addToSet(ix.byTo, rel.To, rel.ID)
+	addToSet(ix.byType, textutil.NormalizeName(rel.RelationType), rel.ID)
+}
+
+func (ix *relationIndex) unlink(rel *models.Relation) {
+	removeFromSet(ix.byFrom, rel.From, rel.ID)
+	removeFromSet(ix.byTo, rel.To, rel.ID)
+	removeFromSet(ix.byType, textutil.NormalizeName(rel.RelationType), rel.ID)
+}
+
+func addToSet(index map[string]map[string]struct{}, key, id string) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[string]struct{})
+		index[key] = set
+	}
+	set[id] = struct{}{}
+}
+
+func removeFromSet(index map[string]map[string]struct{}, key, id string) {
+	set := index[key]
+	delete(set, id)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,173 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+func TestRelationIndex(t *testing.T) {
+	ix := newRelationIndex([]models.Relation{
+		models.NewRelation("api", "auth", "depends_on"),
+		models.NewRelation("api", "db", "uses"),
+		models.NewRelation("web", "api", "depends_on"),
+	})
+
+	if got := ix.byEntity("api"); len(got) != 3 {
+		t.Errorf("Expected 3 relations of api, got %+v", got)
+	}
+	if got := ix.byEntity("db"); len(got) != 1 || got[0].From != "api" {
+		t.Errorf("Expected db's incoming relation, got %+v", got)
+	}
+
+	got := ix.query(storage.RelationFilter{RelationType: "Depends-On"})
+	if len(got) != 2 || got[0].From != "api" || got[1].From != "web" {
+		t.Errorf("Expected depends_on relations in insertion order, got %+v", got)
+	}
+	if got := ix.query(storage.RelationFilter{From: "nobody", To: "api"}); len(got) != 0 {
+		t.Errorf("Expected no relations from an unknown entity, got %+v", got)
+	}
+
+	uses := ix.query(storage.RelationFilter{RelationType: "uses"})[0]
+	uses.To = "cache"
+	ix.put(uses)
+	if len(ix.byEntity("db")) != 0 || len(ix.byEntity("cache")) != 1 {
+		t.Error("Expected replacing a relation to move it in the adjacency indexes")
+	}
+
+	if !ix.remove(uses.ID) || ix.remove(uses.ID) {
+		t.Error("Expected relation to be removed exactly once")
+	}
+	if ix.len() != 2 || len(ix.all()) != 2 {
+		t.Errorf("Expected 2 relations left, got %d", ix.len())
+	}
+	if _, ok := ix.find(models.RelationKey{From: "web", To: "api", RelationType: "dependson"}, models.Relation.Key); !ok {
+		t.Error("Expected relation to be found by key")
+	}
+}
+
+func TestRelationLogPersistence(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	first := models.NewRelation("api", "auth", "depends_on")
+	second := models.NewRelation("api", "db", "uses")
+	for _, rel := range []*models.Relation{&first, &second} {
+		if _, _, err := fs.CreateRelation(ctx, rel); err != nil {
+			t.Fatalf("Failed to create relation: %v", err)
+		}
+	}
+	second.Weight = 0.5
+	if err := fs.UpdateRelation(ctx, &second); err != nil {
+		t.Fatalf("Failed to update relation: %v", err)
+	}
+	if err := fs.DeleteRelation(ctx, first.ID); err != nil {
+		t.Fatalf("Failed to delete relation: %v", err)
+	}
+
+	// Changes are appended to the log, leaving the snapshot untouched
+	snapshot, _ := fs.loadRelationsFile()
+	if len(snapshot.Relations) != 0 {
+		t.Errorf("Expected empty snapshot, got %+v", snapshot.Relations)
+	}
+
+	// A torn final line is ignored when the log is replayed
+	file, err := os.OpenFile(fs.relationsLogFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"op":"put","relation":{"id":`)
+	file.Close()
+
+	reopened := NewFileStore(tempDir)
+	if err := reopened.Initialize(); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	relations, err := reopened.GetRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to replay relation log: %v", err)
+	}
+	if len(relations.Relations) != 1 || relations.Relations[0].ID != second.ID || relations.Relations[0].Weight != 0.5 {
+		t.Errorf("Expected the updated relation to survive, got %+v", relations.Relations)
+	}
+
+	// Changes logged after the torn line are replayed too
+	third := models.NewRelation("web", "api", "calls")
+	if _, _, err := reopened.CreateRelation(ctx, &third); err != nil {
+		t.Fatalf("Failed to create relation: %v", err)
+	}
+	reopened = NewFileStore(tempDir)
+	if err := reopened.Initialize(); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	relations, err = reopened.GetRelations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to replay relation log after a torn line: %v", err)
+	}
+	if len(relations.Relations) != 2 {
+		t.Errorf("Expected 2 relations, got %+v", relations.Relations)
+	}
+
+	if err := reopened.compactRelationLog(reopened.relations); err != nil {
+		t.Fatalf("Failed to compact relation log: %v", err)
+	}
+	if _, err := os.Stat(reopened.relationsLogFile); !os.IsNotExist(err) {
+		t.Error("Expected compaction to remove the log")
+	}
+	snapshot, _ = reopened.loadRelationsFile()
+	if len(snapshot.Relations) != 2 {
+		t.Errorf("Expected compaction to write the snapshot, got %+v", snapshot.Relations)
+	}
+}
+
+// benchmarkRelations is the number of relations the relation benchmarks
+// load, spread over benchmarkEntities entities
+const (
+	benchmarkRelations = 1_000_000
+	benchmarkEntities  = 100_000
+)
+
+func benchmarkRelationSet() []models.Relation {
+	relations := make([]models.Relation, benchmarkRelations)
+	for i := range relations {
+		relations[i] = models.NewRelation(
+			fmt.Sprintf("entity_%d", i%benchmarkEntities),
+			fmt.Sprintf("entity_%d", (i*7+1)%benchmarkEntities),
+			fmt.Sprintf("type_%d", i%10),
+		)
+	}
+	return relations
+}
+
+func BenchmarkRelationIndexNeighbors(b *testing.B) {
+	ix := newRelationIndex(benchmarkRelationSet())
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ix.byEntity(fmt.Sprintf("entity_%d", i%benchmarkEntities))
+	}
+}
+
+func BenchmarkCreateRelation(b *testing.B) {
+	tempDir := b.TempDir()
+	fs := NewFileStore(tempDir)
+	if err := fs.Initialize(); err != nil {
+		b.Fatal(err)
+	}
+	fs.relations = newRelationIndex(benchmarkRelationSet())
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rel := models.NewRelation(fmt.Sprintf("new_%d", i), fmt.Sprintf("entity_%d", i%benchmarkEntities), "depends_on")
+		if _, _, err := fs.CreateRelation(ctx, &rel); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// RenameEntity gives an entity a new name, rewrites every relation that
+// references it and keeps the old name as an alias so existing references
+// still resolve
+func (fs *FileStore) RenameEntity(ctx context.Context, oldName, newName string) (*models.Entity, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	oldName, err := fs.resolveExisting(ctx, "rename", oldName)
+	if err != nil {
+		return nil, err
+	}
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return nil, storage.NewStorageError("rename", "entity", oldName, fmt.Errorf("%w: new entity name is required", storage.ErrInvalidInput))
+	}
+	if newName == oldName {
+		return nil, storage.NewStorageError("rename", "entity", oldName, fmt.Errorf("%w: entity already has that name", storage.ErrInvalidInput))
+	}
+	if owner, err := fs.ResolveEntityName(ctx, newName); err == nil && owner != oldName {
+		return nil, storage.NewStorageError("rename", "entity", newName, storage.ErrAlreadyExists)
+	}
+
+	entity, err := fs.getEntityExact(oldName)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := entity.Clone()
+	renamed.Name = newName
+	renamed.RemoveAlias(newName)
+	renamed.AddAlias(oldName)
+	renamed.LastModified = time.Now()
+
+	if err := fs.replaceEntities(ctx, "rename", renamed, nil, oldName); err != nil {
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// MergeEntities moves the source entity's observations into the target
+// (skipping duplicates), rewrites relations that reference the source to
+// reference the target, deletes the source and keeps its name as an alias
+// of the target
+func (fs *FileStore) MergeEntities(ctx context.Context, sourceName, targetName string) (*models.Entity, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	sourceName, err := fs.resolveExisting(ctx, "merge", sourceName)
+	if err != nil {
+		return nil, err
+	}
+	targetName, err = fs.resolveExisting(ctx, "merge", targetName)
+	if err != nil {
+		return nil, err
+	}
+	if sourceName == targetName {
+		return nil, storage.NewStorageError("merge", "entity", sourceName, fmt.Errorf("%w: cannot merge an entity into itself", storage.ErrInvalidInput))
+	}
+
+	source, err := fs.getEntityExact(sourceName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fs.getEntityExact(targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy so the cached target is untouched if saving fails
+	merged := target.Clone()
+	merged.Merge(source)
+
+	if err := fs.replaceEntities(ctx, "merge", merged, target, sourceName); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// resolveExisting returns the stored name of an entity, reporting unknown
+// names as storage.ErrNotFound
+func (fs *FileStore) resolveExisting(ctx context.Context, op, name string) (string, error) {
+	canonical, err := fs.ResolveEntityName(ctx, name)
+	if err != nil {
+		return "", storage.NewStorageError(op, "entity", name, storage.ErrNotFound)
+	}
+	return canonical, nil
+}
+
+// replaceEntities saves result, rewrites relations from the removed entity
+// names to result's name and then deletes the removed entities. previous is
+// the stored version of result, if any, and is restored if relations cannot
+// be saved. op names the operation in errors.
+func (fs *FileStore) replaceEntities(ctx context.Context, op string, result, previous *models.Entity, removed ...string) error {
+	if err := result.Validate(); err != nil {
+		return storage.NewStorageError(op, "entity", result.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if err := fs.types.ValidateEntity(result); err != nil {
+		return storage.NewStorageError(op, "entity", result.Name, fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+
+	// Only relations of the entities involved can change or collide
+	var affected []models.Relation
+	seen := make(map[string]bool)
+	for _, name := range append([]string{result.Name}, removed...) {
+		relations, err := fs.GetEntityRelations(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, rel := range relations {
+			if !seen[rel.ID] {
+				seen[rel.ID] = true
+				affected = append(affected, rel)
+			}
+		}
+	}
+	rewritten := &models.RelationSet{Relations: slices.Clone(affected)}
+	for _, name := range removed {
+		rewritten.ReplaceEntity(name, result.Name, fs.relationTypes.Key)
+	}
+
+	if err := fs.saveEntityFile(result); err != nil {
+		return fmt.Errorf("failed to save entity: %w", err)
+	}
+
+	if err := fs.rewriteRelations(affected, rewritten.Relations); err != nil {
+		// Roll back so the entity and relations stay consistent
+		if previous != nil {
+			_ = fs.saveEntityFile(previous)
+		} else {
+			_ = os.Remove(fs.getEntityFilePath(result.Name))
+		}
+		return fmt.Errorf("failed to save relations: %w", err)
+	}
+
+	for _, name := range removed {
+		if err := os.Remove(fs.getEntityFilePath(name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete entity file: %w", err)
+		}
+	}
+
+	fs.cacheMutex.Lock()
+	for _, name := range removed {
+		delete(fs.entityCache, name)
+	}
+	fs.entityCache[result.Name] = result
+	fs.nameIndex = nil
+	fs.cacheMutex.Unlock()
+	for _, name := range removed {
+		fs.unindexEntity(name)
+	}
+	fs.indexEntity(result)
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// ResolveEntityName returns the stored name of the entity that name refers
+// to. An exact name match wins; otherwise names and then aliases are compared
+// ignoring case and separators, so "AuthService" resolves to "auth_service".
+func (fs *FileStore) ResolveEntityName(ctx context.Context, name string) (string, error) {
+	if fs.entityFileExists(name) {
+		return name, nil
+	}
+
+	index, err := fs.getNameIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if canonical, ok := index[textutil.NormalizeName(name)]; ok {
+		return canonical, nil
+	}
+	return "", fmt.Errorf("entity '%s' not found", name)
+}
+
+// getNameIndex returns the normalized name index, rebuilding it if an entity
+// was created, updated or deleted since it was last built
+func (fs *FileStore) getNameIndex(ctx context.Context) (map[string]string, error) {
+	fs.cacheMutex.RLock()
+	index := fs.nameIndex
+	fs.cacheMutex.RUnlock()
+	if index != nil {
+		return index, nil
+	}
+
+	entities, err := fs.ListEntities(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort so the index is deterministic when legacy entities collide
+	slices.SortFunc(entities, func(a, b *models.Entity) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	index = make(map[string]string, len(entities))
+	// Names take precedence over aliases
+	for _, entity := range entities {
+		if key := textutil.NormalizeName(entity.Name); key != "" {
+			if _, taken := index[key]; !taken {
+				index[key] = entity.Name
+			}
+		}
+	}
+	for _, entity := range entities {
+		for _, alias := range entity.Aliases {
+			if key := textutil.NormalizeName(alias); key != "" {
+				if _, taken := index[key]; !taken {
+					index[key] = entity.Name
+				}
+			}
+		}
+	}
+
+	fs.cacheMutex.Lock()
+	fs.nameIndex = index
+	fs.cacheMutex.Unlock()
+
+	return index, nil
+}
+
+// checkAliasConflicts rejects aliases that already refer to a different entity
+func (fs *FileStore) checkAliasConflicts(ctx context.Context, entity *models.Entity) error {
+	for _, alias := range entity.Aliases {
+		owner, err := fs.ResolveEntityName(ctx, alias)
+		if err == nil && owner != entity.Name {
+			return storage.NewStorageError("alias", "entity", entity.Name,
+				fmt.Errorf("%w: alias '%s' already refers to entity '%s'", storage.ErrAlreadyExists, alias, owner))
+		}
+	}
+	return nil
+}
+
+// entityFileExists checks for an entity stored under exactly this name
+func (fs *FileStore) entityFileExists(name string) bool {
+	_, err := os.Stat(fs.getEntityFilePath(name))
+	return err == nil
+}
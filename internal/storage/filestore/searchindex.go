@@ -0,0 +1,501 @@
+package filestore
+
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// BM25 parameters: k1 controls how quickly repeated terms stop adding to a
+// score and b how much long observations are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// prefixMatchWeight scales the score of a query term matching the start of
+// a longer term, so "auth" finds "authentication" but ranks it below "auth"
+const prefixMatchWeight = 0.5
+
+// minPrefixLength is the shortest query term that matches longer terms
+const minPrefixLength = 3
+
+// fuzzyMatchWeight scales the score of a query word matching a word one edit
+// away, so "postgress" finds "postgres" but ranks it below exact and prefix
+// matches; two edits score half as much again
+const fuzzyMatchWeight = 0.4
+
+// document identifies an indexed observation by its entity and position
+type document struct {
+	entity   string
+	position int
+}
+
+// searchIndex is an inverted index of observation text ranking matches with
+// BM25. Entities are reindexed as a whole when they change. It is not safe
+// for concurrent use.
+type searchIndex struct {
+	postings    map[string]map[document]int // term -> observation -> term frequency
+	lengths     map[document]int            // observation -> number of terms
+	words       map[string]int              // unstemmed word -> number of entities using it
+	byEntity    map[string]indexedEntity
+	totalLength int
+}
+
+// indexedEntity records what was indexed for an entity, to remove it again
+type indexedEntity struct {
+	observations int
+	terms        map[string]struct{}
+	words        map[string]struct{}
+}
+
+func newSearchIndex(entities []*models.Entity) *searchIndex {
+	ix := &searchIndex{
+		postings: make(map[string]map[document]int),
+		lengths:  make(map[document]int),
+		words:    make(map[string]int),
+		byEntity: make(map[string]indexedEntity),
+	}
+	for _, entity := range entities {
+		ix.put(entity)
+	}
+	return ix
+}
+
+// put indexes an entity's observations, replacing any indexed before
+func (ix *searchIndex) put(entity *models.Entity) {
+	ix.remove(entity.Name)
+	if len(entity.Observations) == 0 {
+		return
+	}
+
+	indexed := indexedEntity{
+		observations: len(entity.Observations),
+		terms:        make(map[string]struct{}),
+		words:        make(map[string]struct{}),
+	}
+	for i, obs := range entity.Observations {
+		doc := document{entity: entity.Name, position: i}
+		words := textutil.Words(obs.Text)
+		for _, word := range words {
+			term := textutil.Stem(word)
+			postings, ok := ix.postings[term]
+			if !ok {
+				postings = make(map[document]int)
+				ix.postings[term] = postings
+			}
+			postings[doc]++
+			indexed.terms[term] = struct{}{}
+			indexed.words[word] = struct{}{}
+		}
+		ix.lengths[doc] = len(words)
+		ix.totalLength += len(words)
+	}
+	for word := range indexed.words {
+		ix.words[word]++
+	}
+	ix.byEntity[entity.Name] = indexed
+}
+
+// remove drops an entity's observations from the index
+func (ix *searchIndex) remove(name string) {
+	indexed, ok := ix.byEntity[name]
+	if !ok {
+		return
+	}
+	delete(ix.byEntity, name)
+
+	for i := 0; i < indexed.observations; i++ {
+		doc := document{entity: name, position: i}
+		ix.totalLength -= ix.lengths[doc]
+		delete(ix.lengths, doc)
+	}
+	for term := range indexed.terms {
+		postings := ix.postings[term]
+		for i := 0; i < indexed.observations; i++ {
+			delete(postings, document{entity: name, position: i})
+		}
+		if len(postings) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	for word := range indexed.words {
+		if ix.words[word]--; ix.words[word] <= 0 {
+			delete(ix.words, word)
+		}
+	}
+}
+
+// search scores the observations containing every word of the query. A
+// query word matches the same term, longer terms it is the start of, and
+// words up to fuzziness edits away (see textutil.MaxEdits). It returns nil
+// if the query has no searchable words, such as only stop words.
+func (ix *searchIndex) search(query string, fuzziness int) map[document]float64 {
+	words := uniqueWords(textutil.Words(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var scores map[document]float64
+	for _, word := range words {
+		termScores := make(map[document]float64)
+		for indexed, weight := range ix.expand(word, fuzziness) {
+			idf := ix.idf(indexed)
+			for doc, freq := range ix.postings[indexed] {
+				if scores != nil {
+					if _, ok := scores[doc]; !ok {
+						continue
+					}
+				}
+				termScores[doc] = max(termScores[doc], weight*idf*ix.saturate(doc, freq))
+			}
+		}
+
+		// Every term must match, so only observations scored so far survive
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for doc := range scores {
+			if score, ok := termScores[doc]; ok {
+				scores[doc] += score
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+	return scores
+}
+
+// expand returns the indexed terms a query word matches with their weights
+func (ix *searchIndex) expand(word string, fuzziness int) map[string]float64 {
+	term := textutil.Stem(word)
+	matches := make(map[string]float64)
+	if edits := textutil.MaxEdits(word, fuzziness); edits > 0 {
+		for indexed := range ix.words {
+			if distance, ok := textutil.WithinEdits(word, indexed, edits); ok && distance > 0 {
+				stem := textutil.Stem(indexed)
+				matches[stem] = max(matches[stem], fuzzyMatchWeight/float64(distance))
+			}
+		}
+	}
+	if len(term) >= minPrefixLength {
+		for indexed := range ix.postings {
+			if indexed != term && strings.HasPrefix(indexed, term) {
+				matches[indexed] = max(matches[indexed], prefixMatchWeight)
+			}
+		}
+	}
+	if _, ok := ix.postings[term]; ok {
+		matches[term] = 1
+	}
+	return matches
+}
+
+// wordMatch weighs how well a query word matches a word by the rules of
+// expand: 1 for the same term, less for a longer term it starts or a word a
+// few edits away, and 0 for no match
+func wordMatch(word, candidate string, fuzziness int) float64 {
+	term, candidateTerm := textutil.Stem(word), textutil.Stem(candidate)
+	switch {
+	case term == candidateTerm:
+		return 1
+	case len(term) >= minPrefixLength && strings.HasPrefix(candidateTerm, term):
+		return prefixMatchWeight
+	}
+	if distance, ok := textutil.WithinEdits(word, candidate, textutil.MaxEdits(word, fuzziness)); ok && distance > 0 {
+		return fuzzyMatchWeight / float64(distance)
+	}
+	return 0
+}
+
+// idf is the inverse document frequency of a term, higher for rarer terms
+func (ix *searchIndex) idf(term string) float64 {
+	n, df := float64(len(ix.lengths)), float64(len(ix.postings[term]))
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// saturate is the BM25 term frequency component for an observation
+func (ix *searchIndex) saturate(doc document, freq int) float64 {
+	avg := float64(ix.totalLength) / float64(len(ix.lengths))
+	length := float64(ix.lengths[doc])
+	f := float64(freq)
+	return f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*length/avg))
+}
+
+// uniqueWords drops words with the same stem as an earlier word
+func uniqueWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	unique := words[:0]
+	for _, word := range words {
+		if term := textutil.Stem(word); !seen[term] {
+			seen[term] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
+
+// SetFuzziness changes how many edits a search word may be from a stored
+// word and still match it, up to textutil.MaxEdits; 0 disables typo
+// tolerance. Searches can override it with ObservationFilter.Fuzziness.
+func (fs *FileStore) SetFuzziness(fuzziness int) {
+	fs.fuzziness = fuzziness
+}
+
+// indexEntity updates the search index after an entity was saved
+func (fs *FileStore) indexEntity(entity *models.Entity) {
+	fs.searchMutex.Lock()
+	defer fs.searchMutex.Unlock()
+	if fs.search != nil {
+		fs.search.put(entity)
+	}
+}
+
+// unindexEntity removes a deleted entity from the search index
+func (fs *FileStore) unindexEntity(name string) {
+	fs.searchMutex.Lock()
+	defer fs.searchMutex.Unlock()
+	if fs.search != nil {
+		fs.search.remove(name)
+	}
+}
+
+// searchQuery returns the observations matching a parsed query and the
+// filter, ranked by score; a nil query matches every observation. Given
+// similar scores, only those observations are candidates and they rank by
+// similarity instead.
+func (fs *FileStore) searchQuery(ctx context.Context, expr search.Expr, similar map[document]float64, filter storage.ObservationFilter) ([]storage.SearchResult, error) {
+	m := &queryMatcher{
+		types:     fs.types,
+		fuzziness: fs.fuzziness,
+		scores:    make(map[*search.Term]map[document]float64),
+	}
+	if filter.Fuzziness != nil {
+		m.fuzziness = *filter.Fuzziness
+	}
+	if expr != nil {
+		if err := fs.scoreTerms(ctx, expr, m.fuzziness, m.scores); err != nil {
+			return nil, err
+		}
+	}
+
+	entityType := ""
+	if filter.EntityType != "" {
+		entityType = fs.types.CanonicalType(filter.EntityType)
+	}
+	var results []storage.SearchResult
+	consider := func(entity *models.Entity, position int) {
+		if entityType != "" && entity.EntityType != entityType || !entity.MatchesAttributes(filter.Attributes) {
+			return
+		}
+		obs := entity.Observations[position]
+		if !filter.Matches(obs) {
+			return
+		}
+		doc := document{entity.Name, position}
+		score := 0.0
+		if expr != nil {
+			var ok bool
+			if ok, score = m.eval(expr, entity, obs, doc); !ok {
+				return
+			}
+		}
+		if similar != nil {
+			score = similar[doc]
+		}
+		results = append(results, storage.SearchResult{
+			EntityName:  entity.Name,
+			EntityType:  entity.EntityType,
+			Observation: obs,
+			Score:       score,
+		})
+	}
+
+	candidates, bounded := m.candidates(expr)
+	if similar != nil {
+		candidates, bounded = similar, true
+	}
+	if bounded {
+		// Only observations containing the query's words can match
+		docs := slices.Collect(maps.Keys(candidates))
+		slices.SortFunc(docs, func(a, b document) int {
+			if c := strings.Compare(a.entity, b.entity); c != 0 {
+				return c
+			}
+			return a.position - b.position
+		})
+		for _, doc := range docs {
+			entity, err := fs.getEntityExact(doc.entity)
+			if err == nil && doc.position < len(entity.Observations) {
+				consider(entity, doc.position)
+			}
+		}
+	} else {
+		entities, err := fs.ListEntities(ctx, filter.EntityType)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range entities {
+			for i := range entity.Observations {
+				consider(entity, i)
+			}
+		}
+	}
+
+	storage.SortSearchResults(results)
+	return results, nil
+}
+
+// scoreTerms looks up the observations each word or phrase of a query
+// matches, building the search index on first use
+func (fs *FileStore) scoreTerms(ctx context.Context, expr search.Expr, fuzziness int, scores map[*search.Term]map[document]float64) error {
+	fs.searchMutex.Lock()
+	defer fs.searchMutex.Unlock()
+	if fs.search == nil {
+		entities, err := fs.ListEntities(ctx, "")
+		if err != nil {
+			return err
+		}
+		fs.search = newSearchIndex(entities)
+	}
+
+	var walk func(expr search.Expr)
+	walk = func(expr search.Expr) {
+		switch e := expr.(type) {
+		case *search.Term:
+			scores[e] = fs.search.search(e.Text, fuzziness)
+		case *search.And:
+			walk(e.Left)
+			walk(e.Right)
+		case *search.Or:
+			walk(e.Left)
+			walk(e.Right)
+		case *search.Not:
+			walk(e.Expr)
+		}
+	}
+	walk(expr)
+	return nil
+}
+
+// queryMatcher evaluates a parsed search query against observations
+type queryMatcher struct {
+	types     *schema.Registry
+	fuzziness int
+
+	// scores holds the observations each word or phrase matches in the
+	// index; nil for those without searchable words, such as stop words,
+	// which are matched against the text instead
+	scores map[*search.Term]map[document]float64
+}
+
+// candidates returns the observations a query can match, or false if the
+// index cannot narrow them down
+func (m *queryMatcher) candidates(expr search.Expr) (map[document]float64, bool) {
+	switch e := expr.(type) {
+	case *search.Term:
+		scores := m.scores[e]
+		return scores, scores != nil
+	case *search.And:
+		left, leftOK := m.candidates(e.Left)
+		right, rightOK := m.candidates(e.Right)
+		switch {
+		case leftOK && rightOK:
+			both := make(map[document]float64)
+			for doc := range left {
+				if _, ok := right[doc]; ok {
+					both[doc] = 0
+				}
+			}
+			return both, true
+		case leftOK:
+			return left, true
+		}
+		return right, rightOK
+	case *search.Or:
+		left, leftOK := m.candidates(e.Left)
+		right, rightOK := m.candidates(e.Right)
+		if !leftOK || !rightOK {
+			return nil, false
+		}
+		either := maps.Clone(left)
+		maps.Copy(either, right)
+		return either, true
+	}
+	return nil, false
+}
+
+// eval reports whether an observation matches a query and its score, the
+// sum of the BM25 scores of the words and phrases it matched
+func (m *queryMatcher) eval(expr search.Expr, entity *models.Entity, obs models.Observation, doc document) (bool, float64) {
+	switch e := expr.(type) {
+	case *search.Term:
+		scores := m.scores[e]
+		if scores == nil {
+			if e.Phrase {
+				return search.ContainsPhrase(obs.Text, e.Text), 0
+			}
+			return strings.Contains(textutil.Fold(obs.Text), textutil.Fold(e.Text)), 0
+		}
+		score, ok := scores[doc]
+		if ok && e.Phrase {
+			ok = search.ContainsPhrase(obs.Text, e.Text)
+		}
+		return ok, score
+	case *search.Field:
+		return m.matchField(e, entity, obs), 0
+	case *search.And:
+		left, leftScore := m.eval(e.Left, entity, obs, doc)
+		if !left {
+			return false, 0
+		}
+		right, rightScore := m.eval(e.Right, entity, obs, doc)
+		return right, leftScore + rightScore
+	case *search.Or:
+		left, leftScore := m.eval(e.Left, entity, obs, doc)
+		right, rightScore := m.eval(e.Right, entity, obs, doc)
+		return left || right, leftScore + rightScore
+	case *search.Not:
+		matched, _ := m.eval(e.Expr, entity, obs, doc)
+		return !matched, 0
+	}
+	return false, 0
+}
+
+func (m *queryMatcher) matchField(f *search.Field, entity *models.Entity, obs models.Observation) bool {
+	switch f.Name {
+	case search.FieldType:
+		return f.MatchesText(entity.EntityType) || m.types.CanonicalType(f.Value) == entity.EntityType
+	case search.FieldEntity:
+		return slices.ContainsFunc(entity.Names(), func(name string) bool {
+			return f.MatchesText(name) || nearName(f.Value, name, m.fuzziness)
+		})
+	case search.FieldSource:
+		return f.MatchesText(obs.Source) || obs.Provenance != nil && f.MatchesText(obs.Provenance.Tool)
+	case search.FieldTag:
+		return slices.ContainsFunc(obs.Tags, f.MatchesText)
+	case search.FieldCreated:
+		return f.MatchesTime(obs.CreatedAt)
+	}
+	return false
+}
+
+// nearName reports whether an entity name is within a typo or two of a name
+// without wildcards
+func nearName(value, name string, fuzziness int) bool {
+	if strings.Contains(value, "*") {
+		return false
+	}
+	value = textutil.NormalizeName(textutil.Fold(value))
+	_, ok := textutil.WithinEdits(value, textutil.NormalizeName(textutil.Fold(name)), textutil.MaxEdits(value, fuzziness))
+	return ok
+}
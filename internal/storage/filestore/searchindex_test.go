@@ -0,0 +1,237 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+func TestSearchIndex(t *testing.T) {
+	api := models.NewEntity("api", "component")
+	api.AddObservation("The API authenticates requests with JWT tokens")
+	api.AddObservation("Authentication tokens expire after one hour")
+	gateway := models.NewEntity("gateway", "component")
+	gateway.AddObservation("The gateway checks auth tokens first")
+	db := models.NewEntity("db", "component")
+	db.AddObservation("Postgres stores the user accounts")
+
+	ix := newSearchIndex([]*models.Entity{api, gateway, db})
+
+	scores := ix.search("Auth token", 0)
+	if len(scores) != 3 {
+		t.Fatalf("Expected all token observations to match, got %v", scores)
+	}
+	// "auth" is the start of "authentic", the stem of "authenticates"
+	if scores[document{"gateway", 0}] <= scores[document{"api", 1}] {
+		t.Errorf("Expected the exact match to score higher than prefix matches, got %v", scores)
+	}
+
+	if got := ix.search("postgres tokens", 0); len(got) != 0 {
+		t.Errorf("Expected observations to need every term, got %v", got)
+	}
+	if got := ix.search("the of", 0); got != nil {
+		t.Errorf("Expected nil for a query of stop words, got %v", got)
+	}
+
+	api.Observations = api.Observations[:1]
+	ix.put(api)
+	if got := ix.search("hour", 0); len(got) != 0 {
+		t.Errorf("Expected reindexing to drop removed observations, got %v", got)
+	}
+	ix.remove("db")
+	if got := ix.search("postgres", 0); len(got) != 0 || len(ix.lengths) != 2 || len(ix.postings["postgr"]) != 0 {
+		t.Errorf("Expected removing an entity to drop its terms, got %v", got)
+	}
+}
+
+func TestSearchObservationsBM25(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	deploy := models.NewEntity("deploy", "process")
+	deploy.AddObservation("Every release is deployed through the staging pipeline")
+	deploy.AddObservation("The pipeline deploys to staging on every merge and deploys to production weekly")
+	if err := fs.CreateEntity(ctx, deploy); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+
+	// Search once so later writes update the built index
+	results, err := fs.SearchObservations(ctx, "deploying staging", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 2 || results[0].Score <= results[1].Score || results[1].Score <= 0 {
+		t.Fatalf("Expected 2 results ranked by score, got %+v", results)
+	}
+
+	cafe := models.NewEntity("cafe", "place")
+	cafe.AddObservation("The Café serves crème brûlée")
+	if err := fs.CreateEntity(ctx, cafe); err != nil {
+		t.Fatalf("Failed to create entity: %v", err)
+	}
+	results, err = fs.SearchObservations(ctx, "creme brulee cafe", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 || results[0].EntityName != "cafe" {
+		t.Errorf("Expected folded search to find the new entity, got %+v", results)
+	}
+
+	if err := fs.DeleteEntity(ctx, "cafe"); err != nil {
+		t.Fatalf("Failed to delete entity: %v", err)
+	}
+	if results, _ := fs.SearchObservations(ctx, "cafe", ""); len(results) != 0 {
+		t.Errorf("Expected deleted entity to be unindexed, got %+v", results)
+	}
+
+	if _, err := fs.RenameEntity(ctx, "deploy", "release"); err != nil {
+		t.Fatalf("Failed to rename entity: %v", err)
+	}
+	results, err = fs.SearchObservationsFiltered(ctx, "pipeline", storage.ObservationFilter{EntityType: "process"})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 2 || results[0].EntityName != "release" {
+		t.Errorf("Expected renamed entity to be reindexed, got %+v", results)
+	}
+
+	// A query of stop words falls back to matching the text
+	results, err = fs.SearchObservations(ctx, "is", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 || results[0].Score != 0 {
+		t.Errorf("Expected a substring match without score, got %+v", results)
+	}
+}
+
+func TestSearchObservationsQuerySyntax(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	auth := models.NewEntity("auth-service", "component")
+	auth.AddAlias("login")
+	auth.AddObservationWithSource("Requests are limited by the rate limit middleware", "copilot")
+	auth.AddObservation("The limit on rate changes is set per tenant")
+	old := models.NewObservation("Tokens are signed with RS256")
+	old.CreatedAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	old.Tags = []string{"security"}
+	auth.Observations = append(auth.Observations, old)
+	style := models.NewEntity("style", "guideline")
+	style.AddObservation("Rate limit errors return 429")
+	for _, entity := range []*models.Entity{auth, style} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	tests := map[string]int{
+		`"rate limit"`:                      2,
+		"rate limit":                        3,
+		`"rate limit" -type:guideline`:      1,
+		"rate NOT middleware":               2,
+		"tenant OR tokens":                  2,
+		"entity:log*":                       3,
+		"source:copilot":                    1,
+		"tag:Security created:<2026-01-01":  1,
+		"(rs256 OR 429) created:2025-06-01": 1,
+		"NOT (rate OR tokens)":              0,
+	}
+
+	for query, want := range tests {
+		results, err := fs.SearchObservations(ctx, query, "")
+		if err != nil {
+			t.Errorf("Failed to search %q: %v", query, err)
+			continue
+		}
+		if len(results) != want {
+			t.Errorf("Search %q returned %d results, want %d: %+v", query, len(results), want, results)
+		}
+	}
+
+	_, err := fs.SearchObservations(ctx, "rate OR", "")
+	var syntaxErr *search.SyntaxError
+	if !errors.As(err, &syntaxErr) || !errors.Is(err, storage.ErrInvalidInput) {
+		t.Errorf("Expected a syntax error for invalid input, got %v", err)
+	}
+}
+
+func TestSearchObservationsFuzzy(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	db := models.NewEntity("postgres-db", "database")
+	db.AddObservation("Postgres stores the user accounts")
+	db.AddObservation("Backups of postgress run nightly")
+	auth := models.NewEntity("auth-service", "component")
+	auth.AddObservation("Authentication uses short-lived tokens")
+	for _, entity := range []*models.Entity{db, auth} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	results, err := fs.SearchObservations(ctx, "postgress", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 2 || results[0].Observation.Text != "Backups of postgress run nightly" || results[0].Score <= results[1].Score {
+		t.Errorf("Expected the exact match to rank above the fuzzy one, got %+v", results)
+	}
+
+	results, err = fs.SearchObservations(ctx, "authentcation entity:postgre-db", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected the entity field to restrict fuzzy matches, got %+v", results)
+	}
+	results, err = fs.SearchObservations(ctx, "authentcation entity:auth-servce", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected typos in words and entity names to match, got %+v", results)
+	}
+
+	exact := 0
+	results, err = fs.SearchObservationsFiltered(ctx, "authentcation", storage.ObservationFilter{Fuzziness: &exact})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected fuzziness 0 to disable typo tolerance, got %+v", results)
+	}
+
+	fs.SetFuzziness(0)
+	suggestions, err := fs.SuggestSearch(ctx, `authentcation -"user acounts"`, storage.ObservationFilter{})
+	if err != nil {
+		t.Fatalf("Failed to suggest: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0] != `authentication -"user accounts"` {
+		t.Errorf("Expected a corrected query, got %v", suggestions)
+	}
+
+	suggestions, err = fs.SuggestSearch(ctx, "tokens entity:auth-srvc", storage.ObservationFilter{})
+	if err != nil {
+		t.Fatalf("Failed to suggest: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0] != "tokens entity:auth-service" {
+		t.Errorf("Expected a corrected entity name, got %v", suggestions)
+	}
+
+	if suggestions, _ := fs.SuggestSearch(ctx, "kubernetes", storage.ObservationFilter{}); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions without a close word, got %v", suggestions)
+	}
+}
@@ -0,0 +1,200 @@
+package filestore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// maxSuggestions caps the corrected queries SuggestSearch returns
+const maxSuggestions = 3
+
+// suggestion is a known word or name close to a misspelled one
+type suggestion struct {
+	text     string
+	distance int
+	uses     int // entities using the word, to prefer common words
+}
+
+// SuggestSearch returns corrections of a search query that find results,
+// best first, for "did you mean" hints when the query finds nothing. Words
+// not in any observation are replaced by the closest indexed words and
+// entity: values naming no entity by the closest entity names, allowing one
+// edit more than matching does.
+func (fs *FileStore) SuggestSearch(ctx context.Context, query string, filter storage.ObservationFilter) ([]string, error) {
+	if _, err := search.Parse(query); err != nil {
+		return nil, storage.NewStorageError("search", "observation", "", fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	entities, err := fs.ListEntities(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entity := range entities {
+		names = append(names, entity.Names()...)
+	}
+
+	words := make(map[string][]suggestion)
+	values := make(map[string][]suggestion)
+	fs.searchMutex.Lock()
+	if fs.search == nil {
+		fs.search = newSearchIndex(entities)
+	}
+	_, err = search.Rewrite(query, func(field, text string) string {
+		switch field {
+		case "":
+			for _, word := range textutil.Words(text) {
+				if _, seen := words[word]; !seen {
+					words[word] = fs.search.suggest(word)
+				}
+			}
+		case search.FieldEntity:
+			if _, seen := values[text]; !seen && !strings.Contains(text, "*") {
+				values[text] = suggestNames(text, names)
+			}
+		}
+		return text
+	})
+	fs.searchMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The best correction of every word first, then the runners-up of one
+	// word at a time
+	best := make(map[string]string)
+	for _, candidates := range []map[string][]suggestion{words, values} {
+		for text, suggestions := range candidates {
+			if len(suggestions) > 0 {
+				best[text] = suggestions[0].text
+			}
+		}
+	}
+	if len(best) == 0 {
+		return nil, nil
+	}
+	variants := []map[string]string{best}
+	for _, candidates := range []map[string][]suggestion{words, values} {
+		for _, text := range slices.Sorted(maps.Keys(candidates)) {
+			for _, alternative := range candidates[text][min(1, len(candidates[text])):] {
+				variant := maps.Clone(best)
+				variant[text] = alternative.text
+				variants = append(variants, variant)
+			}
+		}
+	}
+
+	var suggestions []string
+	for _, variant := range variants {
+		corrected, err := search.Rewrite(query, func(field, text string) string {
+			if field == search.FieldEntity {
+				if name, ok := variant[text]; ok {
+					return name
+				}
+				return text
+			}
+			if field != "" {
+				return text
+			}
+			return correctWords(text, variant)
+		})
+		if err != nil || corrected == query || slices.Contains(suggestions, corrected) {
+			continue
+		}
+		results, err := fs.SearchObservationsFiltered(ctx, corrected, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 {
+			suggestions = append(suggestions, corrected)
+			if len(suggestions) == maxSuggestions {
+				break
+			}
+		}
+	}
+	return suggestions, nil
+}
+
+// suggest returns the indexed words closest to a word that matches nothing,
+// or nil if the word matches
+func (ix *searchIndex) suggest(word string) []suggestion {
+	if len(ix.expand(word, 0)) > 0 {
+		return nil
+	}
+	edits := textutil.MaxEdits(word, textutil.DefaultFuzziness) + 1
+	var suggestions []suggestion
+	for indexed, uses := range ix.words {
+		if distance, ok := textutil.WithinEdits(word, indexed, edits); ok {
+			suggestions = append(suggestions, suggestion{text: indexed, distance: distance, uses: uses})
+		}
+	}
+	return closest(suggestions)
+}
+
+// suggestNames returns the entity names closest to an entity: value that
+// names no entity
+func suggestNames(value string, names []string) []suggestion {
+	key := textutil.NormalizeName(textutil.Fold(value))
+	edits := textutil.MaxEdits(key, textutil.DefaultFuzziness) + 1
+	var suggestions []suggestion
+	for _, name := range names {
+		distance, ok := textutil.WithinEdits(key, textutil.NormalizeName(textutil.Fold(name)), edits)
+		if ok && distance == 0 {
+			return nil
+		}
+		if ok {
+			suggestions = append(suggestions, suggestion{text: name, distance: distance})
+		}
+	}
+	return closest(suggestions)
+}
+
+// closest sorts suggestions by distance, then by use, and keeps the best
+func closest(suggestions []suggestion) []suggestion {
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(
+			cmp.Compare(a.distance, b.distance),
+			cmp.Compare(b.uses, a.uses),
+			strings.Compare(a.text, b.text),
+		)
+	})
+	return suggestions[:min(len(suggestions), maxSuggestions)]
+}
+
+// correctWords replaces the words of text that have corrections, leaving
+// the rest of the text as it was
+func correctWords(text string, corrections map[string]string) string {
+	var corrected strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		run := text[start:end]
+		if words := textutil.Words(run); len(words) == 1 && corrections[words[0]] != "" {
+			run = corrections[words[0]]
+		}
+		corrected.WriteString(run)
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		corrected.WriteRune(r)
+	}
+	flush(len(text))
+	return corrected.String()
+}
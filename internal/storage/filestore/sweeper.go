@@ -0,0 +1,105 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+)
+
+// PurgeExpiredObservations physically removes observations whose expiry
+// passed more than grace ago. Expired observations are already hidden from
+// recall and search; this only reclaims them from disk.
+func (fs *FileStore) PurgeExpiredObservations(ctx context.Context, grace time.Duration) (int, error) {
+	entities, err := fs.ListEntities(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-grace)
+	purged := 0
+	for _, entity := range entities {
+		if !slices.ContainsFunc(entity.Observations, func(obs models.Observation) bool {
+			return obs.IsExpired(cutoff)
+		}) {
+			continue
+		}
+		removed, err := fs.purgeExpired(ctx, entity.Name, cutoff)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge expired observations from '%s': %w", entity.Name, err)
+		}
+		purged += removed
+	}
+
+	return purged, nil
+}
+
+// purgeExpired removes the observations of one entity that expired before
+// cutoff. The entity is read again under structureMutex, which UpdateEntity
+// also holds, so observations added since the sweep listed it are kept.
+func (fs *FileStore) purgeExpired(ctx context.Context, name string, cutoff time.Time) (int, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	if !fs.entityFileExists(name) {
+		return 0, nil // Deleted or renamed since it was listed
+	}
+	entity, err := fs.getEntityExact(name)
+	if err != nil {
+		return 0, err
+	}
+
+	// Work on a copy so readers holding the cached entity are not affected
+	kept := entity.FilterObservations(func(obs models.Observation) bool {
+		return !obs.IsExpired(cutoff)
+	})
+	removed := entity.GetObservationCount() - kept.GetObservationCount()
+	if removed == 0 {
+		return 0, nil
+	}
+
+	kept.PruneSupersessionLinks()
+	kept.LastModified = time.Now()
+	if err := fs.updateEntity(ctx, kept); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// StartExpirySweeper periodically purges expired observations and trash
+// entries past their retention window until the context is cancelled
+func (fs *FileStore) StartExpirySweeper(ctx context.Context, interval, grace time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := fs.PurgeExpiredObservations(ctx, grace)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[FileStore] Expiry sweep failed: %v\n", err)
+					continue
+				}
+				if purged > 0 {
+					fmt.Fprintf(os.Stderr, "[FileStore] Expiry sweep removed %d observations\n", purged)
+				}
+
+				// Deleted entities past their retention window go too
+				trashed, err := fs.PurgeTrash(ctx)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[FileStore] Trash sweep failed: %v\n", err)
+					continue
+				}
+				if trashed > 0 {
+					fmt.Fprintf(os.Stderr, "[FileStore] Trash sweep removed %d entities\n", trashed)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,238 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// DefaultTrashRetention is how long deleted entities can be restored
+const DefaultTrashRetention = 7 * 24 * time.Hour
+
+// SetTrashRetention changes how long deleted entities are kept in the trash
+func (fs *FileStore) SetTrashRetention(retention time.Duration) {
+	fs.trashRetention = retention
+}
+
+// DeleteEntityWithPolicy moves an entity to the trash. Relations that
+// reference it are rejected, deleted with it or left in place according to
+// policy.
+func (fs *FileStore) DeleteEntityWithPolicy(ctx context.Context, name string, policy storage.DeletePolicy) error {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	canonical, err := fs.ResolveEntityName(ctx, name)
+	if err != nil {
+		return storage.NewStorageError("delete", "entity", name, storage.ErrNotFound)
+	}
+
+	entity, err := fs.getEntityExact(canonical)
+	if err != nil {
+		return err
+	}
+
+	referencing, err := fs.GetEntityRelations(ctx, canonical)
+	if err != nil {
+		return err
+	}
+
+	entry := storage.TrashEntry{
+		ID:        fmt.Sprintf("%s.%d", canonical, time.Now().UnixNano()),
+		Entity:    entity,
+		Policy:    policy,
+		DeletedAt: time.Now(),
+		ExpiresAt: time.Now().Add(fs.trashRetention),
+	}
+
+	switch policy {
+	case storage.DeleteReject:
+		if len(referencing) > 0 {
+			return storage.NewStorageError("delete", "entity", canonical,
+				fmt.Errorf("%w (%d relations; delete with the cascade or detach policy)", storage.ErrReferenced, len(referencing)))
+		}
+	case storage.DeleteCascade:
+		entry.Relations = referencing
+	case storage.DeleteDetach:
+	default:
+		return storage.NewStorageError("delete", "entity", canonical, storage.ErrInvalidInput)
+	}
+
+	// Write the trash entry first so a failure never loses the entity
+	if err := fs.saveTrashEntry(entry); err != nil {
+		return fmt.Errorf("failed to move entity to trash: %w", err)
+	}
+
+	if policy == storage.DeleteCascade {
+		if err := fs.removeRelations(referencing); err != nil {
+			_ = os.Remove(fs.getTrashFilePath(entry.ID))
+			return fmt.Errorf("failed to delete relations: %w", err)
+		}
+	}
+
+	if err := os.Remove(fs.getEntityFilePath(canonical)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete entity file: %w", err)
+	}
+
+	fs.cacheMutex.Lock()
+	delete(fs.entityCache, canonical)
+	fs.nameIndex = nil
+	fs.cacheMutex.Unlock()
+	fs.unindexEntity(canonical)
+
+	return nil
+}
+
+// ListTrash returns the deleted entities that can still be restored, newest first
+func (fs *FileStore) ListTrash(ctx context.Context) ([]storage.TrashEntry, error) {
+	entries, err := fs.loadTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries = slices.DeleteFunc(entries, func(entry storage.TrashEntry) bool {
+		return now.After(entry.ExpiresAt)
+	})
+	return entries, nil
+}
+
+// RestoreEntity restores the most recently deleted entity whose name or
+// alias matches name, together with relations deleted by a cascade whose
+// other end still exists
+func (fs *FileStore) RestoreEntity(ctx context.Context, name string) (*models.Entity, error) {
+	fs.structureMutex.Lock()
+	defer fs.structureMutex.Unlock()
+
+	entries, err := fs.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(entries, func(entry storage.TrashEntry) bool {
+		return entry.Entity.MatchesName(name)
+	})
+	if index < 0 {
+		return nil, storage.NewStorageError("restore", "entity", name, storage.ErrNotFound)
+	}
+	entry := entries[index]
+	entity := entry.Entity
+
+	if fs.EntityExists(entity.Name) {
+		return nil, storage.NewStorageError("restore", "entity", entity.Name, storage.ErrAlreadyExists)
+	}
+	if err := fs.checkAliasConflicts(ctx, entity); err != nil {
+		return nil, err
+	}
+
+	if err := fs.saveEntityFile(entity); err != nil {
+		return nil, fmt.Errorf("failed to restore entity: %w", err)
+	}
+
+	if len(entry.Relations) > 0 {
+		var restored []models.Relation
+		for _, rel := range entry.Relations {
+			other := rel.To
+			if other == entity.Name {
+				other = rel.From
+			}
+			if other == entity.Name || fs.entityFileExists(other) {
+				restored = append(restored, rel)
+			}
+		}
+
+		if err := fs.addRelations(restored); err != nil {
+			return nil, fmt.Errorf("failed to restore relations: %w", err)
+		}
+	}
+
+	if err := os.Remove(fs.getTrashFilePath(entry.ID)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove trash entry: %w", err)
+	}
+
+	fs.cacheMutex.Lock()
+	fs.entityCache[entity.Name] = entity
+	fs.nameIndex = nil
+	fs.cacheMutex.Unlock()
+	fs.indexEntity(entity)
+
+	return entity, nil
+}
+
+// PurgeTrash permanently removes trash entries past their retention window
+func (fs *FileStore) PurgeTrash(ctx context.Context) (int, error) {
+	entries, err := fs.loadTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	purged := 0
+	for _, entry := range entries {
+		if now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if err := os.Remove(fs.getTrashFilePath(entry.ID)); err != nil && !os.IsNotExist(err) {
+			return purged, fmt.Errorf("failed to purge trash entry '%s': %w", entry.ID, err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (fs *FileStore) getTrashFilePath(id string) string {
+	return filepath.Join(fs.trashDir, id+".json")
+}
+
+func (fs *FileStore) saveTrashEntry(entry storage.TrashEntry) error {
+	if err := os.MkdirAll(fs.trashDir, 0750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trash entry: %w", err)
+	}
+	return os.WriteFile(fs.getTrashFilePath(entry.ID), data, 0644)
+}
+
+// loadTrash reads every trash entry, newest first
+func (fs *FileStore) loadTrash() ([]storage.TrashEntry, error) {
+	files, err := os.ReadDir(fs.trashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	var entries []storage.TrashEntry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(fs.trashDir, file.Name()))
+		if err != nil {
+			continue // Skip unreadable entries
+		}
+
+		var entry storage.TrashEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Entity == nil {
+			continue // Skip invalid entries
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b storage.TrashEntry) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return entries, nil
+}
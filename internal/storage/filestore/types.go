@@ -0,0 +1,26 @@
+package filestore
+
+import (
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+)
+
+// TypeRegistry returns the registered entity types
+func (fs *FileStore) TypeRegistry() *schema.Registry {
+	return fs.types
+}
+
+// SetTypeRegistry replaces the entity type registry loaded from types.json
+func (fs *FileStore) SetTypeRegistry(registry *schema.Registry) {
+	fs.types = registry
+}
+
+// RelationTypeRegistry returns the registered relation types
+func (fs *FileStore) RelationTypeRegistry() *schema.RelationRegistry {
+	return fs.relationTypes
+}
+
+// SetRelationTypeRegistry replaces the relation type registry loaded from
+// relation_types.json
+func (fs *FileStore) SetRelationTypeRegistry(registry *schema.RelationRegistry) {
+	fs.relationTypes = registry
+}
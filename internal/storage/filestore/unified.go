@@ -0,0 +1,333 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
+)
+
+// Weights of the fields entity and relation hits match in: a name says
+// most about what an entity is
+const (
+	nameWeight         = 3
+	aliasWeight        = 2
+	entityTypeWeight   = 1
+	relationTypeWeight = 2
+	endpointWeight     = 1
+)
+
+// hitField is a piece of text an entity or relation hit can match in
+type hitField struct {
+	name   string
+	value  string
+	weight float64
+}
+
+// SearchAll searches entity names, aliases and types, relation types and
+// endpoints, and observations, returning entity hits first, then relation
+// and observation hits, each ranked by score. Observations are searched by
+// SearchObservationsFiltered. Entities and relations match the query's words
+// in their fields, its entity: and type: fields, created: and, for
+// relations, source:; tag: matches none. The filter's entity type and
+// attributes restrict entity hits too, and observation criteria such as tags
+// leave only observation hits.
+func (fs *FileStore) SearchAll(ctx context.Context, query string, kinds []string, filter storage.ObservationFilter) ([]storage.SearchHit, error) {
+	kinds, err := storage.ParseHitKinds(kinds)
+	if err != nil {
+		return nil, storage.NewStorageError("search", "all", "", fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, storage.NewStorageError("search", "all", "", fmt.Errorf("%w: query is required", storage.ErrInvalidInput))
+	}
+	expr, err := search.Parse(query)
+	if err != nil {
+		return nil, storage.NewStorageError("search", "all", "", fmt.Errorf("%w: %w", storage.ErrInvalidInput, err))
+	}
+
+	fuzziness := fs.fuzziness
+	if filter.Fuzziness != nil {
+		fuzziness = *filter.Fuzziness
+	}
+	structural := !filter.HasObservationCriteria() && !filter.IncludeExpired && !filter.IncludeSuperseded
+
+	var hits []storage.SearchHit
+	if slices.Contains(kinds, storage.HitEntity) && structural {
+		entityHits, err := fs.searchEntities(ctx, expr, fuzziness, filter)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, entityHits...)
+	}
+	if slices.Contains(kinds, storage.HitRelation) && structural {
+		relationHits, err := fs.searchRelations(ctx, expr, fuzziness)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, relationHits...)
+	}
+	if slices.Contains(kinds, storage.HitObservation) {
+		results, err := fs.SearchObservationsFiltered(ctx, query, filter)
+		if err != nil {
+			return nil, err
+		}
+		words, _ := search.Split(expr)
+		queryWords := textutil.Words(words)
+		for _, result := range results {
+			obs := result.Observation
+			snippet, _ := search.Highlight(obs.Text, matchesAny(queryWords, fuzziness), search.DefaultSnippetLength)
+			hits = append(hits, storage.SearchHit{
+				Kind:        storage.HitObservation,
+				EntityName:  result.EntityName,
+				EntityType:  result.EntityType,
+				Observation: &obs,
+				Fields:      []string{"text"},
+				Snippet:     snippet,
+				Score:       result.Score,
+			})
+		}
+	}
+	return hits, nil
+}
+
+// searchEntities returns the entities whose names, aliases or type match
+func (fs *FileStore) searchEntities(ctx context.Context, expr search.Expr, fuzziness int, filter storage.ObservationFilter) ([]storage.SearchHit, error) {
+	entities, err := fs.ListEntities(ctx, filter.EntityType)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []storage.SearchHit
+	for _, entity := range entities {
+		if !entity.MatchesAttributes(filter.Attributes) {
+			continue
+		}
+		fields := []hitField{{"name", entity.Name, nameWeight}}
+		for _, alias := range entity.Aliases {
+			fields = append(fields, hitField{"alias", alias, aliasWeight})
+		}
+		fields = append(fields, hitField{"type", entity.EntityType, entityTypeWeight})
+
+		m := &hitMatcher{fields: fields, fuzziness: fuzziness, matched: make(map[string]bool)}
+		m.matchField = func(f *search.Field) bool {
+			switch f.Name {
+			case search.FieldType:
+				return f.MatchesText(entity.EntityType) || fs.types.CanonicalType(f.Value) == entity.EntityType
+			case search.FieldEntity:
+				return slices.ContainsFunc(entity.Names(), func(name string) bool {
+					return f.MatchesText(name) || nearName(f.Value, name, fuzziness)
+				})
+			case search.FieldCreated:
+				return f.MatchesTime(entity.CreatedAt)
+			}
+			return false
+		}
+		ok, score := m.eval(expr, false)
+		if !ok {
+			continue
+		}
+
+		snippet := entity.Name + " (" + entity.EntityType + ")"
+		if len(entity.Aliases) > 0 {
+			snippet += ", also " + strings.Join(entity.Aliases, ", ")
+		}
+		snippet, _ = search.Highlight(snippet, m.highlights(), search.DefaultSnippetLength)
+		hits = append(hits, storage.SearchHit{
+			Kind:       storage.HitEntity,
+			EntityName: entity.Name,
+			EntityType: entity.EntityType,
+			Fields:     m.matchedFields(),
+			Snippet:    snippet,
+			Score:      score,
+		})
+	}
+	sortHits(hits)
+	return hits, nil
+}
+
+// searchRelations returns the relations whose type or endpoints match
+func (fs *FileStore) searchRelations(ctx context.Context, expr search.Expr, fuzziness int) ([]storage.SearchHit, error) {
+	relations, err := fs.GetRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []storage.SearchHit
+	for _, relation := range relations.Relations {
+		m := &hitMatcher{
+			fields: []hitField{
+				{"type", relation.RelationType, relationTypeWeight},
+				{"from", relation.From, endpointWeight},
+				{"to", relation.To, endpointWeight},
+			},
+			fuzziness: fuzziness,
+			matched:   make(map[string]bool),
+		}
+		m.matchField = func(f *search.Field) bool {
+			switch f.Name {
+			case search.FieldType:
+				return f.MatchesText(relation.RelationType)
+			case search.FieldEntity:
+				return f.MatchesText(relation.From) || f.MatchesText(relation.To)
+			case search.FieldSource:
+				return f.MatchesText(relation.Source)
+			case search.FieldCreated:
+				return f.MatchesTime(relation.CreatedAt)
+			}
+			return false
+		}
+		ok, score := m.eval(expr, false)
+		if !ok {
+			continue
+		}
+
+		snippet, _ := search.Highlight(relation.From+" -["+relation.RelationType+"]-> "+relation.To, m.highlights(), search.DefaultSnippetLength)
+		hits = append(hits, storage.SearchHit{
+			Kind:     storage.HitRelation,
+			Relation: &relation,
+			Fields:   m.matchedFields(),
+			Snippet:  snippet,
+			Score:    score,
+		})
+	}
+	sortHits(hits)
+	return hits, nil
+}
+
+// hitMatcher evaluates a query against the fields of an entity or relation
+type hitMatcher struct {
+	fields     []hitField
+	fuzziness  int
+	matchField func(f *search.Field) bool
+
+	// matched records the fields that query words outside NOT matched in,
+	// and words those query words, for highlighting
+	matched map[string]bool
+	words   []string
+}
+
+// eval reports whether the hit matches a query and its score, the sum over
+// the query's words of the best match weight times the field weight
+func (m *hitMatcher) eval(expr search.Expr, negated bool) (bool, float64) {
+	switch e := expr.(type) {
+	case *search.Term:
+		return m.evalTerm(e, negated)
+	case *search.Field:
+		return m.matchField(e), 0
+	case *search.And:
+		left, leftScore := m.eval(e.Left, negated)
+		if !left {
+			return false, 0
+		}
+		right, rightScore := m.eval(e.Right, negated)
+		return right, leftScore + rightScore
+	case *search.Or:
+		left, leftScore := m.eval(e.Left, negated)
+		right, rightScore := m.eval(e.Right, negated)
+		return left || right, leftScore + rightScore
+	case *search.Not:
+		matched, _ := m.eval(e.Expr, !negated)
+		return !matched, 0
+	}
+	return false, 0
+}
+
+func (m *hitMatcher) evalTerm(term *search.Term, negated bool) (bool, float64) {
+	words := textutil.Words(term.Text)
+	if len(words) == 0 {
+		// Stop words only: match the text as it is
+		for _, field := range m.fields {
+			if strings.Contains(textutil.Fold(field.value), textutil.Fold(term.Text)) {
+				return true, 0
+			}
+		}
+		return false, 0
+	}
+	if term.Phrase {
+		for _, field := range m.fields {
+			if search.ContainsPhrase(field.value, term.Text) {
+				m.record(negated, field.name, words)
+				return true, field.weight * float64(len(words))
+			}
+		}
+		return false, 0
+	}
+
+	score := 0.0
+	for _, word := range words {
+		best, bestField := 0.0, ""
+		for _, field := range m.fields {
+			for _, candidate := range textutil.Words(field.value) {
+				if weight := wordMatch(word, candidate, m.fuzziness) * field.weight; weight > best {
+					best, bestField = weight, field.name
+				}
+			}
+		}
+		if best == 0 {
+			return false, 0
+		}
+		m.record(negated, bestField, []string{word})
+		score += best
+	}
+	return true, score
+}
+
+// record notes a match outside NOT for the hit's fields and highlights
+func (m *hitMatcher) record(negated bool, field string, words []string) {
+	if !negated {
+		m.matched[field] = true
+		m.words = append(m.words, words...)
+	}
+}
+
+// matchedFields returns the fields matched, in the order they are declared
+func (m *hitMatcher) matchedFields() []string {
+	var fields []string
+	for _, field := range m.fields {
+		if m.matched[field.name] && !slices.Contains(fields, field.name) {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields
+}
+
+// highlights returns a matcher for the words of a snippet the query matched
+func (m *hitMatcher) highlights() func(word string) bool {
+	return matchesAny(m.words, m.fuzziness)
+}
+
+// matchesAny returns a matcher accepting words that match any query word
+func matchesAny(queryWords []string, fuzziness int) func(word string) bool {
+	return func(word string) bool {
+		for _, queryWord := range queryWords {
+			if wordMatch(queryWord, word, fuzziness) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// sortHits orders hits of one kind by score, then by name
+func sortHits(hits []storage.SearchHit) {
+	slices.SortStableFunc(hits, func(a, b storage.SearchHit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return strings.Compare(hitName(a), hitName(b))
+	})
+}
+
+func hitName(hit storage.SearchHit) string {
+	if hit.Relation != nil {
+		return hit.Relation.From + " " + hit.Relation.RelationType + " " + hit.Relation.To
+	}
+	return hit.EntityName
+}
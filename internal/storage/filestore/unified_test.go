@@ -0,0 +1,103 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+func TestSearchAll(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+
+	authService := models.NewEntity("auth_service", "service")
+	authService.AddObservation("Issues JWT tokens for the auth flow")
+	postgres := models.NewEntity("postgres", "database")
+	postgres.AddObservation("Stores the user accounts")
+	gateway := models.NewEntity("gateway", "service")
+	gateway.AddObservation("Routes requests to backends")
+	for _, entity := range []*models.Entity{authService, postgres, gateway} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+	for _, rel := range []models.Relation{
+		models.NewRelation("auth_service", "postgres", "depends_on"),
+		models.NewRelation("gateway", "auth_service", "depends_on"),
+		models.NewRelation("gateway", "postgres", "reads_from"),
+	} {
+		if _, _, err := fs.CreateRelation(ctx, &rel); err != nil {
+			t.Fatalf("Failed to create relation: %v", err)
+		}
+	}
+
+	hits, err := fs.SearchAll(ctx, "auth", nil, storage.ObservationFilter{})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	var kinds []string
+	for _, hit := range hits {
+		kinds = append(kinds, hit.Kind)
+	}
+	want := []string{storage.HitEntity, storage.HitRelation, storage.HitRelation, storage.HitObservation}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("Expected hits %v, got %+v", want, hits)
+	}
+	entity := hits[0]
+	if entity.EntityName != "auth_service" || !slices.Equal(entity.Fields, []string{"name"}) || entity.Snippet != "**auth**_service (service)" {
+		t.Errorf("Unexpected entity hit %+v", entity)
+	}
+	for _, hit := range hits[1:3] {
+		if hit.Relation == nil || hit.Relation.RelationType != "depends_on" {
+			t.Errorf("Expected the depends_on edges of auth_service, got %+v", hit)
+		}
+	}
+	if hits[1].Snippet != "**auth**_service -[depends_on]-> postgres" || !slices.Equal(hits[1].Fields, []string{"from"}) {
+		t.Errorf("Unexpected relation hit %+v", hits[1])
+	}
+	if hits[3].Observation == nil || hits[3].Snippet != "Issues JWT tokens for the **auth** flow" {
+		t.Errorf("Unexpected observation hit %+v", hits[3])
+	}
+
+	// Relation types, kinds and fields narrow the hits
+	hits, err = fs.SearchAll(ctx, "reads", []string{"relation"}, storage.ObservationFilter{})
+	if err != nil || len(hits) != 1 || !slices.Equal(hits[0].Fields, []string{"type"}) {
+		t.Errorf("Expected the reads_from relation, got %+v, %v", hits, err)
+	}
+	hits, err = fs.SearchAll(ctx, "type:service", []string{"entity"}, storage.ObservationFilter{})
+	if err != nil || len(hits) != 2 {
+		t.Errorf("Expected both services, got %+v, %v", hits, err)
+	}
+	hits, err = fs.SearchAll(ctx, "service -gateway", []string{"entity"}, storage.ObservationFilter{})
+	if err != nil || len(hits) != 1 || hits[0].EntityName != "auth_service" {
+		t.Errorf("Expected NOT to exclude the gateway, got %+v, %v", hits, err)
+	}
+
+	// Typos are tolerated as in observation search
+	hits, err = fs.SearchAll(ctx, "postgress", []string{"entity"}, storage.ObservationFilter{})
+	if err != nil || len(hits) != 1 || hits[0].EntityName != "postgres" {
+		t.Errorf("Expected a fuzzy name match, got %+v, %v", hits, err)
+	}
+
+	// Observation criteria leave only observation hits
+	hits, err = fs.SearchAll(ctx, "auth", nil, storage.ObservationFilter{Tags: []string{"security"}})
+	if err != nil || len(hits) != 0 {
+		t.Errorf("Expected no hits for an untagged store, got %+v, %v", hits, err)
+	}
+
+	for _, kinds := range [][]string{{"edge"}, nil} {
+		query := "auth"
+		if kinds == nil {
+			query = " "
+		}
+		if _, err := fs.SearchAll(ctx, query, kinds, storage.ObservationFilter{}); !errors.Is(err, storage.ErrInvalidInput) {
+			t.Errorf("Expected invalid input for kinds %v and query %q, got %v", kinds, query, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/embed"
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/search"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// DefaultMinSimilarity is the least cosine similarity a semantic match needs
+// unless configured
+const DefaultMinSimilarity = 0.15
+
+// embedBatchSize caps the observations sent to the embedder at once
+const embedBatchSize = 64
+
+// rrfK dampens the weight of top ranks when hybrid search fuses rankings
+// (reciprocal rank fusion); 60 is the customary value
+const rrfK = 60
+
+// vectorIndex holds an embedding per observation, saved to vectors.json so
+// they are only computed again when the text or the embedder changes
+type vectorIndex struct {
+	Embedder string                 `json:"embedder"`
+	Vectors  map[string]vectorEntry `json:"vectors"` // observation ID -> embedding
+}
+
+type vectorEntry struct {
+	Checksum string    `json:"checksum"` // of the text embedded
+	Vector   []float32 `json:"vector"`
+}
+
+// searchSemantic ranks observations by how similar they are in meaning to
+// the words and phrases of a query. Its fields and NOT clauses still apply
+// exactly; a query of only those is searched as it is.
+func (fs *FileStore) searchSemantic(ctx context.Context, expr search.Expr, filter storage.ObservationFilter) ([]storage.SearchResult, error) {
+	text, conditions := search.Split(expr)
+	if text == "" {
+		return fs.searchQuery(ctx, expr, nil, filter)
+	}
+	similar, err := fs.semanticScores(ctx, text)
+	if err != nil {
+		return nil, storage.NewStorageError("search", "observation", "", err)
+	}
+	return fs.searchQuery(ctx, conditions, similar, filter)
+}
+
+// searchHybrid runs a query both lexically and semantically and fuses the
+// rankings, so observations found both ways rank first. Scores are the sum
+// of 1/(rrfK + rank) over the rankings an observation appears in.
+func (fs *FileStore) searchHybrid(ctx context.Context, expr search.Expr, filter storage.ObservationFilter) ([]storage.SearchResult, error) {
+	lexical, err := fs.searchQuery(ctx, expr, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+	semantic, err := fs.searchSemantic(ctx, expr, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int) // observation ID -> index in results
+	var results []storage.SearchResult
+	for _, ranking := range [][]storage.SearchResult{lexical, semantic} {
+		for rank, result := range ranking {
+			score := 1 / float64(rrfK+rank+1)
+			if i, ok := positions[result.Observation.ID]; ok {
+				results[i].Score += score
+				continue
+			}
+			result.Score = score
+			positions[result.Observation.ID] = len(results)
+			results = append(results, result)
+		}
+	}
+	storage.SortSearchResults(results)
+	return results, nil
+}
+
+// SetEmbedder changes the embedder behind semantic search and the least
+// similarity a match needs. Stored vectors from another embedder are
+// recomputed on the next semantic search.
+func (fs *FileStore) SetEmbedder(embedder embed.Embedder, minSimilarity float64) {
+	fs.vectorMutex.Lock()
+	defer fs.vectorMutex.Unlock()
+	fs.embedder = embedder
+	fs.minSimilarity = minSimilarity
+	fs.vectors = nil
+}
+
+// semanticScores returns the cosine similarity of the observations at least
+// minSimilarity similar to text, embedding observations added or changed
+// since the last search first
+func (fs *FileStore) semanticScores(ctx context.Context, text string) (map[document]float64, error) {
+	entities, err := fs.ListEntities(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	fs.vectorMutex.Lock()
+	defer fs.vectorMutex.Unlock()
+	if err := fs.syncVectors(ctx, entities); err != nil {
+		return nil, err
+	}
+
+	query, err := fs.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	scores := make(map[document]float64)
+	for _, entity := range entities {
+		for i, obs := range entity.Observations {
+			entry, ok := fs.vectors.Vectors[obs.ID]
+			if !ok {
+				continue
+			}
+			if similarity := embed.Cosine(query[0], entry.Vector); similarity >= fs.minSimilarity {
+				scores[document{entity.Name, i}] = similarity
+			}
+		}
+	}
+	return scores, nil
+}
+
+// syncVectors loads the stored vectors, embeds observations that are new or
+// changed, drops those of removed observations and saves the result
+func (fs *FileStore) syncVectors(ctx context.Context, entities []*models.Entity) error {
+	if fs.vectors == nil {
+		fs.vectors = fs.loadVectorsFile()
+	}
+
+	var ids, texts []string
+	current := make(map[string]bool)
+	for _, entity := range entities {
+		for _, obs := range entity.Observations {
+			current[obs.ID] = true
+			if entry, ok := fs.vectors.Vectors[obs.ID]; !ok || entry.Checksum != checksum(obs.Text) {
+				ids = append(ids, obs.ID)
+				texts = append(texts, obs.Text)
+			}
+		}
+	}
+	changed := len(ids) > 0
+	for id := range fs.vectors.Vectors {
+		if !current[id] {
+			delete(fs.vectors.Vectors, id)
+			changed = true
+		}
+	}
+
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		vectors, err := fs.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed observations: %w", err)
+		}
+		for i, vector := range vectors {
+			fs.vectors.Vectors[ids[start+i]] = vectorEntry{Checksum: checksum(texts[start+i]), Vector: vector}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return fs.saveVectorsFile()
+}
+
+// loadVectorsFile reads the stored vectors, starting afresh if there are
+// none, they cannot be read or another embedder computed them
+func (fs *FileStore) loadVectorsFile() *vectorIndex {
+	fresh := &vectorIndex{Embedder: fs.embedder.Name(), Vectors: make(map[string]vectorEntry)}
+	data, err := os.ReadFile(fs.vectorsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "[FileStore] Failed to read vectors, recomputing them: %v\n", err)
+		}
+		return fresh
+	}
+	var stored vectorIndex
+	if err := json.Unmarshal(data, &stored); err != nil {
+		fmt.Fprintf(os.Stderr, "[FileStore] Failed to parse vectors, recomputing them: %v\n", err)
+		return fresh
+	}
+	if stored.Embedder != fresh.Embedder || stored.Vectors == nil {
+		return fresh
+	}
+	return &stored
+}
+
+func (fs *FileStore) saveVectorsFile() error {
+	data, err := json.Marshal(fs.vectors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vectors: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves torn vectors
+	tmpFile := fs.vectorsFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, fs.vectorsFile)
+}
+
+// checksum identifies the text a vector was computed from
+func checksum(text string) string {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
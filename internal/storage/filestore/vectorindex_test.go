@@ -0,0 +1,110 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/tr4d3r/ghcp-memory-context/internal/embed"
+	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/storage"
+)
+
+// countingEmbedder counts the texts it embeds
+type countingEmbedder struct {
+	*embed.HashEmbedder
+	embedded int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	return e.HashEmbedder.Embed(ctx, texts)
+}
+
+func TestSearchObservationsSemantic(t *testing.T) {
+	fs, tempDir := setupTestFileStore(t)
+	defer cleanup(tempDir)
+
+	ctx := context.Background()
+	embedder := &countingEmbedder{HashEmbedder: embed.NewHashEmbedder(0)}
+	fs.SetEmbedder(embedder, DefaultMinSimilarity)
+
+	db := models.NewEntity("orders-db", "database")
+	db.AddObservation("The database connection pool holds 20 connections")
+	db.AddObservation("Connection timeouts are logged as warnings")
+	style := models.NewEntity("style", "guideline")
+	style.AddObservation("The team prefers tabs over spaces")
+	style.AddObservation("Keep each connection helper in its own file")
+	for _, entity := range []*models.Entity{db, style} {
+		if err := fs.CreateEntity(ctx, entity); err != nil {
+			t.Fatalf("Failed to create entity: %v", err)
+		}
+	}
+
+	lexical, err := fs.SearchObservations(ctx, "DB pool", "")
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(lexical) != 0 {
+		t.Fatalf("Expected no lexical match for an abbreviation, got %+v", lexical)
+	}
+
+	semantic := storage.ObservationFilter{Mode: storage.SearchSemantic}
+	results, err := fs.SearchObservationsFiltered(ctx, "DB pool", semantic)
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) == 0 || results[0].Observation.Text != "The database connection pool holds 20 connections" {
+		t.Fatalf("Expected the database observation first, got %+v", results)
+	}
+	for _, result := range results {
+		if result.Observation.Text == "The team prefers tabs over spaces" {
+			t.Errorf("Expected unrelated observations to be left out, got %+v", results)
+		}
+	}
+	if embedder.embedded != 5 {
+		t.Errorf("Expected 4 observations and the query to be embedded, got %d", embedder.embedded)
+	}
+	if _, err := os.Stat(fs.vectorsFile); err != nil {
+		t.Errorf("Expected vectors to be saved: %v", err)
+	}
+
+	// Fields and NOT still apply exactly
+	results, err = fs.SearchObservationsFiltered(ctx, "connection -pool type:database", semantic)
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 1 || results[0].Observation.Text != "Connection timeouts are logged as warnings" {
+		t.Errorf("Expected conditions to restrict semantic matches, got %+v", results)
+	}
+
+	// Hybrid ranks observations found both ways first
+	results, err = fs.SearchObservationsFiltered(ctx, "connection", storage.ObservationFilter{Mode: storage.SearchHybrid})
+	if err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if len(results) != 3 || results[0].Score <= results[len(results)-1].Score {
+		t.Errorf("Expected 3 fused results ranked by score, got %+v", results)
+	}
+
+	// A new store only embeds what changed since the vectors were saved
+	reopened := NewFileStore(tempDir)
+	counting := &countingEmbedder{HashEmbedder: embed.NewHashEmbedder(0)}
+	reopened.SetEmbedder(counting, DefaultMinSimilarity)
+	style.AddObservation("Prefer small database migrations")
+	if err := reopened.UpdateEntity(ctx, style); err != nil {
+		t.Fatalf("Failed to update entity: %v", err)
+	}
+	if _, err := reopened.SearchObservationsFiltered(ctx, "migrations", semantic); err != nil {
+		t.Fatalf("Failed to search observations: %v", err)
+	}
+	if counting.embedded != 2 {
+		t.Errorf("Expected only the new observation and the query to be embedded, got %d", counting.embedded)
+	}
+
+	_, err = fs.SearchObservationsFiltered(ctx, "pool", storage.ObservationFilter{Mode: "vector"})
+	if !errors.Is(err, storage.ErrInvalidInput) {
+		t.Errorf("Expected an unknown mode to be invalid input, got %v", err)
+	}
+}
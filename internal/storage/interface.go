@@ -2,9 +2,14 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/tr4d3r/ghcp-memory-context/internal/models"
+	"github.com/tr4d3r/ghcp-memory-context/internal/schema"
+	"github.com/tr4d3r/ghcp-memory-context/internal/textutil"
 	"github.com/tr4d3r/ghcp-memory-context/pkg/types"
 )
 
@@ -40,23 +45,140 @@ type EntityStore interface {
 	// UpdateEntity updates an existing entity
 	UpdateEntity(ctx context.Context, entity *models.Entity) error
 
-	// DeleteEntity removes an entity by name
+	// DeleteEntity moves an entity to the trash under the default reject
+	// policy, failing with ErrReferenced while relations still reference it
 	DeleteEntity(ctx context.Context, name string) error
 
+	// DeleteEntityWithPolicy moves an entity to the trash, handling relations
+	// that reference it according to policy
+	DeleteEntityWithPolicy(ctx context.Context, name string, policy DeletePolicy) error
+
+	// ListTrash returns deleted entities that can still be restored, newest first
+	ListTrash(ctx context.Context) ([]TrashEntry, error)
+
+	// RestoreEntity restores the most recently deleted entity with the given
+	// name, along with any relations deleted with it
+	RestoreEntity(ctx context.Context, name string) (*models.Entity, error)
+
+	// PurgeTrash permanently removes trash entries past their retention window
+	// and returns the number removed
+	PurgeTrash(ctx context.Context) (int, error)
+
 	// ListEntities retrieves entities, optionally filtered by type
 	ListEntities(ctx context.Context, entityType string) ([]*models.Entity, error)
 
-	// EntityExists checks if an entity exists
+	// ListEntitiesFiltered retrieves entities matching the filter
+	ListEntitiesFiltered(ctx context.Context, filter EntityFilter) ([]*models.Entity, error)
+
+	// EntityExists checks if an entity exists under the given name or alias
 	EntityExists(name string) bool
 
+	// ResolveEntityName returns the canonical name of the entity that name
+	// refers to, matching aliases and ignoring case and separators
+	ResolveEntityName(ctx context.Context, name string) (string, error)
+
+	// RenameEntity renames an entity, rewriting relations that reference it
+	// and keeping the old name as an alias
+	RenameEntity(ctx context.Context, oldName, newName string) (*models.Entity, error)
+
+	// MergeEntities moves the source entity's observations and relations into
+	// the target, deletes the source and keeps its name as an alias
+	MergeEntities(ctx context.Context, sourceName, targetName string) (*models.Entity, error)
+
+	// TypeRegistry returns the registered entity types; an empty registry
+	// accepts any type
+	TypeRegistry() *schema.Registry
+
+	// RelationTypeRegistry returns the registered relation types with their
+	// inverses and allowed entity types
+	RelationTypeRegistry() *schema.RelationRegistry
+
 	// SearchObservations searches for observations across entities
 	SearchObservations(ctx context.Context, query string, entityType string) ([]SearchResult, error)
 
+	// SearchObservationsFiltered searches for observations matching the query and filter
+	SearchObservationsFiltered(ctx context.Context, query string, filter ObservationFilter) ([]SearchResult, error)
+
+	// SuggestSearch returns corrections of a query that find results, for
+	// "did you mean" hints when it finds nothing
+	SuggestSearch(ctx context.Context, query string, filter ObservationFilter) ([]string, error)
+
+	// SearchAll searches entity names and types, relations and observations,
+	// returning hits of the given kinds (all if none)
+	SearchAll(ctx context.Context, query string, kinds []string, filter ObservationFilter) ([]SearchHit, error)
+
+	// PurgeExpiredObservations removes observations that expired more than grace ago
+	// and returns the number of observations removed
+	PurgeExpiredObservations(ctx context.Context, grace time.Duration) (int, error)
+
 	// GetRelations retrieves all relations
 	GetRelations(ctx context.Context) (*models.RelationSet, error)
 
-	// SaveRelations saves the relation set
+	// GetRelation retrieves a relation by ID
+	GetRelation(ctx context.Context, id string) (*models.Relation, error)
+
+	// GetEntityRelations retrieves the relations starting or ending at an entity
+	GetEntityRelations(ctx context.Context, name string) ([]models.Relation, error)
+
+	// QueryRelations retrieves the relations matching a filter
+	QueryRelations(ctx context.Context, filter RelationFilter) ([]models.Relation, error)
+
+	// SaveRelations saves the relation set, rejecting new relations that
+	// duplicate the endpoints and type of another
 	SaveRelations(ctx context.Context, relations *models.RelationSet) error
+
+	// CreateRelation stores a relation unless one with the same endpoints and
+	// type exists, in which case the existing relation is returned and created
+	// is false
+	CreateRelation(ctx context.Context, relation *models.Relation) (stored *models.Relation, created bool, err error)
+
+	// UpdateRelation replaces the relation with the same ID
+	UpdateRelation(ctx context.Context, relation *models.Relation) error
+
+	// DeleteRelation removes a relation by ID
+	DeleteRelation(ctx context.Context, id string) error
+
+	// DedupeRelations removes relations that repeat the endpoints and type of
+	// another and returns the removed relations
+	DedupeRelations(ctx context.Context) ([]models.Relation, error)
+}
+
+// DeletePolicy controls what happens to relations that reference a deleted entity
+type DeletePolicy string
+
+const (
+	// DeleteReject refuses to delete an entity that relations still reference
+	DeleteReject DeletePolicy = "reject"
+
+	// DeleteCascade deletes referencing relations along with the entity; they
+	// are kept in the trash and restored with it
+	DeleteCascade DeletePolicy = "cascade"
+
+	// DeleteDetach deletes only the entity, leaving its relations in place so
+	// they reattach if the entity is restored or recreated
+	DeleteDetach DeletePolicy = "detach"
+)
+
+// ParseDeletePolicy converts a policy name, defaulting to DeleteReject when empty
+func ParseDeletePolicy(value string) (DeletePolicy, error) {
+	switch policy := DeletePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return DeleteReject, nil
+	case DeleteReject, DeleteCascade, DeleteDetach:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: unknown delete policy '%s' (use reject, cascade or detach)", ErrInvalidInput, value)
+	}
+}
+
+// TrashEntry is a deleted entity kept for restoring within the retention window
+type TrashEntry struct {
+	ID        string            `json:"id"`
+	Entity    *models.Entity    `json:"entity"`
+	Relations []models.Relation `json:"relations,omitempty"`
+	Policy    DeletePolicy      `json:"policy"`
+	DeletedAt time.Time         `json:"deletedAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
 }
 
 // SearchResult represents a search result from observation queries
@@ -64,6 +186,254 @@ type SearchResult struct {
 	EntityName  string             `json:"entityName"`
 	EntityType  string             `json:"entityType"`
 	Observation models.Observation `json:"observation"`
+
+	// Score is the BM25 relevance of the observation to the query, its cosine
+	// similarity in semantic searches or the fused rank score in hybrid ones;
+	// zero when the search had no query terms
+	Score float64 `json:"score,omitempty"`
+}
+
+// Kinds of unified search hit
+const (
+	HitEntity      = "entity"
+	HitRelation    = "relation"
+	HitObservation = "observation"
+)
+
+// HitKinds lists the kinds of unified search hit, in the order they are
+// returned
+var HitKinds = []string{HitEntity, HitRelation, HitObservation}
+
+// SearchHit is a unified search result: an entity, a relation or an
+// observation
+type SearchHit struct {
+	Kind string `json:"kind"`
+
+	// The entity hit, or the entity the observation hit belongs to
+	EntityName string `json:"entityName,omitempty"`
+	EntityType string `json:"entityType,omitempty"`
+
+	Observation *models.Observation `json:"observation,omitempty"`
+	Relation    *models.Relation    `json:"relation,omitempty"`
+
+	// Fields the query matched: name, alias or type for entities, type,
+	// from or to for relations and text for observations
+	Fields []string `json:"fields,omitempty"`
+
+	// Snippet shows the matched text with the matches marked **like this**
+	Snippet string `json:"snippet"`
+
+	// Score ranks hits of the same kind
+	Score float64 `json:"score,omitempty"`
+}
+
+// ParseHitKinds validates the kinds of hit a unified search should return,
+// where none means all of them
+func ParseHitKinds(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return HitKinds, nil
+	}
+	kinds := make([]string, 0, len(values))
+	for _, value := range values {
+		kind := strings.ToLower(value)
+		if !slices.Contains(HitKinds, kind) {
+			return nil, fmt.Errorf("kinds must be among %s, got '%s'", strings.Join(HitKinds, ", "), value)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// LimitHits keeps at most limit hits of each kind, so many entity hits do
+// not crowd out observations; 0 or less keeps them all
+func LimitHits(hits []SearchHit, limit int) []SearchHit {
+	if limit <= 0 {
+		return hits
+	}
+	counts := make(map[string]int)
+	limited := make([]SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		if counts[hit.Kind] < limit {
+			counts[hit.Kind]++
+			limited = append(limited, hit)
+		}
+	}
+	return limited
+}
+
+// ObservationFilter defines filtering options for observation queries
+type ObservationFilter struct {
+	// Filter by the owning entity's type
+	EntityType string
+
+	// Observation must carry all of these tags
+	Tags []string
+
+	// Observation metadata must contain all of these key/value pairs
+	Metadata map[string]string
+
+	// Include observations whose expiry has passed (hidden by default)
+	IncludeExpired bool
+
+	// Include observations replaced by a newer observation (hidden by default)
+	IncludeSuperseded bool
+
+	// Observation importance must be at least this value (0 disables)
+	MinImportance int
+
+	// Only include pinned observations
+	PinnedOnly bool
+
+	// Observation provenance must reference this file (or directory, with a trailing slash)
+	FilePath string
+
+	// Observation provenance must reference this symbol
+	Symbol string
+
+	// Observation provenance must reference this repository
+	Repository string
+
+	// The owning entity's attributes must match all of these key/value pairs
+	Attributes map[string]string
+
+	// Most edits a search word may be from a stored word and still match it
+	// (0 disables typo tolerance; nil uses the store's default)
+	Fuzziness *int
+
+	// How the query matches: SearchLexical (the default), SearchSemantic or
+	// SearchHybrid
+	Mode string
+}
+
+// Search modes
+const (
+	SearchLexical  = "lexical"  // words, ranked by BM25
+	SearchSemantic = "semantic" // meaning, ranked by embedding similarity
+	SearchHybrid   = "hybrid"   // both, ranks fused
+)
+
+// SearchModes lists the valid ObservationFilter.Mode values
+var SearchModes = []string{SearchLexical, SearchSemantic, SearchHybrid}
+
+// ParseSearchMode validates a search mode, where empty means lexical
+func ParseSearchMode(value string) (string, error) {
+	mode := strings.ToLower(value)
+	if mode == "" {
+		return SearchLexical, nil
+	}
+	if !slices.Contains(SearchModes, mode) {
+		return "", fmt.Errorf("mode must be one of %s, got '%s'", strings.Join(SearchModes, ", "), value)
+	}
+	return mode, nil
+}
+
+// HasObservationCriteria reports whether the filter restricts individual
+// observations (as opposed to only restricting the entity type)
+func (f ObservationFilter) HasObservationCriteria() bool {
+	return len(f.Tags) > 0 || len(f.Metadata) > 0 || f.MinImportance > 0 || f.PinnedOnly ||
+		f.HasProvenanceCriteria()
+}
+
+// HasProvenanceCriteria reports whether the filter restricts observations by code location
+func (f ObservationFilter) HasProvenanceCriteria() bool {
+	return f.FilePath != "" || f.Symbol != "" || f.Repository != ""
+}
+
+// Matches reports whether an observation satisfies the filter's observation criteria
+func (f ObservationFilter) Matches(obs models.Observation) bool {
+	if !f.IncludeExpired && obs.IsExpired(time.Now()) {
+		return false
+	}
+	if !f.IncludeSuperseded && obs.IsSuperseded() {
+		return false
+	}
+	if len(f.Tags) > 0 && !obs.HasAllTags(f.Tags) {
+		return false
+	}
+	if len(f.Metadata) > 0 && !obs.MatchesMetadata(f.Metadata) {
+		return false
+	}
+	if f.MinImportance > 0 && obs.EffectiveImportance() < f.MinImportance {
+		return false
+	}
+	if f.PinnedOnly && !obs.Pinned {
+		return false
+	}
+	if f.HasProvenanceCriteria() {
+		prov := obs.Provenance
+		if prov == nil {
+			return false
+		}
+		if f.FilePath != "" && !prov.MatchesFile(f.FilePath) {
+			return false
+		}
+		if f.Symbol != "" && prov.Symbol != f.Symbol {
+			return false
+		}
+		if f.Repository != "" && prov.Repository != f.Repository {
+			return false
+		}
+	}
+	return true
+}
+
+// RelationFilter defines filtering options for relation queries
+type RelationFilter struct {
+	From         string
+	To           string
+	RelationType string
+
+	// Relation properties must contain all of these key/value pairs
+	Properties map[string]string
+
+	// Relation weight must be at least this value (0 disables)
+	MinWeight float64
+
+	// Relation must have been learned from this source
+	Source string
+
+	// Relation must hold at this time (nil disables)
+	ValidAt *time.Time
+}
+
+// Matches reports whether a relation satisfies the filter
+func (f RelationFilter) Matches(rel models.Relation) bool {
+	if f.From != "" && rel.From != f.From {
+		return false
+	}
+	if f.To != "" && rel.To != f.To {
+		return false
+	}
+	if f.RelationType != "" && textutil.NormalizeName(rel.RelationType) != textutil.NormalizeName(f.RelationType) {
+		return false
+	}
+	if !rel.MatchesProperties(f.Properties) {
+		return false
+	}
+	if f.MinWeight > 0 && rel.Weight < f.MinWeight {
+		return false
+	}
+	if f.Source != "" && rel.Source != f.Source {
+		return false
+	}
+	if f.ValidAt != nil && !rel.IsValidAt(*f.ValidAt) {
+		return false
+	}
+	return true
+}
+
+// SortSearchResults orders results by score, then by observation rank
+// (pinned, importance, confidence), keeping the existing order for ties
+func SortSearchResults(results []SearchResult) {
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return models.CompareObservations(a.Observation, b.Observation)
+	})
 }
 
 // ContextStore defines operations for generic context objects
@@ -127,7 +497,10 @@ type Transaction interface {
 type EntityFilter struct {
 	// Filter by entity fields
 	EntityType *string
-	Owner      *string
+	Owner      *string // matches the "owner" attribute
+
+	// Entity attributes must match all of these key/value pairs
+	Attributes map[string]string
 
 	// Filter by time ranges
 	CreatedAfter  *time.Time
@@ -145,6 +518,59 @@ type EntityFilter struct {
 	SortOrder string // "asc" or "desc"
 }
 
+// Matches reports whether an entity satisfies the filter's criteria. The
+// entity type is compared as given; callers canonicalize it first.
+func (f EntityFilter) Matches(entity *models.Entity) bool {
+	if f.EntityType != nil && *f.EntityType != "" && entity.EntityType != *f.EntityType {
+		return false
+	}
+	if f.Owner != nil && *f.Owner != "" && !entity.MatchesAttributes(map[string]string{"owner": *f.Owner}) {
+		return false
+	}
+	if !entity.MatchesAttributes(f.Attributes) {
+		return false
+	}
+	if f.CreatedAfter != nil && !entity.CreatedAt.After(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !entity.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	if f.SearchQuery != nil && *f.SearchQuery != "" && len(entity.SearchObservations(*f.SearchQuery)) == 0 {
+		return false
+	}
+	return true
+}
+
+// Apply sorts and paginates matching entities. Entities are sorted by
+// name unless SortBy is "createdAt" or "lastModified".
+func (f EntityFilter) Apply(entities []*models.Entity) []*models.Entity {
+	compare := func(a, b *models.Entity) int { return strings.Compare(a.Name, b.Name) }
+	switch f.SortBy {
+	case "createdAt":
+		compare = func(a, b *models.Entity) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case "lastModified":
+		compare = func(a, b *models.Entity) int { return a.LastModified.Compare(b.LastModified) }
+	}
+	slices.SortStableFunc(entities, func(a, b *models.Entity) int {
+		if f.SortOrder == "desc" {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+
+	if f.Offset > 0 {
+		if f.Offset >= len(entities) {
+			return nil
+		}
+		entities = entities[f.Offset:]
+	}
+	if f.Limit > 0 && len(entities) > f.Limit {
+		entities = entities[:f.Limit]
+	}
+	return entities
+}
+
 // ContextFilter defines filtering options for context queries
 type ContextFilter struct {
 	Type      *types.ContextType
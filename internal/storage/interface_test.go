@@ -0,0 +1,17 @@
+package storage
+
+import "testing"
+
+func TestLimitHits(t *testing.T) {
+	hits := []SearchHit{
+		{Kind: HitEntity}, {Kind: HitEntity},
+		{Kind: HitRelation},
+		{Kind: HitObservation}, {Kind: HitObservation},
+	}
+	if got := LimitHits(hits, 1); len(got) != 3 {
+		t.Errorf("Expected one hit of each kind, got %+v", got)
+	}
+	if got := LimitHits(hits, 0); len(got) != len(hits) {
+		t.Errorf("Expected no limit, got %+v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// stopWords are common English words too frequent to be worth indexing
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "so": true, "such": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "was": true,
+	"were": true, "will": true, "with": true,
+}
+
+// IsStopWord reports whether a lowercase word is too common to index
+func IsStopWord(word string) bool {
+	return stopWords[word]
+}
+
+// Fold lowercases text and strips diacritics, so "Café" and "cafe" compare
+// equal. Compatibility characters such as ligatures are decomposed too.
+func Fold(text string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFKD.String(text) {
+		if !unicode.Is(unicode.Mn, r) {
+			folded.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return folded.String()
+}
+
+// Words splits text into search words. Words are split like identifiers, so
+// "AuthService" yields the same words as "auth service", then folded, and
+// stop words are dropped.
+func Words(text string) []string {
+	var words []string
+	for _, word := range Tokenize(norm.NFC.String(text)) {
+		for _, folded := range Tokenize(Fold(word)) {
+			if !IsStopWord(folded) {
+				words = append(words, folded)
+			}
+		}
+	}
+	return words
+}
+
+// Terms splits text into search terms: its Words, stemmed
+func Terms(text string) []string {
+	words := Words(text)
+	for i, word := range words {
+		words[i] = Stem(word)
+	}
+	return words
+}
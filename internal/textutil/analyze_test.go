@@ -0,0 +1,59 @@
+package textutil
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"hopping":        "hop",
+		"hoping":         "hope",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"electrical":     "electr",
+		"adoption":       "adopt",
+		"controlling":    "control",
+		"commits":        "commit",
+		"configuration":  "configur",
+		"configured":     "configur",
+		"go":             "go",
+		"café":           "café",
+	}
+
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	if got := Fold("Crème Brûlée ﬁle"); got != "creme brulee file" {
+		t.Errorf("Fold() = %q", got)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := map[string][]string{
+		"Use the conventional commits":       {"us", "convent", "commit"},
+		"AuthService connects to Postgres16": {"auth", "servic", "connect", "postgres16"},
+		"Résumé parsing":                     {"resum", "pars"},
+		"the and of":                         nil,
+	}
+
+	for text, want := range tests {
+		if got := Terms(text); !slices.Equal(got, want) {
+			t.Errorf("Terms(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package textutil
+
+// DefaultFuzziness is the most edits a misspelled word may be from the word
+// it matches
+const DefaultFuzziness = 2
+
+// MaxEdits returns how many edits a word may be from another and still
+// match it: none for words of up to three letters, one for up to seven and
+// two beyond, capped at tolerance. Short words allow fewer edits because one
+// edit turns them into too many other words.
+func MaxEdits(word string, tolerance int) int {
+	var edits int
+	switch n := len([]rune(word)); {
+	case n < 4:
+		edits = 0
+	case n < 8:
+		edits = 1
+	default:
+		edits = 2
+	}
+	return max(min(edits, tolerance), 0)
+}
+
+// WithinEdits reports whether two words are at most edits apart, checking
+// their lengths before computing the distance
+func WithinEdits(a, b string, edits int) (int, bool) {
+	if diff := len([]rune(a)) - len([]rune(b)); diff > edits || -diff > edits {
+		return 0, false
+	}
+	distance := Levenshtein(a, b)
+	return distance, distance <= edits
+}
+
+// FuzzyContains reports whether every word of query is in text, or a word
+// in text within MaxEdits of it
+func FuzzyContains(text, query string, tolerance int) bool {
+	want := Words(query)
+	if len(want) == 0 {
+		return false
+	}
+	words := Words(text)
+	for _, w := range want {
+		edits := MaxEdits(w, tolerance)
+		found := false
+		for _, word := range words {
+			if _, ok := WithinEdits(w, word, edits); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package textutil
+
+import "testing"
+
+func TestMaxEdits(t *testing.T) {
+	tests := []struct {
+		word      string
+		tolerance int
+		want      int
+	}{
+		{"api", 2, 0},
+		{"redis", 2, 1},
+		{"postgress", 2, 2},
+		{"postgress", 1, 1},
+		{"postgress", 0, 0},
+		{"brûlée", 2, 1},
+	}
+
+	for _, tt := range tests {
+		if got := MaxEdits(tt.word, tt.tolerance); got != tt.want {
+			t.Errorf("MaxEdits(%q, %d) = %d, want %d", tt.word, tt.tolerance, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyContains(t *testing.T) {
+	text := "Authentication tokens are rotated by the Postgres job"
+	if !FuzzyContains(text, "authentcation postgress", DefaultFuzziness) {
+		t.Error("Expected misspelled words to match")
+	}
+	if FuzzyContains(text, "authentcation postgress", 0) {
+		t.Error("Expected tolerance 0 to require exact words")
+	}
+	if FuzzyContains(text, "tokens redis", DefaultFuzziness) {
+		t.Error("Expected every word to be required")
+	}
+}
@@ -0,0 +1,119 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits an identifier into lowercase words at separators and
+// camelCase boundaries, so "AuthService", "auth_service" and "auth-service"
+// all yield ["auth", "service"]
+func Tokenize(name string) []string {
+	var tokens []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if i > 0 && len(current) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Split "authService" before S and "HTTPServer" before the final S
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return tokens
+}
+
+// NormalizeName returns the case- and separator-insensitive key for a name
+func NormalizeName(name string) string {
+	return strings.Join(Tokenize(name), "")
+}
+
+// Levenshtein returns the edit distance between two strings
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// NameSimilarity scores how likely two names refer to the same thing, from 0
+// (unrelated) to 1 (identical after normalization). Names whose words are
+// abbreviations of each other, such as "auth_service" and
+// "authentication-service", score highly even when their spelling differs.
+func NameSimilarity(a, b string) float64 {
+	tokensA, tokensB := Tokenize(a), Tokenize(b)
+	keyA, keyB := strings.Join(tokensA, ""), strings.Join(tokensB, "")
+	if keyA == "" || keyB == "" {
+		return 0
+	}
+	if keyA == keyB {
+		return 1
+	}
+
+	if abbreviates(tokensA, tokensB) {
+		return 0.9
+	}
+
+	longest := max(len([]rune(keyA)), len([]rune(keyB)))
+	return 1 - float64(Levenshtein(keyA, keyB))/float64(longest)
+}
+
+// abbreviates reports whether the names have the same words, allowing each
+// word to be a prefix of at least three letters of its counterpart
+func abbreviates(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		short, long := a[i], b[i]
+		if len(short) > len(long) {
+			short, long = long, short
+		}
+		if short != long && (len(short) < 3 || !strings.HasPrefix(long, short)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package textutil
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string][]string{
+		"auth_service":           {"auth", "service"},
+		"AuthService":            {"auth", "service"},
+		"authentication-service": {"authentication", "service"},
+		"HTTPServer":             {"http", "server"},
+		"oauth2Client":           {"oauth2", "client"},
+		"  spaced  name ":        {"spaced", "name"},
+		"---":                    nil,
+	}
+
+	for input, want := range tests {
+		if got := Tokenize(input); !slices.Equal(got, want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for _, name := range []string{"auth_service", "AuthService", "auth-service", "Auth Service"} {
+		if got := NormalizeName(name); got != "authservice" {
+			t.Errorf("NormalizeName(%q) = %q, want %q", name, got, "authservice")
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if got := NameSimilarity("auth_service", "AuthService"); got != 1 {
+		t.Errorf("Expected identical normalized names to score 1, got %f", got)
+	}
+	if got := NameSimilarity("auth_service", "authentication-service"); got < 0.8 {
+		t.Errorf("Expected abbreviation to score highly, got %f", got)
+	}
+	if got := NameSimilarity("user_service", "users_service"); got < 0.8 {
+		t.Errorf("Expected near spelling to score highly, got %f", got)
+	}
+	if got := NameSimilarity("auth_service", "billing_database"); got >= 0.5 {
+		t.Errorf("Expected unrelated names to score low, got %f", got)
+	}
+}
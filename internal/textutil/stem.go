@@ -0,0 +1,222 @@
+package textutil
+
+import "strings"
+
+// Stem reduces a lowercase English word to its stem with the Porter
+// algorithm, so "connected", "connecting" and "connections" all yield
+// "connect". Words of one or two letters and words with non-ASCII letters
+// are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = stemStep1a(w)
+	w = stemStep1b(w)
+	w = stemStep1c(w)
+	w = stemStep2(w)
+	w = stemStep3(w)
+	w = stemStep4(w)
+	w = stemStep5(w)
+	return string(w)
+}
+
+// isConsonant reports whether the letter at i is a consonant; y is a
+// consonant at the start of a word or after a vowel
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in a stem, m in [C](VC)^m[V]
+func measure(w []byte) int {
+	m, i := 0, 0
+	for i < len(w) && isConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !isConsonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		m++
+		for i < len(w) && isConsonant(w, i) {
+			i++
+		}
+	}
+	return m
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports whether a stem ends consonant-vowel-consonant where the
+// last consonant is not w, x or y, as in "hop" but not "snow"
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+// replaceSuffix swaps a suffix for a replacement if the remaining stem
+// satisfies cond, reporting whether the word had the suffix at all
+func replaceSuffix(w *[]byte, suffix, replacement string, cond func([]byte) bool) bool {
+	if !hasSuffix(*w, suffix) {
+		return false
+	}
+	stem := (*w)[:len(*w)-len(suffix)]
+	if cond == nil || cond(stem) {
+		*w = append(stem, replacement...)
+	}
+	return true
+}
+
+func measureAbove(n int) func([]byte) bool {
+	return func(stem []byte) bool { return measure(stem) > n }
+}
+
+func stemStep1a(w []byte) []byte {
+	for _, rule := range [][2]string{{"sses", "ss"}, {"ies", "i"}, {"ss", "ss"}, {"s", ""}} {
+		if replaceSuffix(&w, rule[0], rule[1], nil) {
+			break
+		}
+	}
+	return w
+}
+
+func stemStep1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		replaceSuffix(&w, "eed", "ee", measureAbove(0))
+		return w
+	}
+
+	stripped := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if hasSuffix(w, suffix) && hasVowel(w[:len(w)-len(suffix)]) {
+			w = w[:len(w)-len(suffix)]
+			stripped = true
+			break
+		}
+	}
+	if !stripped {
+		return w
+	}
+
+	switch {
+	case hasSuffix(w, "at"), hasSuffix(w, "bl"), hasSuffix(w, "iz"):
+		w = append(w, 'e')
+	case endsDoubleConsonant(w) && !hasSuffix(w, "l") && !hasSuffix(w, "s") && !hasSuffix(w, "z"):
+		w = w[:len(w)-1]
+	case measure(w) == 1 && endsCVC(w):
+		w = append(w, 'e')
+	}
+	return w
+}
+
+func stemStep1c(w []byte) []byte {
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+var stemStep2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+var stemStep3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+// stemApplyLongest applies the rule with the longest matching suffix
+func stemApplyLongest(w []byte, rules [][2]string, cond func([]byte) bool) []byte {
+	best := -1
+	for i, rule := range rules {
+		if hasSuffix(w, rule[0]) && (best < 0 || len(rule[0]) > len(rules[best][0])) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		replaceSuffix(&w, rules[best][0], rules[best][1], cond)
+	}
+	return w
+}
+
+func stemStep2(w []byte) []byte {
+	return stemApplyLongest(w, stemStep2Rules, measureAbove(0))
+}
+
+func stemStep3(w []byte) []byte {
+	return stemApplyLongest(w, stemStep3Rules, measureAbove(0))
+}
+
+var stemStep4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func stemStep4(w []byte) []byte {
+	rules := make([][2]string, len(stemStep4Suffixes))
+	for i, suffix := range stemStep4Suffixes {
+		rules[i] = [2]string{suffix, ""}
+	}
+	return stemApplyLongest(w, rules, func(stem []byte) bool {
+		if measure(stem) <= 1 {
+			return false
+		}
+		// "ion" is only removed after s or t, as in "adoption"
+		if hasSuffix(w, "ion") && len(stem) == len(w)-3 {
+			return hasSuffix(stem, "s") || hasSuffix(stem, "t")
+		}
+		return true
+	})
+}
+
+func stemStep5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || m == 1 && !endsCVC(stem) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && endsDoubleConsonant(w) && hasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}